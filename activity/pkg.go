@@ -1,6 +1,7 @@
 package activity
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"time"
@@ -33,7 +34,7 @@ type Activity struct {
 	Meta Meta            `json:"meta"`
 }
 
-var activityStore *stores.ActivityStore
+var activityStore stores.ActivityStore
 
 func Setup() error {
 	storesClient := stores.Get()
@@ -45,7 +46,7 @@ func Setup() error {
 	return nil
 }
 
-func (a *Activity) Save() error {
+func (a *Activity) Save(ctx context.Context) error {
 	if activityStore == nil {
 		return errors.New("activity store not initialized")
 	}
@@ -59,5 +60,5 @@ func (a *Activity) Save() error {
 	// convert when to mongo datetime
 	activityMap["when"] = a.When.UTC()
 
-	return activityStore.Create(activityMap)
+	return activityStore.Create(ctx, activityMap)
 }
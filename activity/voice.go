@@ -0,0 +1,105 @@
+package activity
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// stored is an activity as it is saved, with who reduced to the member's id
+type stored struct {
+	Who  string    `bson:"who"`
+	When time.Time `bson:"when"`
+	Meta struct {
+		What  ActivityType `bson:"what"`
+		Where *string      `bson:"where"`
+	} `bson:"meta"`
+}
+
+// VoiceSession is a stretch of time a member spent in one voice channel
+type VoiceSession struct {
+	MemberId  string
+	ChannelId string
+	Start     time.Time
+	End       time.Time
+}
+
+// VoiceSessions rebuilds everyone's time in voice channels between from and
+// to. Activity from lookback before from is read too, so members who joined
+// before the window are known to be in their channel. Anyone who was already
+// in voice when the bot started tracking can't be seen.
+func VoiceSessions(ctx context.Context, from time.Time, to time.Time, lookback time.Duration) ([]VoiceSession, error) {
+	if activityStore == nil {
+		return nil, errors.New("activity store not initialized")
+	}
+
+	cur, err := activityStore.List(ctx, bson.D{
+		{Key: "when", Value: bson.D{{Key: "$gte", Value: from.Add(-lookback).UTC()}, {Key: "$lt", Value: to.UTC()}}},
+		{Key: "meta.what", Value: bson.D{{Key: "$in", Value: bson.A{string(VoiceJoin), string(VoiceSwitch), string(VoiceLeave), string(VoiceAFK)}}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	activities := []stored{}
+	if err := cur.All(ctx, &activities); err != nil {
+		return nil, err
+	}
+
+	sessions := []VoiceSession{}
+	open := map[string]*VoiceSession{}
+	closeSession := func(memberId string, when time.Time) {
+		session, ok := open[memberId]
+		if !ok {
+			return
+		}
+		delete(open, memberId)
+
+		session.End = when
+		if session.Start.Before(from) {
+			session.Start = from
+		}
+		if session.End.After(session.Start) {
+			sessions = append(sessions, *session)
+		}
+	}
+
+	for _, a := range activities {
+		closeSession(a.Who, a.When)
+
+		if a.Meta.What == VoiceLeave || a.Meta.Where == nil {
+			continue
+		}
+		open[a.Who] = &VoiceSession{
+			MemberId:  a.Who,
+			ChannelId: *a.Meta.Where,
+			Start:     a.When,
+		}
+	}
+
+	// whoever is still in a channel stayed until the end of the window
+	for memberId := range open {
+		closeSession(memberId, to)
+	}
+
+	return sessions, nil
+}
+
+// VoiceTime adds up how long each member spent in any of the channels
+func VoiceTime(sessions []VoiceSession, channelIds []string) map[string]time.Duration {
+	inChannels := map[string]bool{}
+	for _, channelId := range channelIds {
+		inChannels[channelId] = true
+	}
+
+	times := map[string]time.Duration{}
+	for _, session := range sessions {
+		if inChannels[session.ChannelId] {
+			times[session.MemberId] += session.End.Sub(session.Start)
+		}
+	}
+	return times
+}
@@ -0,0 +1,143 @@
+package attendance
+
+import (
+	"context"
+	"math"
+
+	"github.com/sol-armada/sol-bot/settings"
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
+)
+
+// Role is what a member did at the event
+type Role string
+
+const (
+	Host        Role = "host"
+	Leader      Role = "leader"
+	Participant Role = "participant"
+	Support     Role = "support"
+)
+
+// Roles are the attendance roles in the order they are shown
+var Roles = []Role{Host, Leader, Participant, Support}
+
+// roleNames are what the attendees in each role are shown under
+var roleNames = map[Role]string{
+	Host:        "Hosts",
+	Leader:      "Leaders",
+	Participant: "Attendees",
+	Support:     "Support",
+}
+
+// EventType is the kind of event a record is for
+type EventType string
+
+const (
+	Training  EventType = "training"
+	Operation EventType = "operation"
+	Social    EventType = "social"
+)
+
+// EventTypes are the event types in the order they are offered
+var EventTypes = []EventType{Training, Operation, Social}
+
+// how far apart records have to be created to count separately
+const overlapHours = 8
+
+func (r Role) String() string {
+	return cases.Title(language.English).String(string(r))
+}
+
+func (t EventType) String() string {
+	return cases.Title(language.English).String(string(t))
+}
+
+// CreditWeights are how much attending each type of event in each role counts
+// towards promotions. They are set under features.attendance.credits by type
+// then role, falling back to a weight for the role alone, then 1.
+type CreditWeights map[string]interface{}
+
+// LoadCreditWeights reads the credit weights from the settings
+func LoadCreditWeights() CreditWeights {
+	weights := CreditWeights{}
+	if err := settings.UnmarshalKey("FEATURES.ATTENDANCE.CREDITS", &weights); err != nil {
+		return CreditWeights{}
+	}
+	return weights
+}
+
+// Weight is how much attending an event of the type in the role counts
+func (w CreditWeights) Weight(eventType EventType, role Role) float64 {
+	if byRole, ok := w[string(eventType)].(map[string]interface{}); ok && eventType != "" {
+		if weight, ok := toWeight(byRole[string(role)]); ok {
+			return weight
+		}
+	}
+	if weight, ok := toWeight(w[string(role)]); ok {
+		return weight
+	}
+	return 1
+}
+
+func toWeight(value interface{}) (float64, bool) {
+	switch weight := value.(type) {
+	case int:
+		return float64(weight), true
+	case int64:
+		return float64(weight), true
+	case float64:
+		return weight, true
+	}
+	return 0, false
+}
+
+// GetMemberAttendanceCredits is the member's attendance weighted by the type of
+// each event and their role in it. Records within 8 hours of the one before
+// them overlap, and each run of overlapping records counts once at the best
+// weight in it.
+func GetMemberAttendanceCredits(ctx context.Context, memberId string) (int, error) {
+	attended, err := ListRecorded(ctx, memberId)
+	if err != nil {
+		return 0, err
+	}
+
+	weights := LoadCreditWeights()
+	credits := 0.0
+	best := 0.0
+	for i, record := range attended {
+		overlaps := i > 0 && math.RoundToEven(record.DateCreated.Sub(attended[i-1].DateCreated).Hours()) <= overlapHours
+		if !overlaps {
+			credits += best
+			best = 0
+		}
+
+		if weight := weights.Weight(record.Type, record.Role(memberId)); weight > best {
+			best = weight
+		}
+	}
+	credits += best
+
+	return int(math.Floor(credits)), nil
+}
+
+// Role is what the member did at the event, participant unless set otherwise
+func (a *Attendance) Role(memberId string) Role {
+	if role, ok := a.Roles[memberId]; ok {
+		return role
+	}
+	return Participant
+}
+
+// SetRole sets what the member did at the event
+func (a *Attendance) SetRole(memberId string, role Role) {
+	if role == Participant || role == "" {
+		delete(a.Roles, memberId)
+		return
+	}
+
+	if a.Roles == nil {
+		a.Roles = map[string]Role{}
+	}
+	a.Roles[memberId] = role
+}
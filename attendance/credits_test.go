@@ -0,0 +1,123 @@
+package attendance
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sol-armada/sol-bot/members"
+	"github.com/sol-armada/sol-bot/settings"
+)
+
+func setCreditWeights(t *testing.T) {
+	t.Helper()
+
+	settings.Reset()
+	t.Cleanup(settings.Reset)
+	settings.Set("FEATURES.ATTENDANCE.CREDITS", map[string]interface{}{
+		"host":      2,
+		"operation": map[string]interface{}{"host": 3},
+		"social":    map[string]interface{}{"participant": 0.5},
+	})
+}
+
+func TestCreditWeights(t *testing.T) {
+	setCreditWeights(t)
+	weights := LoadCreditWeights()
+
+	tests := []struct {
+		name      string
+		eventType EventType
+		role      Role
+		want      float64
+	}{
+		{"type and role", Operation, Host, 3},
+		{"role alone", Training, Host, 2},
+		{"no type", "", Host, 2},
+		{"fraction", Social, Participant, 0.5},
+		{"not set", Training, Participant, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := weights.Weight(tt.eventType, tt.role); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetMemberAttendanceCredits(t *testing.T) {
+	start := time.Date(2024, 5, 1, 19, 0, 0, 0, time.UTC)
+
+	type attended struct {
+		offset    time.Duration
+		eventType EventType
+		role      Role
+	}
+
+	tests := []struct {
+		name     string
+		attended []attended
+		want     int
+	}{
+		{"none", nil, 0},
+		{"single record", []attended{{0, Training, Participant}}, 1},
+		{"host in overlap", []attended{{0, Training, Participant}, {2 * time.Hour, Operation, Host}}, 3},
+		{"host first in overlap", []attended{{0, Operation, Host}, {2 * time.Hour, Training, Participant}}, 3},
+		{"apart", []attended{{0, Operation, Host}, {24 * time.Hour, Training, Participant}}, 4},
+		{"exactly 8 hours overlaps", []attended{{0, Training, Participant}, {8 * time.Hour, Training, Participant}}, 1},
+		{"overlaps chain", []attended{{0, Training, Participant}, {6 * time.Hour, Training, Participant}, {12 * time.Hour, Operation, Host}}, 3},
+		{"fractions add up", []attended{{0, Social, Participant}, {24 * time.Hour, Social, Participant}, {48 * time.Hour, Social, Participant}}, 1},
+		{"out of order", []attended{{24 * time.Hour, Training, Participant}, {0, Training, Participant}}, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			setCreditWeights(t)
+			setupMemory(t)
+
+			one := &members.Member{Id: "1", Name: "one"}
+			two := &members.Member{Id: "2", Name: "two"}
+			for _, member := range []*members.Member{one, two} {
+				if err := member.Save(ctx); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			record := func(offset time.Duration, eventType EventType, recorded bool, attendees ...*members.Member) *Attendance {
+				a := New("event", one)
+				a.DateCreated = start.Add(offset)
+				a.Type = eventType
+				a.Recorded = recorded
+				a.Members = attendees
+				return a
+			}
+
+			records := []*Attendance{
+				// neither unrecorded records nor other members' records count
+				record(1000*time.Hour, Operation, false, one),
+				record(2000*time.Hour, Operation, true, two),
+			}
+			for _, a := range tt.attended {
+				r := record(a.offset, a.eventType, true, one, two)
+				r.SetRole("1", a.role)
+				records = append(records, r)
+			}
+			for _, a := range records {
+				if err := a.Save(ctx); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got, err := GetMemberAttendanceCredits(ctx, "1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %d credits, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"regexp"
 	"sort"
 	"strings"
@@ -25,6 +26,16 @@ type Attendance struct {
 	Members     []*members.Member `json:"members"`
 	WithIssues  []*members.Member `json:"with_issues" bson:"with_issues"`
 	Recorded    bool              `json:"recorded"`
+	// Minutes is how long each member was in voice for, by member id, when the
+	// record was taken from voice activity
+	Minutes map[string]int `json:"minutes" bson:"minutes"`
+	// EventId is the scheduled event the record was opened for
+	EventId string `json:"event_id,omitempty" bson:"event_id,omitempty"`
+	// Type is the kind of event the record is for
+	Type EventType `json:"type" bson:"type"`
+	// Roles are what each member did at the event by member id. Anyone not in
+	// it was a participant.
+	Roles map[string]Role `json:"roles" bson:"roles"`
 
 	ChannelId string `json:"channel_id" bson:"channel_id"`
 	MessageId string `json:"message_id" bson:"message_id"`
@@ -37,7 +48,7 @@ var (
 	ErrAttendanceNotFound = errors.New("attendance not found")
 )
 
-var attendanceStore *stores.AttendanceStore
+var attendanceStore stores.AttendanceStore
 
 func Setup() error {
 	storesClient := stores.Get()
@@ -61,8 +72,8 @@ func New(name string, submittedBy *members.Member) *Attendance {
 	return attendance
 }
 
-func Get(id string) (*Attendance, error) {
-	cur, err := attendanceStore.Get(id)
+func Get(ctx context.Context, id string) (*Attendance, error) {
+	cur, err := attendanceStore.Get(ctx, id)
 	if err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
 			return nil, ErrAttendanceNotFound
@@ -73,11 +84,14 @@ func Get(id string) (*Attendance, error) {
 
 	attendance := &Attendance{}
 
-	for cur.Next(context.TODO()) {
+	for cur.Next(ctx) {
 		if err := cur.Decode(attendance); err != nil {
 			return nil, err
 		}
 	}
+	if err := cur.Err(); err != nil {
+		return nil, err
+	}
 
 	if attendance.Id == "" {
 		return nil, ErrAttendanceNotFound
@@ -86,18 +100,24 @@ func Get(id string) (*Attendance, error) {
 	return attendance, nil
 }
 
-func GetFromMessage(message *discordgo.Message) (*Attendance, error) {
+func GetFromMessage(ctx context.Context, message *discordgo.Message) (*Attendance, error) {
 	// get the Id from the footer of the embed
 	// Last Updated 00-00-00T00:00:00Z (1234567890)
 	reg := regexp.MustCompile(`Last Updated .*?\((.*?)\)`)
 
 	attendanceId := reg.FindStringSubmatch(message.Embeds[0].Footer.Text)[1]
-	cur, err := attendanceStore.Get(attendanceId)
+	cur, err := attendanceStore.Get(ctx, attendanceId)
 	if err != nil {
 		return nil, err
 	}
 
 	attendance := &Attendance{}
+	if !cur.Next(ctx) {
+		if err := cur.Err(); err != nil {
+			return nil, err
+		}
+		return nil, ErrAttendanceNotFound
+	}
 	if err := cur.Decode(attendance); err != nil {
 		return nil, err
 	}
@@ -105,7 +125,7 @@ func GetFromMessage(message *discordgo.Message) (*Attendance, error) {
 	return attendance, nil
 }
 
-func NewFromThreadMessages(threadMessages []*discordgo.Message) (*Attendance, error) {
+func NewFromThreadMessages(ctx context.Context, threadMessages []*discordgo.Message) (*Attendance, error) {
 	mainMessage := threadMessages[len(threadMessages)-1].ReferencedMessage
 	attendanceMessage := threadMessages[len(threadMessages)-2]
 
@@ -128,7 +148,7 @@ func NewFromThreadMessages(threadMessages []*discordgo.Message) (*Attendance, er
 		memberid = strings.ReplaceAll(memberid, ">", "")
 		memberid = strings.Split(memberid, ":")[0]
 
-		member, err := members.Get(memberid)
+		member, err := members.Get(ctx, memberid)
 		if err != nil {
 			return nil, err
 		}
@@ -139,57 +159,86 @@ func NewFromThreadMessages(threadMessages []*discordgo.Message) (*Attendance, er
 	return attendance, nil
 }
 
-func ListActive(limit int) ([]*Attendance, error) {
-	cur, err := attendanceStore.List(bson.M{"recorded": bson.M{"$eq": false}}, limit, 0)
+func ListActive(ctx context.Context, limit int) ([]*Attendance, error) {
+	cur, err := attendanceStore.List(ctx, bson.M{"recorded": bson.M{"$eq": false}}, limit, 0)
 	if err != nil {
 		return nil, err
 	}
 
 	var attendances []*Attendance
 
-	for cur.Next(context.TODO()) {
+	for cur.Next(ctx) {
 		attendance := &Attendance{}
 		if err := cur.Decode(attendance); err != nil {
 			return nil, err
 		}
 		attendances = append(attendances, attendance)
 	}
+	if err := cur.Err(); err != nil {
+		return nil, err
+	}
 
 	return attendances, nil
 }
 
-func List(filter interface{}, limit int, page int) ([]*Attendance, error) {
-	cur, err := attendanceStore.List(filter, limit, page)
+func List(ctx context.Context, filter interface{}, limit int, page int) ([]*Attendance, error) {
+	cur, err := attendanceStore.List(ctx, filter, limit, page)
 	if err != nil {
 		return nil, err
 	}
 
 	var attendances []*Attendance
 
-	for cur.Next(context.TODO()) {
+	for cur.Next(ctx) {
 		attendance := &Attendance{}
 		if err := cur.Decode(attendance); err != nil {
 			return nil, err
 		}
 		attendances = append(attendances, attendance)
 	}
+	if err := cur.Err(); err != nil {
+		return nil, err
+	}
 
 	return attendances, nil
 }
 
-func GetMemberAttendanceCount(memberId string) (int, error) {
-	return attendanceStore.GetCount(memberId)
+// ListRecorded returns the recorded attendance the member is in, oldest first
+func ListRecorded(ctx context.Context, memberId string) ([]*Attendance, error) {
+	cur, err := attendanceStore.ListRecorded(ctx, memberId)
+	if err != nil {
+		return nil, err
+	}
+
+	var attendances []*Attendance
+
+	for cur.Next(ctx) {
+		attendance := &Attendance{}
+		if err := cur.Decode(attendance); err != nil {
+			return nil, err
+		}
+		attendances = append(attendances, attendance)
+	}
+	if err := cur.Err(); err != nil {
+		return nil, err
+	}
+
+	return attendances, nil
 }
 
-func GetMemberAttendanceRecords(memberId string) ([]*Attendance, error) {
+func GetMemberAttendanceCount(ctx context.Context, memberId string) (int, error) {
+	return attendanceStore.GetCount(ctx, memberId)
+}
+
+func GetMemberAttendanceRecords(ctx context.Context, memberId string) ([]*Attendance, error) {
 	records := []*Attendance{}
 
-	cur, err := attendanceStore.List(bson.D{}, 0, 0)
+	cur, err := attendanceStore.List(ctx, bson.D{}, 0, 0)
 	if err != nil {
 		return nil, err
 	}
 
-	for cur.Next(context.TODO()) {
+	for cur.Next(ctx) {
 		attendance := &Attendance{}
 		if err := cur.Decode(attendance); err != nil {
 			return nil, err
@@ -202,6 +251,9 @@ func GetMemberAttendanceRecords(memberId string) ([]*Attendance, error) {
 			}
 		}
 	}
+	if err := cur.Err(); err != nil {
+		return nil, err
+	}
 
 	return records, nil
 }
@@ -233,10 +285,13 @@ func (a *Attendance) RemoveMember(member *members.Member) {
 		}
 	}
 
+	delete(a.Minutes, member.Id)
+	delete(a.Roles, member.Id)
+
 	a.removeDuplicates()
 }
 
-func (a *Attendance) RecheckIssues() error {
+func (a *Attendance) RecheckIssues(ctx context.Context) error {
 	attendees := []*members.Member{}
 	for _, member := range a.Members {
 		issues := Issues(member)
@@ -262,7 +317,7 @@ func (a *Attendance) RecheckIssues() error {
 
 	a.removeDuplicates()
 
-	return a.Save()
+	return a.Save(ctx)
 }
 
 func (a *Attendance) ToDiscordMessage() *discordgo.MessageSend {
@@ -271,11 +326,14 @@ func (a *Attendance) ToDiscordMessage() *discordgo.MessageSend {
 			Name:  "Submitted By",
 			Value: "<@" + a.SubmittedBy.Id + ">",
 		},
-		{
-			Name:   "Attendees",
-			Value:  "",
+	}
+	if a.Type != "" {
+		fields[0].Inline = true
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:   "Type",
+			Value:  a.Type.String(),
 			Inline: true,
-		},
+		})
 	}
 
 	sort.Slice(a.Members, func(i, j int) bool {
@@ -314,27 +372,38 @@ func (a *Attendance) ToDiscordMessage() *discordgo.MessageSend {
 		return false
 	})
 
-	i := 0
+	// group the attendees by their role, keeping them sorted
+	byRole := map[Role][]*members.Member{}
 	for _, member := range a.Members {
-		// for every 10 members, make a new field
-		if i%10 == 0 && i != 0 {
-			fields = append(fields, &discordgo.MessageEmbedField{
-				Name:   "Attendees (continued)",
-				Value:  "",
-				Inline: true,
-			})
-		}
+		role := a.Role(member.Id)
+		byRole[role] = append(byRole[role], member)
+	}
 
-		field := fields[len(fields)-1]
-		field.Value += "<@" + member.Id + ">"
+	for _, role := range Roles {
+		name := roleNames[role]
+		for i, member := range byRole[role] {
+			// for every 10 members, make a new field
+			if i%10 == 0 {
+				fields = append(fields, &discordgo.MessageEmbedField{
+					Name:   name,
+					Value:  "",
+					Inline: true,
+				})
+				name = roleNames[role] + " (continued)"
+			}
+
+			field := fields[len(fields)-1]
+			field.Value += "<@" + member.Id + ">" + a.minutes(member.Id)
 
-		// if not the 10th, add a new line
-		if i%10 != 9 {
-			field.Value += "\n"
+			// if not the 10th, add a new line
+			if i%10 != 9 {
+				field.Value += "\n"
+			}
 		}
-		i++
 	}
 
+	i := 0
+
 	if len(a.WithIssues) > 0 {
 		fields = append(fields, &discordgo.MessageEmbedField{
 			Name:   "Attendees with Issues",
@@ -346,7 +415,7 @@ func (a *Attendance) ToDiscordMessage() *discordgo.MessageSend {
 		for _, member := range a.WithIssues {
 			field := fields[len(fields)-1]
 
-			field.Value += "<@" + member.Id + "> - " + strings.Join(Issues(member), ", ")
+			field.Value += "<@" + member.Id + ">" + a.minutes(member.Id) + " - " + strings.Join(Issues(member), ", ")
 
 			// if not the 10th, add a new line
 			if i%10 != 9 {
@@ -415,12 +484,21 @@ func (a *Attendance) ToDiscordMessage() *discordgo.MessageSend {
 	}
 }
 
-func (a *Attendance) Record() error {
+// minutes is how long the member was in voice for, if known
+func (a *Attendance) minutes(memberId string) string {
+	minutes, ok := a.Minutes[memberId]
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf(" (%d min)", minutes)
+}
+
+func (a *Attendance) Record(ctx context.Context) error {
 	a.Recorded = true
-	return a.Save()
+	return a.Save(ctx)
 }
 
-func (a *Attendance) Save() error {
+func (a *Attendance) Save(ctx context.Context) error {
 	if attendanceStore == nil {
 		return errors.New("attendance store not found")
 	}
@@ -454,7 +532,7 @@ func (a *Attendance) Save() error {
 	// convert date_updated to mongo datetime
 	attendanceMap["date_updated"] = a.DateUpdated.UTC()
 
-	return attendanceStore.Upsert(a.Id, attendanceMap)
+	return attendanceStore.Upsert(ctx, a.Id, attendanceMap)
 }
 
 func (a *Attendance) removeDuplicates() {
@@ -477,6 +555,6 @@ func (a *Attendance) removeDuplicates() {
 	}
 }
 
-func (a *Attendance) Delete() error {
-	return attendanceStore.Delete(a.Id)
+func (a *Attendance) Delete(ctx context.Context) error {
+	return attendanceStore.Delete(ctx, a.Id)
 }
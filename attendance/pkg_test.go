@@ -0,0 +1,61 @@
+package attendance
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sol-armada/sol-bot/members"
+	"github.com/sol-armada/sol-bot/stores"
+)
+
+func setupMemory(t *testing.T) {
+	t.Helper()
+
+	stores.NewMemory(context.Background())
+	if err := members.Setup(); err != nil {
+		t.Fatal(err)
+	}
+	if err := Setup(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSaveClearsRoles(t *testing.T) {
+	ctx := context.Background()
+	setupMemory(t)
+
+	host := &members.Member{Id: "1", Name: "one"}
+	if err := host.Save(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	a := New("event", host)
+	a.Type = Operation
+	a.Members = []*members.Member{host}
+	a.Minutes = map[string]int{"1": 60}
+	a.SetRole("1", Host)
+	if err := a.Save(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	a.Type = ""
+	a.Minutes = nil
+	a.SetRole("1", Participant)
+	if err := a.Save(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	stored, err := Get(ctx, a.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if role := stored.Role("1"); role != Participant {
+		t.Errorf("role is %s after clearing it, want participant", role)
+	}
+	if stored.Type != "" {
+		t.Errorf("type is %q after clearing it", stored.Type)
+	}
+	if len(stored.Minutes) != 0 {
+		t.Errorf("minutes are %v after clearing them", stored.Minutes)
+	}
+}
@@ -0,0 +1,31 @@
+package attendance
+
+import (
+	"context"
+
+	"github.com/sol-armada/sol-bot/members"
+	"github.com/sol-armada/sol-bot/ranks"
+	"github.com/sol-armada/sol-bot/settings"
+)
+
+// EvaluatePromotion checks the member against the requirements of the rank
+// above theirs. Events are counted by their attendance credits.
+func EvaluatePromotion(ctx context.Context, member *members.Member) (ranks.Evaluation, int, error) {
+	count, err := GetMemberAttendanceCredits(ctx, member.Id)
+	if err != nil {
+		return ranks.Evaluation{}, 0, err
+	}
+
+	candidate := ranks.Candidate{
+		Rank:           member.Rank,
+		Events:         count,
+		MeritScore:     member.MeritScore(),
+		ActiveDemerits: len(member.ActiveDemerits()),
+		Validated:      member.Validated,
+	}
+	if timeInRank, ok := member.TimeInRank(); ok {
+		candidate.TimeInRank = &timeInRank
+	}
+
+	return ranks.Evaluate(candidate, settings.GetIntWithDefault("FEATURES.ATTENDANCE.MIN_DAYS_IN_RANK", 0)), count, nil
+}
@@ -33,7 +33,7 @@ func Issues(member *members.Member) []string {
 		issues = append(issues, "redacted org")
 	}
 
-	if member.RSIMember && member.Rank <= ranks.Technician && member.PrimaryOrg != settings.GetString("rsi_org_sid") {
+	if member.RSIMember && primaryOrgRequired(member.Rank) && member.PrimaryOrg != settings.GetString("rsi_org_sid") {
 		issues = append(issues, "bad primary org")
 	}
 
@@ -67,3 +67,14 @@ func Issues(member *members.Member) []string {
 
 	return issues
 }
+
+// primaryOrgRequired is if the rank has to have the org as their primary. It
+// is checked for members without a rank too, who only have the org as an
+// affiliation at best.
+func primaryOrgRequired(rank ranks.Rank) bool {
+	if rank == ranks.None {
+		return true
+	}
+	definition, _ := rank.Definition()
+	return definition.PrimaryOrg
+}
@@ -0,0 +1,262 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/rs/xid"
+	"github.com/sol-armada/sol-bot/stores"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type Action string
+
+const (
+	AttendanceCreated      Action = "attendance_created"
+	AttendanceUpdated      Action = "attendance_updated"
+	AttendanceRecorded     Action = "attendance_recorded"
+	AttendanceDeleted      Action = "attendance_deleted"
+	MeritGiven             Action = "merit_given"
+	DemeritGiven           Action = "demerit_given"
+	MeritRevoked           Action = "merit_revoked"
+	DemeritRevoked         Action = "demerit_revoked"
+	DemeritEscalated       Action = "demerit_escalated"
+	EscalationAcknowledged Action = "escalation_acknowledged"
+	EscalationDismissed    Action = "escalation_dismissed"
+	MeritRedeemed          Action = "merit_redeemed"
+	MeritTransferred       Action = "merit_transferred"
+	RedemptionFulfilled    Action = "redemption_fulfilled"
+	RedemptionRefunded     Action = "redemption_refunded"
+	MemberValidated        Action = "member_validated"
+	MemberOnboarded        Action = "member_onboarded"
+	OnboardingReviewed     Action = "onboarding_reviewed"
+	RecruiterSet           Action = "recruiter_set"
+	RankChanged            Action = "rank_changed"
+	PromotionDenied        Action = "promotion_denied"
+	AffiliationChanged     Action = "affiliation_changed"
+	MemberArchived         Action = "member_archived"
+	MemberRestored         Action = "member_restored"
+	EventCreated           Action = "event_created"
+	EventUpdated           Action = "event_updated"
+	EventCancelled         Action = "event_cancelled"
+)
+
+type TargetType string
+
+const (
+	MemberTarget     TargetType = "member"
+	AttendanceTarget TargetType = "attendance"
+	EventTarget      TargetType = "event"
+)
+
+// Actor is who made the change
+type Actor struct {
+	Id   string
+	Name string
+}
+
+// System is the actor for changes the bot makes on its own, like the member
+// monitor
+var System = Actor{Id: "system", Name: "Sol Bot"}
+
+// Target is what was changed
+type Target struct {
+	Type TargetType
+	Id   string
+	Name string
+}
+
+// Change is a single field that differs between the before and after
+type Change struct {
+	Field  string `json:"field" bson:"field"`
+	Before any    `json:"before" bson:"before"`
+	After  any    `json:"after" bson:"after"`
+}
+
+type Entry struct {
+	Id         string     `json:"id" bson:"_id"`
+	ActorId    string     `json:"actor_id" bson:"actor_id"`
+	ActorName  string     `json:"actor_name" bson:"actor_name"`
+	Action     Action     `json:"action" bson:"action"`
+	TargetType TargetType `json:"target_type" bson:"target_type"`
+	TargetId   string     `json:"target_id" bson:"target_id"`
+	TargetName string     `json:"target_name" bson:"target_name"`
+	Changes    []Change   `json:"changes" bson:"changes"`
+	When       time.Time  `json:"when" bson:"when"`
+}
+
+// fields that change on every save and only add noise to a diff
+var ignoredFields = map[string]bool{
+	"updated":      true,
+	"date_updated": true,
+	"version":      true,
+}
+
+var auditStore stores.AuditStore
+
+func Setup() error {
+	storesClient := stores.Get()
+	as, ok := storesClient.GetAuditStore()
+	if !ok {
+		return errors.New("audit store not found")
+	}
+	auditStore = as
+	return nil
+}
+
+// Record saves an entry for the change the actor made to the target. Before is
+// nil for things that were created and after is nil for things that were
+// deleted.
+func Record(ctx context.Context, actor Actor, action Action, target Target, before, after any) (*Entry, error) {
+	if auditStore == nil {
+		return nil, errors.New("audit store not initialized")
+	}
+
+	entry := &Entry{
+		Id:         xid.New().String(),
+		ActorId:    actor.Id,
+		ActorName:  actor.Name,
+		Action:     action,
+		TargetType: target.Type,
+		TargetId:   target.Id,
+		TargetName: target.Name,
+		Changes:    Diff(before, after),
+		When:       time.Now().UTC(),
+	}
+
+	if err := auditStore.Create(ctx, entry); err != nil {
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+// Diff compares the json form of before and after field by field
+func Diff(before, after any) []Change {
+	beforeMap := toMap(before)
+	afterMap := toMap(after)
+
+	fields := map[string]bool{}
+	for k := range beforeMap {
+		fields[k] = true
+	}
+	for k := range afterMap {
+		fields[k] = true
+	}
+
+	changes := []Change{}
+	for field := range fields {
+		if ignoredFields[field] {
+			continue
+		}
+
+		b, a := beforeMap[field], afterMap[field]
+		if reflect.DeepEqual(b, a) {
+			continue
+		}
+
+		changes = append(changes, Change{Field: field, Before: b, After: a})
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+
+	return changes
+}
+
+func toMap(v any) map[string]interface{} {
+	m := map[string]interface{}{}
+	if v == nil || (reflect.ValueOf(v).Kind() == reflect.Pointer && reflect.ValueOf(v).IsNil()) {
+		return m
+	}
+
+	j, _ := json.Marshal(v)
+	_ = json.Unmarshal(j, &m)
+
+	return m
+}
+
+// ListForMember returns the entries where the member made the change or was
+// the one changed
+func ListForMember(ctx context.Context, memberId string, limit int, page int) ([]*Entry, error) {
+	return list(ctx, bson.D{{Key: "$or", Value: bson.A{
+		bson.D{{Key: "target_id", Value: memberId}},
+		bson.D{{Key: "actor_id", Value: memberId}},
+	}}}, limit, page)
+}
+
+// ListForTarget returns the entries for a single record
+func ListForTarget(ctx context.Context, targetId string, limit int, page int) ([]*Entry, error) {
+	return list(ctx, bson.D{{Key: "target_id", Value: targetId}}, limit, page)
+}
+
+func list(ctx context.Context, filter interface{}, limit int, page int) ([]*Entry, error) {
+	if auditStore == nil {
+		return nil, errors.New("audit store not initialized")
+	}
+
+	cur, err := auditStore.List(ctx, filter, limit, page)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []*Entry{}
+	for cur.Next(ctx) {
+		entry := &Entry{}
+		if err := cur.Decode(entry); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	if err := cur.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+func (c Change) String() string {
+	return c.Field + ": " + formatValue(c.Before) + " → " + formatValue(c.After)
+}
+
+func formatValue(v any) string {
+	if v == nil {
+		return "none"
+	}
+
+	j, err := json.Marshal(plain(v))
+	if err != nil {
+		return "?"
+	}
+
+	return string(j)
+}
+
+// plain turns the documents and arrays bson decodes into interface{} values
+// back into maps and slices so they print like the json they were saved from
+func plain(v any) any {
+	switch t := v.(type) {
+	case bson.D:
+		m := map[string]any{}
+		for _, e := range t {
+			m[e.Key] = plain(e.Value)
+		}
+		return m
+	case bson.M:
+		m := map[string]any{}
+		for k, e := range t {
+			m[k] = plain(e)
+		}
+		return m
+	case bson.A:
+		a := make([]any, len(t))
+		for i, e := range t {
+			a[i] = plain(e)
+		}
+		return a
+	}
+	return v
+}
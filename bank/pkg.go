@@ -0,0 +1,338 @@
+package bank
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/rs/xid"
+	"github.com/sol-armada/sol-bot/stores"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+var (
+	InsufficientBalance = errors.New("insufficient balance")
+	AlreadySettled      = errors.New("redemption was already settled")
+	RedemptionNotFound  = errors.New("redemption not found")
+	InvalidTransfer     = errors.New("merits can only be transferred in positive amounts to someone else")
+)
+
+// Account is which of a member's balances an entry moves
+type Account string
+
+const (
+	// Allowance is what a holder has left to give out as merits
+	Allowance Account = "allowance"
+	// Balance is what a member has earned from merits and can redeem
+	Balance Account = "balance"
+)
+
+type Kind string
+
+const (
+	Allowanced Kind = "allowance"
+	Spent      Kind = "spent"
+	Earned     Kind = "earned"
+	Clawback   Kind = "clawback"
+	Redeemed   Kind = "redeemed"
+	Fulfilled  Kind = "fulfilled"
+	Refunded   Kind = "refunded"
+	Cancelled  Kind = "cancelled"
+	Sent       Kind = "sent"
+	Received   Kind = "received"
+)
+
+// Entry is a single movement on a member's account. Entries are never changed
+// or removed, a mistake is undone with another entry.
+type Entry struct {
+	Id       string  `json:"id" bson:"_id"`
+	MemberId string  `json:"member_id" bson:"member_id"`
+	Account  Account `json:"account" bson:"account"`
+	Kind     Kind    `json:"kind" bson:"kind"`
+	// Amount is positive for money in and negative for money out
+	Amount int    `json:"amount" bson:"amount"`
+	Reason string `json:"reason" bson:"reason"`
+	// RelatedId is the merit or redemption the entry is for
+	RelatedId string    `json:"related_id" bson:"related_id"`
+	ActorId   string    `json:"actor_id" bson:"actor_id"`
+	When      time.Time `json:"when" bson:"when"`
+}
+
+// Reward is something in the catalog members can redeem their balance for
+type Reward struct {
+	Name        string `mapstructure:"name"`
+	Cost        int    `mapstructure:"cost"`
+	Description string `mapstructure:"description"`
+}
+
+var ledgerStore stores.LedgerStore
+
+// balances are read then written, so every change goes through this lock to
+// keep two at once from spending the same merits
+var mu sync.Mutex
+
+func Setup() error {
+	storesClient := stores.Get()
+	ls, ok := storesClient.GetLedgerStore()
+	if !ok {
+		return errors.New("ledger store not found")
+	}
+	ledgerStore = ls
+	return nil
+}
+
+func newEntry(memberId string, account Account, kind Kind, amount int, reason string, relatedId string, actorId string) *Entry {
+	return &Entry{
+		Id:        xid.New().String(),
+		MemberId:  memberId,
+		Account:   account,
+		Kind:      kind,
+		Amount:    amount,
+		Reason:    reason,
+		RelatedId: relatedId,
+		ActorId:   actorId,
+		When:      time.Now().UTC(),
+	}
+}
+
+func record(ctx context.Context, entries ...*Entry) error {
+	if ledgerStore == nil {
+		return errors.New("ledger store not initialized")
+	}
+
+	for _, entry := range entries {
+		if err := ledgerStore.Create(ctx, entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func list(ctx context.Context, filter interface{}, limit int, page int) ([]*Entry, error) {
+	if ledgerStore == nil {
+		return nil, errors.New("ledger store not initialized")
+	}
+
+	cur, err := ledgerStore.List(ctx, filter, limit, page)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	entries := []*Entry{}
+	if err := cur.All(ctx, &entries); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// GetBalance adds up the member's entries on the account
+func GetBalance(ctx context.Context, memberId string, account Account) (int, error) {
+	entries, err := list(ctx, bson.D{{Key: "member_id", Value: memberId}, {Key: "account", Value: string(account)}}, 0, 0)
+	if err != nil {
+		return 0, err
+	}
+
+	balance := 0
+	for _, entry := range entries {
+		balance += entry.Amount
+	}
+	return balance, nil
+}
+
+// Ledger lists the member's entries on both accounts, newest first
+func Ledger(ctx context.Context, memberId string, limit int, page int) ([]*Entry, error) {
+	return list(ctx, bson.D{{Key: "member_id", Value: memberId}}, limit, page)
+}
+
+// TopUpAllowance gives the holder their allowance if they haven't had one
+// within the period
+func TopUpAllowance(ctx context.Context, holderId string, amount int, period time.Duration) error {
+	if amount <= 0 {
+		return nil
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	latest, err := list(ctx, bson.D{{Key: "member_id", Value: holderId}, {Key: "kind", Value: string(Allowanced)}}, 1, 1)
+	if err != nil {
+		return err
+	}
+	if len(latest) > 0 && time.Since(latest[0].When) < period {
+		return nil
+	}
+
+	return record(ctx, newEntry(holderId, Allowance, Allowanced, amount, "periodic allowance", "", ""))
+}
+
+// Spend takes the merit's worth out of the holder's allowance and pays it into
+// the receiver's balance
+func Spend(ctx context.Context, holderId string, receiverId string, amount int, meritId string) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	allowance, err := GetBalance(ctx, holderId, Allowance)
+	if err != nil {
+		return err
+	}
+	if allowance < amount {
+		return InsufficientBalance
+	}
+
+	return record(ctx,
+		newEntry(holderId, Allowance, Spent, -amount, "gave a merit", meritId, holderId),
+		newEntry(receiverId, Balance, Earned, amount, "received a merit", meritId, holderId),
+	)
+}
+
+// Earn pays the merit's worth into the receiver's balance, for merits given by
+// officers who don't spend an allowance
+func Earn(ctx context.Context, receiverId string, amount int, meritId string, actorId string) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	return record(ctx, newEntry(receiverId, Balance, Earned, amount, "received a merit", meritId, actorId))
+}
+
+// CancelMerit undoes whatever was paid for a merit that couldn't be given, so
+// the holder gets their allowance back and the receiver doesn't keep it
+func CancelMerit(ctx context.Context, meritId string, actorId string) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	related, err := list(ctx, bson.D{{Key: "related_id", Value: meritId}}, 0, 0)
+	if err != nil {
+		return err
+	}
+
+	entries := []*Entry{}
+	for _, entry := range related {
+		if entry.Amount == 0 {
+			continue
+		}
+		entries = append(entries, newEntry(entry.MemberId, entry.Account, Cancelled, -entry.Amount, "merit could not be given", meritId, actorId))
+	}
+
+	return record(ctx, entries...)
+}
+
+// Transfer moves some of the sender's balance to the receiver's. Both entries
+// share a related id so the two sides can be matched up.
+func Transfer(ctx context.Context, senderId string, receiverId string, amount int, reason string) (string, error) {
+	if amount <= 0 || senderId == receiverId {
+		return "", InvalidTransfer
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	balance, err := GetBalance(ctx, senderId, Balance)
+	if err != nil {
+		return "", err
+	}
+	if balance < amount {
+		return "", InsufficientBalance
+	}
+
+	transferId := xid.New().String()
+	if err := record(ctx,
+		newEntry(senderId, Balance, Sent, -amount, reason, transferId, senderId),
+		newEntry(receiverId, Balance, Received, amount, reason, transferId, senderId),
+	); err != nil {
+		return "", err
+	}
+
+	return transferId, nil
+}
+
+// ClawBack takes back what a revoked merit paid the receiver, if anything. The
+// balance can go below zero if it was already spent.
+func ClawBack(ctx context.Context, receiverId string, meritId string, actorId string) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	related, err := list(ctx, bson.D{{Key: "related_id", Value: meritId}, {Key: "member_id", Value: receiverId}}, 0, 0)
+	if err != nil {
+		return err
+	}
+
+	paid := 0
+	for _, entry := range related {
+		if entry.Account == Balance {
+			paid += entry.Amount
+		}
+	}
+	if paid <= 0 {
+		return nil
+	}
+
+	return record(ctx, newEntry(receiverId, Balance, Clawback, -paid, "merit was revoked", meritId, actorId))
+}
+
+// Redeem takes the reward's cost out of the member's balance. The entry's id
+// is the redemption officers settle later.
+func Redeem(ctx context.Context, memberId string, reward Reward) (*Entry, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	balance, err := GetBalance(ctx, memberId, Balance)
+	if err != nil {
+		return nil, err
+	}
+	if balance < reward.Cost {
+		return nil, InsufficientBalance
+	}
+
+	entry := newEntry(memberId, Balance, Redeemed, -reward.Cost, reward.Name, "", memberId)
+	entry.RelatedId = entry.Id
+	if err := record(ctx, entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// Fulfil marks the redemption as given to the member
+func Fulfil(ctx context.Context, redemptionId string, actorId string) (*Entry, error) {
+	return settle(ctx, redemptionId, actorId, false)
+}
+
+// Refund turns down the redemption and gives the member their balance back
+func Refund(ctx context.Context, redemptionId string, actorId string) (*Entry, error) {
+	return settle(ctx, redemptionId, actorId, true)
+}
+
+func settle(ctx context.Context, redemptionId string, actorId string, refund bool) (*Entry, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	related, err := list(ctx, bson.D{{Key: "related_id", Value: redemptionId}}, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var redemption *Entry
+	for _, entry := range related {
+		switch entry.Kind {
+		case Redeemed:
+			redemption = entry
+		case Fulfilled, Refunded:
+			return nil, AlreadySettled
+		}
+	}
+	if redemption == nil {
+		return nil, RedemptionNotFound
+	}
+
+	entry := newEntry(redemption.MemberId, Balance, Fulfilled, 0, redemption.Reason, redemptionId, actorId)
+	if refund {
+		entry = newEntry(redemption.MemberId, Balance, Refunded, -redemption.Amount, redemption.Reason, redemptionId, actorId)
+	}
+	if err := record(ctx, entry); err != nil {
+		return nil, err
+	}
+
+	return redemption, nil
+}
@@ -0,0 +1,90 @@
+package bank
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sol-armada/sol-bot/stores"
+)
+
+func setupMemory(t *testing.T) {
+	t.Helper()
+
+	stores.NewMemory(context.Background())
+	if err := Setup(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func balance(t *testing.T, memberId string, account Account) int {
+	t.Helper()
+
+	b, err := GetBalance(context.Background(), memberId, account)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestCancelMerit(t *testing.T) {
+	ctx := context.Background()
+	setupMemory(t)
+
+	if err := TopUpAllowance(ctx, "holder", 10, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if err := Spend(ctx, "holder", "receiver", 3, "merit"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := CancelMerit(ctx, "merit", "holder"); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := balance(t, "holder", Allowance); got != 10 {
+		t.Errorf("holder allowance is %d, want 10", got)
+	}
+	if got := balance(t, "receiver", Balance); got != 0 {
+		t.Errorf("receiver balance is %d, want 0", got)
+	}
+}
+
+func TestTransfer(t *testing.T) {
+	ctx := context.Background()
+	setupMemory(t)
+
+	if err := Earn(ctx, "sender", 5, "merit", "officer"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		receiver string
+		amount   int
+		err      error
+	}{
+		{"to someone else", "receiver", 3, nil},
+		{"more than the balance", "receiver", 3, InsufficientBalance},
+		{"to themselves", "sender", 1, InvalidTransfer},
+		{"nothing", "receiver", 0, InvalidTransfer},
+		{"negative", "receiver", -1, InvalidTransfer},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Transfer(ctx, "sender", tt.receiver, tt.amount, "thanks")
+			if !errors.Is(err, tt.err) {
+				t.Errorf("got %v, want %v", err, tt.err)
+			}
+		})
+	}
+
+	if got := balance(t, "sender", Balance); got != 2 {
+		t.Errorf("sender balance is %d, want 2", got)
+	}
+	if got := balance(t, "receiver", Balance); got != 3 {
+		t.Errorf("receiver balance is %d, want 3", got)
+	}
+}
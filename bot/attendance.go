@@ -4,17 +4,27 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/apex/log"
 	"github.com/bwmarrin/discordgo"
 	"github.com/pkg/errors"
 	"github.com/rs/xid"
 	attdnc "github.com/sol-armada/sol-bot/attendance"
+	"github.com/sol-armada/sol-bot/audit"
+	"github.com/sol-armada/sol-bot/events"
 	"github.com/sol-armada/sol-bot/members"
 	"github.com/sol-armada/sol-bot/settings"
 	"github.com/sol-armada/sol-bot/utils"
 )
 
+// eventChoicePrefix marks an autocomplete choice as an event rather than an
+// attendance record
+const eventChoicePrefix = "event:"
+
+// how far back events are offered when taking attendance
+const recentEventsWindow = 24 * time.Hour
+
 func takeAttendanceAutocompleteHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) error {
 	logger := utils.GetLoggerFromContext(ctx).(*log.Entry)
 	logger.Debug("taking attendance autocomplete")
@@ -26,7 +36,7 @@ func takeAttendanceAutocompleteHandler(ctx context.Context, s *discordgo.Session
 	switch {
 	case !allowed(i.Member, "ATTENDANCE"):
 	case data.Options[0].Focused:
-		attendanceRecords, err := attdnc.ListActive(5)
+		attendanceRecords, err := attdnc.ListActive(ctx, 5)
 		if err != nil {
 			return errors.Wrap(err, "getting active attendance records")
 		}
@@ -44,6 +54,19 @@ func takeAttendanceAutocompleteHandler(ctx context.Context, s *discordgo.Session
 				Value: record.Id,
 			})
 		}
+
+		// events from the last day, including the ones mirrored from discord
+		recentEvents, err := events.Recent(ctx, time.Now().Add(-recentEventsWindow), 10)
+		if err != nil {
+			return errors.Wrap(err, "getting recent events")
+		}
+
+		for _, event := range recentEvents {
+			choices = append(choices, &discordgo.ApplicationCommandOptionChoice{
+				Name:  fmt.Sprintf("%s (%s UTC)", event.Name, event.Start.Format(events.TimeLayout)),
+				Value: eventChoicePrefix + event.Id,
+			})
+		}
 	}
 
 	if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
@@ -69,7 +92,7 @@ func removeAttendanceAutocompleteHandler(ctx context.Context, s *discordgo.Sessi
 	switch {
 	case !allowed(i.Member, "ATTENDANCE"):
 	case data.Options[0].Focused:
-		attendanceRecords, err := attdnc.ListActive(5)
+		attendanceRecords, err := attdnc.ListActive(ctx, 5)
 		if err != nil {
 			return errors.Wrap(err, "getting active attendance records")
 		}
@@ -117,53 +140,155 @@ func takeAttendanceCommandHandler(ctx context.Context, s *discordgo.Session, i *
 			Flags: discordgo.MessageFlagsEphemeral,
 		},
 	})
+	ctx = deferred(ctx)
 
 	data := i.ApplicationCommandData()
+	options := optionsByName(data.Options)
 
-	eventName := data.Options[0].StringValue()
+	attendance, exists, err := attendanceForEvent(ctx, data.Options[0].StringValue(), commandMember)
+	if err != nil {
+		return err
+	}
 
-	var attendance *attdnc.Attendance
+	var before map[string]interface{}
+	if exists {
+		before = attendanceSnapshot(attendance)
+	}
 
-	exists := false
-	if _, err := xid.FromString(eventName); err == nil {
-		exists = true
+	userIds := []string{}
+	channelIds := []string{}
+	for _, option := range data.Options[1:] {
+		switch option.Type {
+		case discordgo.ApplicationCommandOptionUser:
+			userIds = append(userIds, option.UserValue(s).ID)
+		case discordgo.ApplicationCommandOptionChannel:
+			channelIds = append(channelIds, option.ChannelValue(s).ID)
+		}
 	}
 
-	var err error
-	if exists { // get an existing attendance record
-		attendance, err = attdnc.Get(eventName)
-	} else { // create a new attendance record
-		attendance = attdnc.New(eventName, commandMember)
+	inVoice := []string{}
+	if len(channelIds) > 0 {
+		inVoice, err = voiceAttendees(s, channelIds)
+		if err != nil {
+			return errors.Wrap(err, "getting voice channel attendees")
+		}
+		userIds = append(userIds, inVoice...)
 	}
-	if err != nil {
-		return errors.Wrap(err, "getting or creating attendance record")
+
+	if len(userIds) == 0 {
+		_, _ = s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Content: "Nobody to take attendance for! Pick some users, or voice channels with people in them",
+			Flags:   discordgo.MessageFlagsEphemeral,
+		})
+		return nil
 	}
 
-	discordMembersList := data.Options[1:]
+	// members already on the record keep their role unless one is given
+	var role attdnc.Role
+	if o := options["role"]; o != nil {
+		role = attdnc.Role(o.StringValue())
+	}
+	if o := options["type"]; o != nil {
+		attendance.Type = attdnc.EventType(o.StringValue())
+	}
 
-	for _, discordMember := range discordMembersList {
-		member, err := members.Get(discordMember.UserValue(s).ID)
+	for _, userId := range userIds {
+		member, err := attendee(ctx, s, userId)
 		if err != nil {
-			if !errors.Is(err, members.MemberNotFound) {
-				return errors.Wrap(err, "getting member for new attendance")
-			}
-
-			attendance.WithIssues = append(attendance.WithIssues, member)
-
-			continue
+			return errors.Wrap(err, "getting member for new attendance")
 		}
 
 		attendance.AddMember(member)
+		if role != "" {
+			attendance.SetRole(member.Id, role)
+		}
 	}
 
 	// save now incase there is an error with creating the message
-	if err := attendance.Save(); err != nil {
+	if err := attendance.Save(ctx); err != nil {
 		return errors.Wrap(err, "saving attendance record")
 	}
 
+	action := audit.AttendanceCreated
+	if exists {
+		action = audit.AttendanceUpdated
+	}
+	recordAudit(ctx, memberActor(commandMember), action, attendanceTarget(attendance), before, attendanceSnapshot(attendance))
+
+	if err := publishAttendance(ctx, s, attendance); err != nil {
+		return err
+	}
+
+	content := "Attendance record created!"
+	if exists {
+		content = "Attendance record updated!"
+	}
+	if len(channelIds) > 0 {
+		content += fmt.Sprintf(" Took %d from voice", len(inVoice))
+	}
+	_, _ = s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+		Content: content,
+		Flags:   discordgo.MessageFlagsEphemeral,
+	})
+
+	return nil
+}
+
+// attendanceForEvent gets the attendance record if event is the id of one, or
+// creates a new record named event. An event picked from the autocomplete gets
+// the record linked to it, or a new one linked to it.
+func attendanceForEvent(ctx context.Context, event string, submittedBy *members.Member) (*attdnc.Attendance, bool, error) {
+	if eventId, ok := strings.CutPrefix(event, eventChoicePrefix); ok {
+		return attendanceForLinkedEvent(ctx, eventId, submittedBy)
+	}
+
+	if _, err := xid.FromString(event); err != nil {
+		return attdnc.New(event, submittedBy), false, nil
+	}
+
+	attendance, err := attdnc.Get(ctx, event)
+	if err != nil {
+		return nil, false, errors.Wrap(err, "getting attendance record")
+	}
+	return attendance, true, nil
+}
+
+func attendanceForLinkedEvent(ctx context.Context, eventId string, submittedBy *members.Member) (*attdnc.Attendance, bool, error) {
+	event, err := events.Get(ctx, eventId)
+	if err != nil {
+		return nil, false, errors.Wrap(err, "getting event")
+	}
+
+	if event.AttendanceId != "" {
+		attendance, err := attdnc.Get(ctx, event.AttendanceId)
+		if err == nil {
+			return attendance, true, nil
+		}
+		if !errors.Is(err, attdnc.ErrAttendanceNotFound) {
+			return nil, false, errors.Wrap(err, "getting event attendance record")
+		}
+	}
+
+	attendance := attdnc.New(event.Name, submittedBy)
+	attendance.EventId = event.Id
+
+	if _, err := events.Update(ctx, event.Id, func(e *events.Event) error {
+		e.AttendanceId = attendance.Id
+		return nil
+	}); err != nil {
+		return nil, false, errors.Wrap(err, "linking event attendance")
+	}
+
+	return attendance, false, nil
+}
+
+// publishAttendance posts the attendance record's message, or updates it if it
+// was already posted, and saves where it is
+func publishAttendance(ctx context.Context, s *discordgo.Session, attendance *attdnc.Attendance) error {
 	// check if the attendance record channel exists
 	var channel *discordgo.Channel
 	var message *discordgo.Message
+	var err error
 
 	if attendance.ChannelId != "" {
 		channel, _ = s.Channel(attendance.ChannelId)
@@ -190,7 +315,7 @@ func takeAttendanceCommandHandler(ctx context.Context, s *discordgo.Session, i *
 	attendance.ChannelId = channel.ID
 	attendance.MessageId = message.ID
 
-	if err := attendance.Save(); err != nil {
+	if err := attendance.Save(ctx); err != nil {
 		return errors.Wrap(err, "saving attendance record")
 	}
 
@@ -203,15 +328,6 @@ func takeAttendanceCommandHandler(ctx context.Context, s *discordgo.Session, i *
 		return err
 	}
 
-	content := "Attendance record created!"
-	if exists {
-		content = "Attendance record updated!"
-	}
-	_, _ = s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
-		Content: content,
-		Flags:   discordgo.MessageFlagsEphemeral,
-	})
-
 	return nil
 }
 
@@ -229,18 +345,21 @@ func removeAttendanceCommandHandler(ctx context.Context, s *discordgo.Session, i
 			Flags: discordgo.MessageFlagsEphemeral,
 		},
 	})
+	ctx = deferred(ctx)
 
 	data := i.ApplicationCommandData()
 
-	attendance, err := attdnc.Get(data.Options[0].StringValue())
+	attendance, err := attdnc.Get(ctx, data.Options[0].StringValue())
 	if err != nil {
 		return errors.Wrap(err, "getting attendance record")
 	}
 
+	before := attendanceSnapshot(attendance)
+
 	discordMembers := data.Options[1:]
 
 	for _, discordMember := range discordMembers {
-		member, err := members.Get(discordMember.UserValue(s).ID)
+		member, err := members.Get(ctx, discordMember.UserValue(s).ID)
 		if err != nil {
 			if !errors.Is(err, members.MemberNotFound) {
 				return errors.Wrap(err, "getting member for new attendance")
@@ -250,10 +369,12 @@ func removeAttendanceCommandHandler(ctx context.Context, s *discordgo.Session, i
 		attendance.RemoveMember(member)
 	}
 
-	if err := attendance.Save(); err != nil {
+	if err := attendance.Save(ctx); err != nil {
 		return errors.Wrap(err, "saving attendance record")
 	}
 
+	recordAudit(ctx, memberActor(utils.GetMemberFromContext(ctx).(*members.Member)), audit.AttendanceUpdated, attendanceTarget(attendance), before, attendanceSnapshot(attendance))
+
 	message := attendance.ToDiscordMessage()
 
 	if _, err := s.ChannelMessageEditComplex(&discordgo.MessageEdit{
@@ -287,18 +408,23 @@ func recheckIssuesButtonHandler(ctx context.Context, s *discordgo.Session, i *di
 			Flags: discordgo.MessageFlagsEphemeral,
 		},
 	})
+	ctx = deferred(ctx)
 
 	id := strings.Split(i.MessageComponentData().CustomID, ":")[2]
 
-	attendance, err := attdnc.Get(id)
+	attendance, err := attdnc.Get(ctx, id)
 	if err != nil {
 		return errors.Wrap(err, "getting attendance record")
 	}
 
-	if err := attendance.RecheckIssues(); err != nil {
+	before := attendanceSnapshot(attendance)
+
+	if err := attendance.RecheckIssues(ctx); err != nil {
 		return errors.Wrap(err, "rechecking issues for attendance record")
 	}
 
+	recordAudit(ctx, memberActor(utils.GetMemberFromContext(ctx).(*members.Member)), audit.AttendanceUpdated, attendanceTarget(attendance), before, attendanceSnapshot(attendance))
+
 	message := attendance.ToDiscordMessage()
 
 	if _, err := s.ChannelMessageEditComplex(&discordgo.MessageEdit{
@@ -332,18 +458,23 @@ func recordAttendanceButtonHandler(ctx context.Context, s *discordgo.Session, i
 			Flags: discordgo.MessageFlagsEphemeral,
 		},
 	})
+	ctx = deferred(ctx)
 
 	id := strings.Split(i.MessageComponentData().CustomID, ":")[2]
 
-	attendance, err := attdnc.Get(id)
+	attendance, err := attdnc.Get(ctx, id)
 	if err != nil {
 		return errors.Wrap(err, "getting attendance record")
 	}
 
-	if err := attendance.Record(); err != nil {
+	before := attendanceSnapshot(attendance)
+
+	if err := attendance.Record(ctx); err != nil {
 		return errors.Wrap(err, "recording attendance for attendance record")
 	}
 
+	recordAudit(ctx, memberActor(utils.GetMemberFromContext(ctx).(*members.Member)), audit.AttendanceRecorded, attendanceTarget(attendance), before, attendanceSnapshot(attendance))
+
 	attendanceMessage := attendance.ToDiscordMessage()
 	_, _ = s.ChannelMessageEditComplex(&discordgo.MessageEdit{
 		Channel:    attendance.ChannelId,
@@ -366,7 +497,7 @@ func deleteAttendanceButtonHandler(ctx context.Context, s *discordgo.Session, i
 
 	id := strings.Split(i.MessageComponentData().CustomID, ":")[2]
 
-	attendance, err := attdnc.Get(id)
+	attendance, err := attdnc.Get(ctx, id)
 	if err != nil && !errors.Is(err, attdnc.ErrAttendanceNotFound) {
 		return errors.Wrap(err, "getting attendance record")
 	}
@@ -424,20 +555,23 @@ func verifyDeleteButtonModalHandler(ctx context.Context, s *discordgo.Session, i
 			Flags: discordgo.MessageFlagsEphemeral,
 		},
 	})
+	ctx = deferred(ctx)
 
 	id := strings.Split(i.MessageComponentData().CustomID, ":")[2]
 
-	attendance, err := attdnc.Get(id)
+	attendance, err := attdnc.Get(ctx, id)
 	if err != nil && !errors.Is(err, attdnc.ErrAttendanceNotFound) {
 		return errors.Wrap(err, "getting attendance record")
 	}
 	if attendance != nil {
-		if err := attendance.Delete(); err != nil {
+		if err := attendance.Delete(ctx); err != nil {
 			return errors.Wrap(err, "deleting attendance record")
 		}
-	}
 
-	_ = s.ChannelMessageDelete(attendance.ChannelId, attendance.MessageId)
+		recordAudit(ctx, memberActor(utils.GetMemberFromContext(ctx).(*members.Member)), audit.AttendanceDeleted, attendanceTarget(attendance), attendanceSnapshot(attendance), nil)
+
+		_ = s.ChannelMessageDelete(attendance.ChannelId, attendance.MessageId)
+	}
 
 	_, _ = s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
 		Content: "Attendance record deleted!",
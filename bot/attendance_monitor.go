@@ -14,6 +14,7 @@ import (
 func MonitorAttendance(stop <-chan bool) {
 	logger := log.WithField("func", "monitorAttendance")
 	logger.Info("monitoring attendance")
+	ctx := bot.ctx
 
 	channel := settings.GetString("FEATURES.ATTENDANCE.CHANNEL_ID")
 
@@ -52,7 +53,7 @@ func MonitorAttendance(stop <-chan bool) {
 
 		for _, msg := range msgs {
 			id := msg.Embeds[0].Description
-			_, err := attdnc.Get(id)
+			_, err := attdnc.Get(ctx, id)
 			if (err != nil && errors.Is(err, attdnc.ErrAttendanceNotFound)) || id == "" {
 				_ = bot.ChannelMessageDelete(channel, msg.ID)
 			}
@@ -0,0 +1,295 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/bwmarrin/discordgo"
+	"github.com/pkg/errors"
+	"github.com/sol-armada/sol-bot/activity"
+	attdnc "github.com/sol-armada/sol-bot/attendance"
+	"github.com/sol-armada/sol-bot/audit"
+	"github.com/sol-armada/sol-bot/events"
+	"github.com/sol-armada/sol-bot/members"
+	"github.com/sol-armada/sol-bot/settings"
+	"github.com/sol-armada/sol-bot/utils"
+)
+
+// how many voice channels or categories attendance can be taken from at once
+const maxAttendanceChannels = 3
+
+// how long someone has to be in voice to count when not configured
+const defaultMinVoiceMinutes = 30
+
+// parseWhen reads a time given to a command, either how long ago like "2h30m"
+// or a UTC time like "2024-05-01 19:00"
+func parseWhen(value string, now time.Time) (time.Time, error) {
+	if ago, err := time.ParseDuration(value); err == nil {
+		return now.Add(-ago), nil
+	}
+
+	when, err := time.Parse(events.TimeLayout, value)
+	if err != nil {
+		return time.Time{}, errors.Errorf("%q is not a duration like 2h30m or a UTC time like %s", value, events.TimeLayout)
+	}
+	return when, nil
+}
+
+// voiceChannels are the voice channels picked, and the voice channels under
+// any categories picked. The AFK channel is never included.
+func voiceChannels(s *discordgo.Session, channelIds []string) ([]string, error) {
+	guild, err := s.State.Guild(bot.GuildId)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting guild from state")
+	}
+
+	afkChannels := []string{guild.AfkChannelID, settings.GetString("FEATURES.ACTIVITY_TRACKING.AFK_CHANNEL_ID")}
+
+	// the state is updated as channels change, so copy what is needed
+	s.State.RLock()
+	channels := make([]discordgo.Channel, 0, len(guild.Channels))
+	for _, channel := range guild.Channels {
+		channels = append(channels, *channel)
+	}
+	s.State.RUnlock()
+
+	voice := []string{}
+	for _, channel := range channels {
+		if channel.Type != discordgo.ChannelTypeGuildVoice && channel.Type != discordgo.ChannelTypeGuildStageVoice {
+			continue
+		}
+		if utils.StringSliceContains(afkChannels, channel.ID) {
+			continue
+		}
+		if utils.StringSliceContains(channelIds, channel.ID) || utils.StringSliceContains(channelIds, channel.ParentID) {
+			voice = append(voice, channel.ID)
+		}
+	}
+
+	return voice, nil
+}
+
+// voiceAttendees are the users in the voice channels right now, or in any voice
+// channel under the given categories. Bots and anyone in the AFK channel are
+// left out.
+func voiceAttendees(s *discordgo.Session, channelIds []string) ([]string, error) {
+	channels, err := voiceChannels(s, channelIds)
+	if err != nil {
+		return nil, err
+	}
+
+	guild, err := s.State.Guild(bot.GuildId)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting guild from state")
+	}
+
+	// the state is updated as people move around, so copy what is needed
+	// before looking anything else up
+	s.State.RLock()
+	voiceStates := make([]discordgo.VoiceState, 0, len(guild.VoiceStates))
+	for _, voiceState := range guild.VoiceStates {
+		voiceStates = append(voiceStates, *voiceState)
+	}
+	s.State.RUnlock()
+
+	userIds := []string{}
+	for _, voiceState := range voiceStates {
+		if !utils.StringSliceContains(channels, voiceState.ChannelID) {
+			continue
+		}
+
+		discordMember, err := guildMember(s, voiceState.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if discordMember.User.Bot {
+			continue
+		}
+
+		userIds = append(userIds, voiceState.UserID)
+	}
+
+	return userIds, nil
+}
+
+// guildMember gets the Discord member from the state, or from Discord if the
+// state doesn't have them
+func guildMember(s *discordgo.Session, userId string) (*discordgo.Member, error) {
+	discordMember, err := s.State.Member(bot.GuildId, userId)
+	if err == nil && discordMember.User != nil {
+		return discordMember, nil
+	}
+
+	discordMember, err = s.GuildMember(bot.GuildId, userId)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting discord member")
+	}
+	return discordMember, nil
+}
+
+// attendee gets the stored member for attendance. Someone who isn't onboarded
+// yet is still added as a guest so they show up with their issues.
+func attendee(ctx context.Context, s *discordgo.Session, userId string) (*members.Member, error) {
+	member, err := members.Get(ctx, userId)
+	if err == nil {
+		return member, nil
+	}
+	if !errors.Is(err, members.MemberNotFound) {
+		return nil, errors.Wrap(err, "getting member for attendance")
+	}
+
+	discordMember, err := guildMember(s, userId)
+	if err != nil {
+		return nil, err
+	}
+	return members.New(discordMember), nil
+}
+
+// voiceAttendanceCommandHandler takes attendance from who was in the voice
+// channels between two times, going by the voice activity that was tracked
+func voiceAttendanceCommandHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) error {
+	logger := utils.GetLoggerFromContext(ctx).(*log.Entry)
+	logger.Debug("voice attendance command")
+
+	commandMember := utils.GetMemberFromContext(ctx).(*members.Member)
+
+	if !allowed(i.Member, "ATTENDANCE") {
+		return InvalidPermissions
+	}
+
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	})
+	ctx = deferred(ctx)
+
+	data := i.ApplicationCommandData()
+	options := optionsByName(data.Options)
+
+	now := time.Now().UTC()
+	start, err := parseWhen(options["start"].StringValue(), now)
+	if err != nil {
+		_, _ = s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Content: "Start " + err.Error(),
+			Flags:   discordgo.MessageFlagsEphemeral,
+		})
+		return nil
+	}
+	end := now
+	if o := options["end"]; o != nil {
+		end, err = parseWhen(o.StringValue(), now)
+		if err != nil {
+			_, _ = s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+				Content: "End " + err.Error(),
+				Flags:   discordgo.MessageFlagsEphemeral,
+			})
+			return nil
+		}
+	}
+	if !end.After(start) || end.After(now) {
+		_, _ = s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Content: "The end has to be after the start, and can't be in the future",
+			Flags:   discordgo.MessageFlagsEphemeral,
+		})
+		return nil
+	}
+
+	minMinutes := settings.GetIntWithDefault("FEATURES.ATTENDANCE.MIN_VOICE_MINUTES", defaultMinVoiceMinutes)
+	if o := options["min-minutes"]; o != nil {
+		minMinutes = int(o.IntValue())
+	}
+
+	picked := []string{}
+	for n := 0; n < maxAttendanceChannels; n++ {
+		if o := options[fmt.Sprintf("channel-%d", n+1)]; o != nil {
+			picked = append(picked, o.ChannelValue(s).ID)
+		}
+	}
+	channels, err := voiceChannels(s, picked)
+	if err != nil {
+		return err
+	}
+
+	sessions, err := activity.VoiceSessions(ctx, start, end, settings.GetDurationWithDefault("FEATURES.ATTENDANCE.VOICE_LOOKBACK", 12*time.Hour))
+	if err != nil {
+		return errors.Wrap(err, "getting voice sessions")
+	}
+
+	attendance, exists, err := attendanceForEvent(ctx, options["event"].StringValue(), commandMember)
+	if err != nil {
+		return err
+	}
+
+	var before map[string]interface{}
+	if exists {
+		before = attendanceSnapshot(attendance)
+	}
+
+	if attendance.Minutes == nil {
+		attendance.Minutes = map[string]int{}
+	}
+	if o := options["type"]; o != nil {
+		attendance.Type = attdnc.EventType(o.StringValue())
+	}
+
+	added, tooShort := 0, 0
+	for memberId, inVoice := range activity.VoiceTime(sessions, channels) {
+		minutes := int(inVoice.Minutes())
+		if minutes < minMinutes {
+			tooShort++
+			continue
+		}
+
+		member, err := attendee(ctx, s, memberId)
+		if err != nil {
+			logger.WithError(err).WithField("member", memberId).Warn("getting member for voice attendance")
+			continue
+		}
+		if member.IsBot {
+			continue
+		}
+
+		attendance.AddMember(member)
+		attendance.Minutes[memberId] = minutes
+		added++
+	}
+
+	if added == 0 && !exists {
+		_, _ = s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Content: fmt.Sprintf("Nobody was in those channels for %d minutes or more between <t:%d:f> and <t:%d:f>", minMinutes, start.Unix(), end.Unix()),
+			Flags:   discordgo.MessageFlagsEphemeral,
+		})
+		return nil
+	}
+
+	// save now incase there is an error with creating the message
+	if err := attendance.Save(ctx); err != nil {
+		return errors.Wrap(err, "saving attendance record")
+	}
+
+	action := audit.AttendanceCreated
+	if exists {
+		action = audit.AttendanceUpdated
+	}
+	recordAudit(ctx, memberActor(commandMember), action, attendanceTarget(attendance), before, attendanceSnapshot(attendance))
+
+	if err := publishAttendance(ctx, s, attendance); err != nil {
+		return err
+	}
+
+	content := "Attendance record created!"
+	if exists {
+		content = "Attendance record updated!"
+	}
+	content += fmt.Sprintf(" Took %d from voice, left out %d who were in for less than %d minutes", added, tooShort, minMinutes)
+	_, _ = s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+		Content: content,
+		Flags:   discordgo.MessageFlagsEphemeral,
+	})
+
+	return nil
+}
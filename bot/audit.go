@@ -0,0 +1,208 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/apex/log"
+	"github.com/bwmarrin/discordgo"
+	"github.com/pkg/errors"
+	attdnc "github.com/sol-armada/sol-bot/attendance"
+	"github.com/sol-armada/sol-bot/audit"
+	"github.com/sol-armada/sol-bot/events"
+	"github.com/sol-armada/sol-bot/members"
+	"github.com/sol-armada/sol-bot/utils"
+)
+
+// how many audit entries to show per page, kept low so the embed stays under
+// discord's size limits
+const auditPageSize = 5
+
+func auditCommandHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) error {
+	logger := utils.GetLoggerFromContext(ctx).(*log.Entry)
+	logger.Debug("audit command")
+
+	if !allowed(i.Member, "AUDIT") {
+		return InvalidPermissions
+	}
+
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	})
+	ctx = deferred(ctx)
+
+	subcommand := i.ApplicationCommandData().Options[0]
+
+	page := 1
+	var title string
+	var entries []*audit.Entry
+	var err error
+
+	switch subcommand.Name {
+	case "member":
+		user := subcommand.Options[0].UserValue(s)
+		for _, o := range subcommand.Options[1:] {
+			if o.Name == "page" {
+				page = int(o.IntValue())
+			}
+		}
+
+		title = "Audit log for " + user.Username
+		entries, err = audit.ListForMember(ctx, user.ID, auditPageSize, page)
+	case "record":
+		id := subcommand.Options[0].StringValue()
+		for _, o := range subcommand.Options[1:] {
+			if o.Name == "page" {
+				page = int(o.IntValue())
+			}
+		}
+
+		title = "Audit log for record " + id
+		entries, err = audit.ListForTarget(ctx, id, auditPageSize, page)
+	default:
+		return errors.New("unknown audit subcommand " + subcommand.Name)
+	}
+	if err != nil {
+		return errors.Wrap(err, "listing audit entries")
+	}
+
+	if len(entries) == 0 {
+		_, _ = s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Content: "No audit entries found",
+			Flags:   discordgo.MessageFlagsEphemeral,
+		})
+		return nil
+	}
+
+	fields := []*discordgo.MessageEmbedField{}
+	for _, entry := range entries {
+		fields = append(fields, auditEntryField(entry))
+	}
+
+	if _, err := s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+		Flags: discordgo.MessageFlagsEphemeral,
+		Embeds: []*discordgo.MessageEmbed{
+			{
+				Title:  title,
+				Fields: fields,
+				Footer: &discordgo.MessageEmbedFooter{
+					Text: fmt.Sprintf("Page %d", page),
+				},
+			},
+		},
+	}); err != nil {
+		return errors.Wrap(err, "responding to audit command")
+	}
+
+	return nil
+}
+
+func auditEntryField(entry *audit.Entry) *discordgo.MessageEmbedField {
+	actor := "<@" + entry.ActorId + ">"
+	if entry.ActorId == audit.System.Id {
+		actor = entry.ActorName
+	}
+
+	target := entry.TargetName
+	switch entry.TargetType {
+	case audit.MemberTarget:
+		target = "<@" + entry.TargetId + ">"
+	case audit.AttendanceTarget, audit.EventTarget:
+		target = fmt.Sprintf("%s (%s)", entry.TargetName, entry.TargetId)
+	}
+
+	lines := []string{fmt.Sprintf("by %s on %s", actor, target)}
+	for _, change := range entry.Changes {
+		lines = append(lines, change.String())
+	}
+
+	value := strings.Join(lines, "\n")
+	if len(value) > 1000 {
+		value = value[:1000] + "…"
+	}
+
+	return &discordgo.MessageEmbedField{
+		Name:  fmt.Sprintf("%s <t:%d:f>", strings.ReplaceAll(string(entry.Action), "_", " "), entry.When.Unix()),
+		Value: value,
+	}
+}
+
+// recordAudit saves an audit entry, failing to audit never stops the change
+// that was already made so errors are only logged
+func recordAudit(ctx context.Context, actor audit.Actor, action audit.Action, target audit.Target, before, after any) {
+	if _, err := audit.Record(ctx, actor, action, target, before, after); err != nil {
+		log.WithError(err).WithFields(log.Fields{
+			"actor":  actor.Id,
+			"action": action,
+			"target": target.Id,
+		}).Error("recording audit entry")
+	}
+}
+
+func memberActor(member *members.Member) audit.Actor {
+	return audit.Actor{Id: member.Id, Name: member.Name}
+}
+
+func memberTarget(member *members.Member) audit.Target {
+	return audit.Target{Type: audit.MemberTarget, Id: member.Id, Name: member.Name}
+}
+
+func attendanceTarget(attendance *attdnc.Attendance) audit.Target {
+	return audit.Target{Type: audit.AttendanceTarget, Id: attendance.Id, Name: attendance.Name}
+}
+
+func eventTarget(event *events.Event) audit.Target {
+	return audit.Target{Type: audit.EventTarget, Id: event.Id, Name: event.Name}
+}
+
+// eventSnapshot is the part of an event worth auditing, leaving out the RSVPs
+// and reminders that change on their own
+func eventSnapshot(event *events.Event) map[string]interface{} {
+	if event == nil {
+		return nil
+	}
+
+	return map[string]interface{}{
+		"name":       event.Name,
+		"start":      event.Start,
+		"duration":   event.Duration.String(),
+		"host_id":    event.HostId,
+		"gameplay":   event.Gameplay,
+		"channel_id": event.ChannelId,
+		"status":     event.Status,
+	}
+}
+
+// attendanceSnapshot is the part of an attendance record worth auditing, with
+// members reduced to their ids
+func attendanceSnapshot(attendance *attdnc.Attendance) map[string]interface{} {
+	if attendance == nil {
+		return nil
+	}
+
+	memberIds := func(list []*members.Member) []string {
+		ids := []string{}
+		for _, member := range list {
+			if member == nil {
+				continue
+			}
+			ids = append(ids, member.Id)
+		}
+		sort.Strings(ids)
+		return ids
+	}
+
+	return map[string]interface{}{
+		"name":        attendance.Name,
+		"members":     memberIds(attendance.Members),
+		"with_issues": memberIds(attendance.WithIssues),
+		"recorded":    attendance.Recorded,
+		"type":        string(attendance.Type),
+		"roles":       attendance.Roles,
+	}
+}
@@ -0,0 +1,393 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/bwmarrin/discordgo"
+	"github.com/pkg/errors"
+	"github.com/sol-armada/sol-bot/audit"
+	"github.com/sol-armada/sol-bot/bank"
+	"github.com/sol-armada/sol-bot/members"
+	"github.com/sol-armada/sol-bot/settings"
+	"github.com/sol-armada/sol-bot/utils"
+)
+
+// how many ledger entries to show on a page
+const ledgerPageSize = 15
+
+// how many merits a holder gets each period when not configured
+const defaultAllowance = 10
+
+func bankEnabled() bool {
+	return settings.GetBool("FEATURES.MERIT.BANK.ENABLE")
+}
+
+// isHolder is if the member has one of the bank holder roles and gives merits
+// out of an allowance
+func isHolder(discordMember *discordgo.Member) bool {
+	return utils.StringSliceContainsOneOf(discordMember.Roles, settings.GetStringSlice("FEATURES.MERIT.HOLDERS"))
+}
+
+func rewards() ([]bank.Reward, error) {
+	rewards := []bank.Reward{}
+	if err := settings.UnmarshalKey("FEATURES.MERIT.BANK.REWARDS", &rewards); err != nil {
+		return nil, errors.Wrap(err, "reading rewards")
+	}
+	return rewards, nil
+}
+
+// topUpAllowance gives the holder their allowance if a period has passed since
+// the last one
+func topUpAllowance(ctx context.Context, holderId string) error {
+	return bank.TopUpAllowance(ctx,
+		holderId,
+		settings.GetIntWithDefault("FEATURES.MERIT.BANK.ALLOWANCE", defaultAllowance),
+		settings.GetDurationWithDefault("FEATURES.MERIT.BANK.ALLOWANCE_PERIOD", 30*24*time.Hour),
+	)
+}
+
+func balanceCommandHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, subcommand *discordgo.ApplicationCommandInteractionDataOption) error {
+	logger := utils.GetLoggerFromContext(ctx).(*log.Entry)
+	logger.Debug("merit balance command")
+
+	member, ok, err := bankMemberOption(ctx, s, i, subcommand)
+	if err != nil || !ok {
+		return err
+	}
+
+	fields := []*discordgo.MessageEmbedField{}
+
+	// only the holder's own roles are known here, so an allowance is only
+	// topped up when they look themselves
+	if member.Id == i.Member.User.ID && isHolder(i.Member) {
+		if err := topUpAllowance(ctx, member.Id); err != nil {
+			return errors.Wrap(err, "topping up allowance")
+		}
+	}
+	allowance, err := bank.GetBalance(ctx, member.Id, bank.Allowance)
+	if err != nil {
+		return errors.Wrap(err, "getting allowance")
+	}
+	if allowance != 0 || (member.Id == i.Member.User.ID && isHolder(i.Member)) {
+		fields = append(fields, &discordgo.MessageEmbedField{Name: "Allowance", Value: fmt.Sprintf("%d", allowance), Inline: true})
+	}
+
+	balance, err := bank.GetBalance(ctx, member.Id, bank.Balance)
+	if err != nil {
+		return errors.Wrap(err, "getting balance")
+	}
+	fields = append(fields, &discordgo.MessageEmbedField{Name: "Balance", Value: fmt.Sprintf("%d", balance), Inline: true})
+
+	if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags: discordgo.MessageFlagsEphemeral,
+			Embeds: []*discordgo.MessageEmbed{
+				{
+					Title:  "Merit Bank of " + member.Name,
+					Fields: fields,
+				},
+			},
+		},
+	}); err != nil {
+		return errors.Wrap(err, "responding to merit balance command")
+	}
+
+	return nil
+}
+
+func ledgerCommandHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, subcommand *discordgo.ApplicationCommandInteractionDataOption) error {
+	logger := utils.GetLoggerFromContext(ctx).(*log.Entry)
+	logger.Debug("merit ledger command")
+
+	member, ok, err := bankMemberOption(ctx, s, i, subcommand)
+	if err != nil || !ok {
+		return err
+	}
+
+	page := 1
+	if o := optionsByName(subcommand.Options)["page"]; o != nil {
+		page = int(o.IntValue())
+	}
+
+	entries, err := bank.Ledger(ctx, member.Id, ledgerPageSize, page)
+	if err != nil {
+		return errors.Wrap(err, "getting ledger")
+	}
+
+	lines := []string{}
+	for _, entry := range entries {
+		lines = append(lines, ledgerLine(entry))
+	}
+	description := strings.Join(lines, "\n")
+	if description == "" {
+		description = "Nothing on this page"
+	}
+
+	if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags: discordgo.MessageFlagsEphemeral,
+			Embeds: []*discordgo.MessageEmbed{
+				{
+					Title:       "Merit Ledger of " + member.Name,
+					Description: description,
+					Footer:      &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("Page %d", page)},
+				},
+			},
+		},
+	}); err != nil {
+		return errors.Wrap(err, "responding to merit ledger command")
+	}
+
+	return nil
+}
+
+func ledgerLine(entry *bank.Entry) string {
+	line := fmt.Sprintf("<t:%d:d> %+d %s, %s", entry.When.Unix(), entry.Amount, entry.Account, entry.Kind)
+	if entry.Reason != "" {
+		line += ": " + entry.Reason
+	}
+	return line
+}
+
+// bankMemberOption gets the member the bank command is about, the one running
+// it if none was given. Only officers can look at someone else's.
+func bankMemberOption(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, subcommand *discordgo.ApplicationCommandInteractionDataOption) (*members.Member, bool, error) {
+	member := utils.GetMemberFromContext(ctx).(*members.Member)
+
+	o := optionsByName(subcommand.Options)["member"]
+	if o == nil || o.UserValue(s).ID == member.Id {
+		return member, true, nil
+	}
+
+	if !allowed(i.Member, "MERIT") {
+		return nil, false, InvalidPermissions
+	}
+
+	other, err := members.Get(ctx, o.UserValue(s).ID)
+	if err != nil {
+		if !errors.Is(err, members.MemberNotFound) {
+			return nil, false, errors.Wrap(err, "getting member for bank")
+		}
+		return nil, false, s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "That member was not found in the system!",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+	}
+
+	return other, true, nil
+}
+
+func redeemCommandHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, subcommand *discordgo.ApplicationCommandInteractionDataOption) error {
+	logger := utils.GetLoggerFromContext(ctx).(*log.Entry)
+	logger.Debug("merit redeem command")
+
+	member := utils.GetMemberFromContext(ctx).(*members.Member)
+
+	catalog, err := rewards()
+	if err != nil {
+		return err
+	}
+
+	name := optionsByName(subcommand.Options)["reward"].StringValue()
+	var reward *bank.Reward
+	for n := range catalog {
+		if catalog[n].Name == name {
+			reward = &catalog[n]
+		}
+	}
+	if reward == nil {
+		return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "That reward is no longer offered",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+	}
+
+	redemption, err := bank.Redeem(ctx, member.Id, *reward)
+	if err != nil {
+		if !errors.Is(err, bank.InsufficientBalance) {
+			return errors.Wrap(err, "redeeming reward")
+		}
+		return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: fmt.Sprintf("You need %d merits for %s", reward.Cost, reward.Name),
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+	}
+
+	recordAudit(ctx, memberActor(member), audit.MeritRedeemed, memberTarget(member), nil, redemption)
+
+	channelId := settings.GetStringWithDefault("FEATURES.MERIT.BANK.CHANNEL_ID", settings.GetString("DISCORD.OFFICER_CHANNEL_ID"))
+	if channelId != "" {
+		if _, err := s.ChannelMessageSendComplex(channelId, &discordgo.MessageSend{
+			Embeds: []*discordgo.MessageEmbed{
+				{
+					Title:       "Reward Redeemed: " + reward.Name,
+					Description: fmt.Sprintf("<@%s> spent %d merits on %s\n%s", member.Id, reward.Cost, reward.Name, reward.Description),
+				},
+			},
+			Components: []discordgo.MessageComponent{
+				discordgo.ActionsRow{
+					Components: []discordgo.MessageComponent{
+						discordgo.Button{
+							Label:    "Fulfilled",
+							Style:    discordgo.SuccessButton,
+							CustomID: "merit:fulfil:" + redemption.Id,
+						},
+						discordgo.Button{
+							Label:    "Refund",
+							Style:    discordgo.DangerButton,
+							CustomID: "merit:refund:" + redemption.Id,
+						},
+					},
+				},
+			},
+		}); err != nil {
+			logger.WithError(err).Warn("sending redemption to officers")
+		}
+	}
+
+	if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: fmt.Sprintf("Redeemed %s for %d merits! An officer will get it to you soon", reward.Name, reward.Cost),
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	}); err != nil {
+		return errors.Wrap(err, "responding to merit redeem command")
+	}
+
+	return nil
+}
+
+func transferCommandHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, subcommand *discordgo.ApplicationCommandInteractionDataOption) error {
+	logger := utils.GetLoggerFromContext(ctx).(*log.Entry)
+	logger.Debug("merit transfer command")
+
+	member := utils.GetMemberFromContext(ctx).(*members.Member)
+
+	options := optionsByName(subcommand.Options)
+	amount := int(options["amount"].IntValue())
+	reason := "transfer"
+	if o := options["reason"]; o != nil && o.StringValue() != "" {
+		reason = o.StringValue()
+	}
+
+	receiver, err := members.Get(ctx, options["member"].UserValue(s).ID)
+	if err != nil {
+		if !errors.Is(err, members.MemberNotFound) {
+			return errors.Wrap(err, "getting member to transfer to")
+		}
+		return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "That member was not found in the system!",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+	}
+
+	transferId, err := bank.Transfer(ctx, member.Id, receiver.Id, amount, reason)
+	if err != nil {
+		content := ""
+		switch {
+		case errors.Is(err, bank.InsufficientBalance):
+			content = fmt.Sprintf("You don't have %d merits to transfer", amount)
+		case errors.Is(err, bank.InvalidTransfer):
+			content = "You can only transfer merits to someone else"
+		default:
+			return errors.Wrap(err, "transferring merits")
+		}
+		return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: content,
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+	}
+
+	recordAudit(ctx, memberActor(member), audit.MeritTransferred, memberTarget(receiver), nil, map[string]interface{}{
+		"id":     transferId,
+		"amount": amount,
+		"reason": reason,
+	})
+
+	if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: fmt.Sprintf("Transferred %d merits to %s!", amount, receiver.Name),
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	}); err != nil {
+		return errors.Wrap(err, "responding to merit transfer command")
+	}
+
+	return nil
+}
+
+// settleRedemptionButtonHandler marks a redemption as fulfilled, or refunds it
+func settleRedemptionButtonHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) error {
+	logger := utils.GetLoggerFromContext(ctx).(*log.Entry)
+	logger.Debug("settle redemption button handler")
+
+	if !allowed(i.Member, "MERIT") {
+		return InvalidPermissions
+	}
+
+	officer := utils.GetMemberFromContext(ctx).(*members.Member)
+
+	id := strings.Split(i.MessageComponentData().CustomID, ":")
+	settle, action, resolution := bank.Fulfil, audit.RedemptionFulfilled, "Fulfilled"
+	if id[1] == "refund" {
+		settle, action, resolution = bank.Refund, audit.RedemptionRefunded, "Refunded"
+	}
+
+	redemption, err := settle(ctx, id[2], officer.Id)
+	if err != nil {
+		if !errors.Is(err, bank.AlreadySettled) && !errors.Is(err, bank.RedemptionNotFound) {
+			return errors.Wrap(err, "settling redemption")
+		}
+		return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "That redemption was already dealt with",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+	}
+
+	recordAudit(ctx, memberActor(officer), action, audit.Target{Type: audit.MemberTarget, Id: redemption.MemberId}, nil, redemption)
+
+	embeds := i.Message.Embeds
+	if len(embeds) > 0 {
+		embeds[0].Footer = &discordgo.MessageEmbedFooter{
+			Text: resolution + " by " + officer.Name,
+		}
+	}
+
+	if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     embeds,
+			Components: []discordgo.MessageComponent{},
+		},
+	}); err != nil {
+		return errors.Wrap(err, "responding to redemption button")
+	}
+
+	return nil
+}
@@ -7,11 +7,23 @@ import (
 	"github.com/apex/log"
 	"github.com/bwmarrin/discordgo"
 	"github.com/pkg/errors"
+	"github.com/sol-armada/sol-bot/audit"
 	"github.com/sol-armada/sol-bot/members"
 	"github.com/sol-armada/sol-bot/utils"
 )
 
-func giveDemeritCommandHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) error {
+func demeritCommandHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) error {
+	subcommand := i.ApplicationCommandData().Options[0]
+	switch subcommand.Name {
+	case "give":
+		return giveDemeritCommandHandler(ctx, s, i, subcommand)
+	case "revoke":
+		return revokeDemeritCommandHandler(ctx, s, i, subcommand)
+	}
+	return errors.New("unknown demerit subcommand " + subcommand.Name)
+}
+
+func giveDemeritCommandHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, subcommand *discordgo.ApplicationCommandInteractionDataOption) error {
 	logger := utils.GetLoggerFromContext(ctx).(*log.Entry)
 	logger.Debug("demerit command")
 
@@ -19,24 +31,27 @@ func giveDemeritCommandHandler(ctx context.Context, s *discordgo.Session, i *dis
 		return InvalidPermissions
 	}
 
-	data := i.ApplicationCommandData()
+	options := optionsByName(subcommand.Options)
 
-	receivingDiscordUser := data.Options[0].UserValue(s)
+	receivingDiscordUser := options["member"].UserValue(s)
 
-	receivingMember, err := members.Get(receivingDiscordUser.ID)
+	receivingMember, err := members.Get(ctx, receivingDiscordUser.ID)
 	if err != nil {
 		return errors.Wrap(err, "getting receiving member")
 	}
 
-	givingMember, err := members.Get(i.Member.User.ID)
+	givingMember, err := members.Get(ctx, i.Member.User.ID)
 	if err != nil {
 		return errors.Wrap(err, "getting member from storage for demerit command")
 	}
 
-	if err := receivingMember.GiveDemerit(data.Options[1].StringValue(), givingMember); err != nil {
+	demerit, err := receivingMember.GiveDemerit(ctx, options["reason"].StringValue(), givingMember, meritDetails(options))
+	if err != nil {
 		return errors.Wrap(err, "giving member demerit")
 	}
 
+	recordAudit(ctx, memberActor(givingMember), audit.DemeritGiven, memberTarget(receivingMember), nil, demerit)
+
 	if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseChannelMessageWithSource,
 		Data: &discordgo.InteractionResponseData{
@@ -47,5 +62,17 @@ func giveDemeritCommandHandler(ctx context.Context, s *discordgo.Session, i *dis
 		return errors.Wrap(err, "responding to demerit command")
 	}
 
+	if err := escalateDemerits(ctx, s, receivingMember); err != nil {
+		logger.WithError(err).Error("escalating demerits")
+	}
+
 	return nil
 }
+
+func revokeDemeritCommandHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, subcommand *discordgo.ApplicationCommandInteractionDataOption) error {
+	return revokeCommandHandler(ctx, s, i, subcommand, "demerit", members.RevokeDemerit, audit.DemeritRevoked)
+}
+
+func demeritAutocompleteHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) error {
+	return revokeAutocompleteHandler(ctx, s, i, "demerit", func(m *members.Member) []*members.Merit { return m.Demerits })
+}
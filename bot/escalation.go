@@ -0,0 +1,213 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/bwmarrin/discordgo"
+	"github.com/pkg/errors"
+	"github.com/sol-armada/sol-bot/audit"
+	"github.com/sol-armada/sol-bot/members"
+	"github.com/sol-armada/sol-bot/settings"
+	"github.com/sol-armada/sol-bot/utils"
+)
+
+// escalateDemerits applies any of the configured escalation rules the member
+// has reached with their latest demerit
+func escalateDemerits(ctx context.Context, s *discordgo.Session, member *members.Member) error {
+	rules := []members.EscalationRule{}
+	if err := settings.UnmarshalKey("FEATURES.MERIT.ESCALATIONS", &rules); err != nil {
+		return errors.Wrap(err, "reading escalation rules")
+	}
+
+	for _, rule := range member.DueEscalations(rules) {
+		if err := escalate(ctx, s, member, rule); err != nil {
+			return errors.Wrap(err, "escalating "+rule.Name)
+		}
+	}
+
+	return nil
+}
+
+func escalate(ctx context.Context, s *discordgo.Session, member *members.Member, rule members.EscalationRule) error {
+	logger := log.WithFields(log.Fields{
+		"func":   "escalate",
+		"member": member.Id,
+		"rule":   rule.Name,
+	})
+	logger.Info("escalating demerits")
+
+	escalation := members.NewEscalation(rule, len(member.DemeritsWithin(rule.Days)))
+
+	// the alert still goes out if discord won't let one of these happen, so
+	// the officers can deal with it by hand
+	if rule.RoleId != "" {
+		if err := s.GuildMemberRoleAdd(bot.GuildId, member.Id, rule.RoleId); err != nil {
+			logger.WithError(err).Warn("adding escalation role")
+		} else {
+			escalation.RoleId = rule.RoleId
+		}
+	}
+
+	if rule.Timeout > 0 {
+		until := time.Now().Add(rule.Timeout)
+		if err := s.GuildMemberTimeout(bot.GuildId, member.Id, &until); err != nil {
+			logger.WithError(err).Warn("timing out member")
+		} else {
+			escalation.Timeout = rule.Timeout
+		}
+	}
+
+	if rule.DM != "" {
+		channel, err := s.UserChannelCreate(member.Id)
+		if err == nil {
+			_, err = s.ChannelMessageSend(channel.ID, rule.DM)
+		}
+		if err != nil {
+			logger.WithError(err).Warn("sending escalation dm")
+		} else {
+			escalation.DMSent = true
+		}
+	}
+
+	channelId := settings.GetStringWithDefault("FEATURES.MERIT.ESCALATION_CHANNEL_ID", settings.GetString("DISCORD.OFFICER_CHANNEL_ID"))
+	if channelId != "" {
+		message, err := s.ChannelMessageSendComplex(channelId, &discordgo.MessageSend{
+			Embeds:     []*discordgo.MessageEmbed{escalationEmbed(member, escalation, rule.Days)},
+			Components: escalationComponents(member, escalation),
+		})
+		if err != nil {
+			logger.WithError(err).Warn("sending escalation alert")
+		} else {
+			escalation.ChannelId = message.ChannelID
+			escalation.MessageId = message.ID
+		}
+	}
+
+	if err := member.AddEscalation(ctx, escalation); err != nil {
+		return errors.Wrap(err, "saving escalation")
+	}
+
+	recordAudit(ctx, audit.System, audit.DemeritEscalated, memberTarget(member), nil, escalation)
+
+	return nil
+}
+
+func escalationEmbed(member *members.Member, escalation *members.Escalation, days int) *discordgo.MessageEmbed {
+	period := ""
+	if days > 0 {
+		period = fmt.Sprintf(" in the last %d days", days)
+	}
+
+	taken := []string{"Officers alerted"}
+	if escalation.RoleId != "" {
+		taken = append(taken, fmt.Sprintf("Given <@&%s>", escalation.RoleId))
+	}
+	if escalation.Timeout > 0 {
+		taken = append(taken, fmt.Sprintf("Timed out until <t:%d:f>", escalation.When.Add(escalation.Timeout).Unix()))
+	}
+	if escalation.DMSent {
+		taken = append(taken, "Sent a DM")
+	}
+
+	return &discordgo.MessageEmbed{
+		Title:       "Demerit Escalation: " + escalation.Rule,
+		Description: fmt.Sprintf("<@%s> has %d active demerits%s", member.Id, escalation.Demerits, period),
+		Color:       0xFF0000,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Demerits", Value: meritList(member.DemeritsWithin(days), false)},
+			{Name: "Actions Taken", Value: strings.Join(taken, "\n")},
+		},
+		Timestamp: escalation.When.Format(time.RFC3339),
+	}
+}
+
+func escalationComponents(member *members.Member, escalation *members.Escalation) []discordgo.MessageComponent {
+	id := member.Id + ":" + escalation.Id
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{
+					Label:    "Acknowledge",
+					Style:    discordgo.PrimaryButton,
+					CustomID: "merit:acknowledge:" + id,
+				},
+				discordgo.Button{
+					Label:    "Dismiss",
+					Style:    discordgo.SecondaryButton,
+					CustomID: "merit:dismiss:" + id,
+				},
+			},
+		},
+	}
+}
+
+// escalationButtonHandler acknowledges or dismisses an escalation alert.
+// Dismissing takes back the role and timeout the escalation gave.
+func escalationButtonHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) error {
+	logger := utils.GetLoggerFromContext(ctx).(*log.Entry)
+	logger.Debug("escalation button handler")
+
+	if !allowed(i.Member, "MERIT") {
+		return InvalidPermissions
+	}
+
+	officer := utils.GetMemberFromContext(ctx).(*members.Member)
+
+	id := strings.Split(i.MessageComponentData().CustomID, ":")
+	status, action, resolution := members.EscalationAcknowledged, audit.EscalationAcknowledged, "Acknowledged"
+	if id[1] == "dismiss" {
+		status, action, resolution = members.EscalationDismissed, audit.EscalationDismissed, "Dismissed"
+	}
+
+	member, escalation, err := members.ResolveEscalation(ctx, id[2], id[3], status, officer)
+	if err != nil {
+		if !errors.Is(err, members.EscalationNotFound) && !errors.Is(err, members.EscalationAlreadyResolved) {
+			return errors.Wrap(err, "resolving escalation")
+		}
+		return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "That escalation was already dealt with",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+	}
+
+	if status == members.EscalationDismissed {
+		if escalation.RoleId != "" {
+			if err := s.GuildMemberRoleRemove(bot.GuildId, member.Id, escalation.RoleId); err != nil {
+				logger.WithError(err).Warn("removing escalation role")
+			}
+		}
+		if escalation.Timeout > 0 {
+			if err := s.GuildMemberTimeout(bot.GuildId, member.Id, nil); err != nil {
+				logger.WithError(err).Warn("removing timeout")
+			}
+		}
+	}
+
+	recordAudit(ctx, memberActor(officer), action, memberTarget(member), nil, escalation)
+
+	embeds := i.Message.Embeds
+	if len(embeds) > 0 {
+		embeds[0].Footer = &discordgo.MessageEmbedFooter{
+			Text: resolution + " by " + officer.Name,
+		}
+	}
+
+	if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     embeds,
+			Components: []discordgo.MessageComponent{},
+		},
+	}); err != nil {
+		return errors.Wrap(err, "responding to escalation button")
+	}
+
+	return nil
+}
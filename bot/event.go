@@ -0,0 +1,418 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/bwmarrin/discordgo"
+	"github.com/pkg/errors"
+	"github.com/sol-armada/sol-bot/audit"
+	customerrors "github.com/sol-armada/sol-bot/errors"
+	"github.com/sol-armada/sol-bot/events"
+	"github.com/sol-armada/sol-bot/members"
+	"github.com/sol-armada/sol-bot/settings"
+	"github.com/sol-armada/sol-bot/utils"
+)
+
+// how many upcoming events /event list shows
+const maxEventsListed = 10
+
+func eventCommandHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) error {
+	subcommand := i.ApplicationCommandData().Options[0]
+	switch subcommand.Name {
+	case "create":
+		return createEventCommandHandler(ctx, s, i, subcommand)
+	case "edit":
+		return editEventCommandHandler(ctx, s, i, subcommand)
+	case "cancel":
+		return cancelEventCommandHandler(ctx, s, i, subcommand)
+	case "list":
+		return listEventsCommandHandler(ctx, s, i)
+	}
+	return errors.New("unknown event subcommand " + subcommand.Name)
+}
+
+// eventInputMessage explains what was wrong with what was given for an event,
+// or is empty if the error isn't the member's fault
+func eventInputMessage(err error) string {
+	switch {
+	case errors.Is(err, customerrors.ErrMissingName):
+		return "The event needs a name"
+	case errors.Is(err, customerrors.ErrMissingStart):
+		return "The event needs a start time"
+	case errors.Is(err, customerrors.ErrStartWrongFormat):
+		return fmt.Sprintf("The start should be how long from now like 2h30m, or a UTC time like %s", events.TimeLayout)
+	case errors.Is(err, customerrors.ErrMissingDuration):
+		return "The duration should be how long the event runs for like 2h"
+	}
+	return ""
+}
+
+// eventDuration reads how long an event runs for, 0 if it can't be read
+func eventDuration(value string) time.Duration {
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0
+	}
+	return duration
+}
+
+// applyEventOptions sets whatever the options give on the event
+func applyEventOptions(s *discordgo.Session, event *events.Event, options map[string]*discordgo.ApplicationCommandInteractionDataOption) error {
+	if o := options["name"]; o != nil {
+		if o.StringValue() == "" {
+			return customerrors.ErrMissingName
+		}
+		event.Name = o.StringValue()
+	}
+	if o := options["start"]; o != nil {
+		start, err := events.ParseStart(o.StringValue(), time.Now())
+		if err != nil {
+			return err
+		}
+		if !start.Equal(event.Start) {
+			// reminders are worked out from the start, so they go out again
+			event.RemindersSent = nil
+		}
+		event.Start = start
+	}
+	if o := options["duration"]; o != nil {
+		duration := eventDuration(o.StringValue())
+		if duration <= 0 {
+			return customerrors.ErrMissingDuration
+		}
+		event.Duration = duration
+	}
+	if o := options["channel"]; o != nil {
+		event.ChannelId = o.ChannelValue(s).ID
+	}
+	if o := options["gameplay"]; o != nil {
+		event.Gameplay = members.ToGameplayType(o.StringValue())
+	}
+	if o := options["host"]; o != nil {
+		event.HostId = o.UserValue(s).ID
+	}
+	return nil
+}
+
+func createEventCommandHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, subcommand *discordgo.ApplicationCommandInteractionDataOption) error {
+	logger := utils.GetLoggerFromContext(ctx).(*log.Entry)
+	logger.Debug("create event command")
+
+	if !allowed(i.Member, "EVENTS") {
+		return InvalidPermissions
+	}
+
+	member := utils.GetMemberFromContext(ctx).(*members.Member)
+	options := optionsByName(subcommand.Options)
+
+	var event *events.Event
+	start, err := events.ParseStart(options["start"].StringValue(), time.Now())
+	if err == nil {
+		event, err = events.New(options["name"].StringValue(), start, eventDuration(options["duration"].StringValue()), member.Id)
+	}
+	if err == nil {
+		err = applyEventOptions(s, event, options)
+	}
+	if err != nil {
+		message := eventInputMessage(err)
+		if message == "" {
+			return errors.Wrap(err, "creating event")
+		}
+		return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: message,
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+	}
+
+	if !event.Start.After(time.Now()) {
+		return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "The event has to start in the future",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+	}
+
+	channelId := settings.GetString("FEATURES.EVENTS.CHANNEL_ID")
+	message, err := s.ChannelMessageSendComplex(channelId, event.ToDiscordMessage())
+	if err != nil {
+		return errors.Wrap(err, "sending event message")
+	}
+	event.MessageChannelId = message.ChannelID
+	event.MessageId = message.ID
+
+	if err := event.Save(ctx); err != nil {
+		return errors.Wrap(err, "saving event")
+	}
+
+	recordAudit(ctx, memberActor(member), audit.EventCreated, eventTarget(event), nil, eventSnapshot(event))
+
+	if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: fmt.Sprintf("Created %s for <t:%d:F>", event.Name, event.Start.Unix()),
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	}); err != nil {
+		return errors.Wrap(err, "responding to create event command")
+	}
+
+	return nil
+}
+
+func editEventCommandHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, subcommand *discordgo.ApplicationCommandInteractionDataOption) error {
+	logger := utils.GetLoggerFromContext(ctx).(*log.Entry)
+	logger.Debug("edit event command")
+
+	if !allowed(i.Member, "EVENTS") {
+		return InvalidPermissions
+	}
+
+	member := utils.GetMemberFromContext(ctx).(*members.Member)
+	options := optionsByName(subcommand.Options)
+
+	var before map[string]interface{}
+	event, err := events.Update(ctx, options["event"].StringValue(), func(e *events.Event) error {
+		if e.Over() {
+			return events.ErrEventOver
+		}
+		before = eventSnapshot(e)
+		return applyEventOptions(s, e, options)
+	})
+	if err != nil {
+		message := eventInputMessage(err)
+		switch {
+		case errors.Is(err, events.ErrEventNotFound):
+			message = "That event was not found"
+		case errors.Is(err, events.ErrEventOver):
+			message = "That event already ended or was cancelled"
+		case message == "":
+			return errors.Wrap(err, "editing event")
+		}
+		return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: message,
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+	}
+
+	recordAudit(ctx, memberActor(member), audit.EventUpdated, eventTarget(event), before, eventSnapshot(event))
+
+	updateEventMessage(s, event)
+
+	if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: "Event updated!",
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	}); err != nil {
+		return errors.Wrap(err, "responding to edit event command")
+	}
+
+	return nil
+}
+
+func cancelEventCommandHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, subcommand *discordgo.ApplicationCommandInteractionDataOption) error {
+	logger := utils.GetLoggerFromContext(ctx).(*log.Entry)
+	logger.Debug("cancel event command")
+
+	if !allowed(i.Member, "EVENTS") {
+		return InvalidPermissions
+	}
+
+	member := utils.GetMemberFromContext(ctx).(*members.Member)
+
+	var before map[string]interface{}
+	event, err := events.Update(ctx, optionsByName(subcommand.Options)["event"].StringValue(), func(e *events.Event) error {
+		if e.Over() {
+			return events.ErrEventOver
+		}
+		before = eventSnapshot(e)
+		e.Status = events.Cancelled
+		return nil
+	})
+	if err != nil {
+		message := ""
+		switch {
+		case errors.Is(err, events.ErrEventNotFound):
+			message = "That event was not found"
+		case errors.Is(err, events.ErrEventOver):
+			message = "That event already ended or was cancelled"
+		default:
+			return errors.Wrap(err, "cancelling event")
+		}
+		return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: message,
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+	}
+
+	recordAudit(ctx, memberActor(member), audit.EventCancelled, eventTarget(event), before, eventSnapshot(event))
+
+	updateEventMessage(s, event)
+
+	if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: event.Name + " was cancelled",
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	}); err != nil {
+		return errors.Wrap(err, "responding to cancel event command")
+	}
+
+	return nil
+}
+
+func listEventsCommandHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) error {
+	logger := utils.GetLoggerFromContext(ctx).(*log.Entry)
+	logger.Debug("list events command")
+
+	upcoming, err := events.Upcoming(ctx, maxEventsListed)
+	if err != nil {
+		return errors.Wrap(err, "getting upcoming events")
+	}
+
+	lines := []string{}
+	for _, event := range upcoming {
+		line := fmt.Sprintf("**%s** <t:%d:F> (<t:%d:R>)", event.Name, event.Start.Unix(), event.Start.Unix())
+		if event.Status == events.Live {
+			line = fmt.Sprintf("**%s** live now", event.Name)
+		}
+		if event.MessageId != "" {
+			line += fmt.Sprintf(" [RSVP](https://discord.com/channels/%s/%s/%s)", bot.GuildId, event.MessageChannelId, event.MessageId)
+		}
+		lines = append(lines, line)
+	}
+	description := strings.Join(lines, "\n")
+	if description == "" {
+		description = "No events coming up"
+	}
+
+	if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags: discordgo.MessageFlagsEphemeral,
+			Embeds: []*discordgo.MessageEmbed{
+				{
+					Title:       "Upcoming Events",
+					Description: description,
+				},
+			},
+		},
+	}); err != nil {
+		return errors.Wrap(err, "responding to list events command")
+	}
+
+	return nil
+}
+
+// eventAutocompleteHandler offers the upcoming events matching what was typed
+func eventAutocompleteHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) error {
+	logger := utils.GetLoggerFromContext(ctx).(*log.Entry)
+	logger.Debug("event autocomplete")
+
+	choices := []*discordgo.ApplicationCommandOptionChoice{}
+
+	subcommand := i.ApplicationCommandData().Options[0]
+	option := optionsByName(subcommand.Options)["event"]
+	if allowed(i.Member, "EVENTS") && option != nil && option.Focused {
+		upcoming, err := events.Upcoming(ctx, 0)
+		if err != nil {
+			return errors.Wrap(err, "getting upcoming events")
+		}
+
+		typed := strings.ToLower(option.StringValue())
+		for _, event := range upcoming {
+			if len(choices) == 25 {
+				break
+			}
+			if !strings.Contains(strings.ToLower(event.Name), typed) {
+				continue
+			}
+			choices = append(choices, &discordgo.ApplicationCommandOptionChoice{
+				Name:  fmt.Sprintf("%s (%s UTC)", event.Name, event.Start.Format(events.TimeLayout)),
+				Value: event.Id,
+			})
+		}
+	}
+
+	if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionApplicationCommandAutocompleteResult,
+		Data: &discordgo.InteractionResponseData{
+			Choices: choices,
+		},
+	}); err != nil {
+		return errors.Wrap(err, "responding to event autocomplete")
+	}
+
+	return nil
+}
+
+// rsvpButtonHandler records the member's response and refreshes the RSVP
+// message
+func rsvpButtonHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) error {
+	logger := utils.GetLoggerFromContext(ctx).(*log.Entry)
+	logger.Debug("rsvp button handler")
+
+	id := strings.Split(i.MessageComponentData().CustomID, ":")
+
+	event, err := events.RSVP(ctx, id[2], i.Member.User.ID, events.Response(id[1]))
+	if err != nil {
+		if !errors.Is(err, events.ErrEventOver) && !errors.Is(err, events.ErrEventNotFound) {
+			return errors.Wrap(err, "saving rsvp")
+		}
+		return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "That event is over",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+	}
+
+	message := event.ToDiscordMessage()
+	if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     message.Embeds,
+			Components: message.Components,
+		},
+	}); err != nil {
+		return errors.Wrap(err, "responding to rsvp button")
+	}
+
+	return nil
+}
+
+// updateEventMessage refreshes the posted RSVP message. The event was already
+// saved so failing here is only logged.
+func updateEventMessage(s *discordgo.Session, event *events.Event) {
+	if event.MessageId == "" {
+		return
+	}
+
+	message := event.ToDiscordMessage()
+	if _, err := s.ChannelMessageEditComplex(&discordgo.MessageEdit{
+		Channel:    event.MessageChannelId,
+		ID:         event.MessageId,
+		Embeds:     &message.Embeds,
+		Components: &message.Components,
+	}); err != nil {
+		log.WithError(err).WithField("event", event.Id).Warn("updating event message")
+	}
+}
@@ -0,0 +1,246 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/pkg/errors"
+	attdnc "github.com/sol-armada/sol-bot/attendance"
+	"github.com/sol-armada/sol-bot/audit"
+	"github.com/sol-armada/sol-bot/events"
+	"github.com/sol-armada/sol-bot/members"
+	"github.com/sol-armada/sol-bot/settings"
+)
+
+// MonitorEvents sends event reminders, and opens attendance when an event
+// starts
+func MonitorEvents(stop <-chan bool) {
+	logger := log.WithField("func", "MonitorEvents")
+	logger.Info("monitoring events")
+
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			logger.Warn("stopping monitor")
+			return
+		case <-ticker.C:
+		}
+
+		upcoming, err := events.Upcoming(bot.ctx, 0)
+		if err != nil {
+			logger.WithError(err).Error("getting upcoming events")
+			continue
+		}
+
+		now := time.Now().UTC()
+		for _, event := range upcoming {
+			if err := checkEvent(bot.ctx, event, now); err != nil {
+				logger.WithError(err).WithField("event", event.Id).Error("checking event")
+			}
+		}
+	}
+}
+
+func checkEvent(ctx context.Context, event *events.Event, now time.Time) error {
+	switch {
+	case event.ScheduledEventId != "":
+		// Discord runs its scheduled events
+		return nil
+	case event.Status == events.Scheduled && !now.Before(event.Start):
+		return startEvent(ctx, event)
+	case event.Status == events.Live && !now.Before(event.End()):
+		return endEvent(ctx, event)
+	case event.Status == events.Scheduled:
+		return remindEvent(ctx, event, now)
+	}
+	return nil
+}
+
+// reminderOffsets are how long before an event starts reminders go out
+func reminderOffsets() ([]time.Duration, error) {
+	offsets := []time.Duration{time.Hour}
+	if err := settings.UnmarshalKey("FEATURES.EVENTS.REMINDERS", &offsets); err != nil {
+		return nil, errors.Wrap(err, "reading event reminders")
+	}
+	return offsets, nil
+}
+
+// remindEvent reminds whoever is going or might go, by DM or by pinging them
+// under the RSVP message
+func remindEvent(ctx context.Context, event *events.Event, now time.Time) error {
+	offsets, err := reminderOffsets()
+	if err != nil {
+		return err
+	}
+
+	_, due, ok := event.DueReminder(offsets, now)
+	if !ok {
+		return nil
+	}
+
+	// mark it sent first, a reminder missed is better than one sent every
+	// minute if discord keeps failing
+	if _, err := events.Update(ctx, event.Id, func(e *events.Event) error {
+		e.RemindersSent = append(e.RemindersSent, due...)
+		return nil
+	}); err != nil {
+		return errors.Wrap(err, "marking reminder sent")
+	}
+
+	remind := append(event.Responded(events.Going), event.Responded(events.Maybe)...)
+	if len(remind) == 0 {
+		return nil
+	}
+
+	content := fmt.Sprintf("**%s** starts <t:%d:R>", event.Name, event.Start.Unix())
+	if event.ChannelId != "" {
+		content += " in <#" + event.ChannelId + ">"
+	}
+
+	if settings.GetStringWithDefault("FEATURES.EVENTS.REMIND_BY", "ping") == "dm" {
+		for _, memberId := range remind {
+			channel, err := bot.UserChannelCreate(memberId)
+			if err == nil {
+				_, err = bot.ChannelMessageSend(channel.ID, content)
+			}
+			if err != nil {
+				log.WithError(err).WithField("member", memberId).Warn("sending event reminder")
+			}
+		}
+		return nil
+	}
+
+	mentions := []string{}
+	for _, memberId := range remind {
+		mentions = append(mentions, "<@"+memberId+">")
+	}
+	if _, err := bot.ChannelMessageSend(event.MessageChannelId, content+"\n"+strings.Join(mentions, " ")); err != nil {
+		return errors.Wrap(err, "sending event reminder")
+	}
+
+	return nil
+}
+
+// errAttendanceOpened is when the event already has an attendance record
+var errAttendanceOpened = errors.New("event attendance already opened")
+
+// startEvent opens an attendance record for the event with whoever is in its
+// voice channel already
+func startEvent(ctx context.Context, event *events.Event) error {
+	if err := openEventAttendance(ctx, event, nil); err != nil {
+		return err
+	}
+
+	started, err := events.Update(ctx, event.Id, func(e *events.Event) error {
+		e.Status = events.Live
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "starting event")
+	}
+
+	updateEventMessage(bot.Session, started)
+
+	return nil
+}
+
+// openEventAttendance opens an attendance record for the event with whoever is
+// in its voice channel, and the other users given. Nothing is opened if the
+// event already has a record.
+func openEventAttendance(ctx context.Context, event *events.Event, userIds []string) error {
+	host, err := members.Get(ctx, event.HostId)
+	if err != nil {
+		if !errors.Is(err, members.MemberNotFound) {
+			return errors.Wrap(err, "getting event host")
+		}
+		host = &members.Member{Id: event.HostId}
+	}
+
+	attendance := attdnc.New(event.Name, host)
+	attendance.EventId = event.Id
+	attendance.SetRole(event.HostId, attdnc.Host)
+
+	// link it to the event first so two records are never opened for it
+	if _, err := events.Update(ctx, event.Id, func(e *events.Event) error {
+		if e.AttendanceId != "" {
+			return errAttendanceOpened
+		}
+		e.AttendanceId = attendance.Id
+		return nil
+	}); err != nil {
+		if errors.Is(err, errAttendanceOpened) {
+			return nil
+		}
+		return errors.Wrap(err, "linking event attendance")
+	}
+
+	if event.ChannelId != "" {
+		inVoice, err := voiceAttendees(bot.Session, []string{event.ChannelId})
+		if err != nil {
+			log.WithError(err).WithField("event", event.Id).Warn("getting event voice channel attendees")
+		}
+		userIds = append(inVoice, userIds...)
+	}
+
+	for _, userId := range userIds {
+		member, err := attendee(ctx, bot.Session, userId)
+		if err != nil {
+			log.WithError(err).WithField("member", userId).Warn("getting member for event attendance")
+			continue
+		}
+		if member.IsBot {
+			continue
+		}
+		attendance.AddMember(member)
+	}
+
+	if err := attendance.Save(ctx); err != nil {
+		unlinkEventAttendance(ctx, event.Id, attendance.Id)
+		return errors.Wrap(err, "saving event attendance")
+	}
+
+	if err := publishAttendance(ctx, bot.Session, attendance); err != nil {
+		if err := attendance.Delete(ctx); err != nil {
+			log.WithError(err).WithField("attendance", attendance.Id).Error("deleting unposted event attendance")
+		}
+		unlinkEventAttendance(ctx, event.Id, attendance.Id)
+		return errors.Wrap(err, "posting event attendance")
+	}
+
+	recordAudit(ctx, audit.System, audit.AttendanceCreated, attendanceTarget(attendance), nil, attendanceSnapshot(attendance))
+
+	return nil
+}
+
+// unlinkEventAttendance takes the attendance record off the event when it
+// couldn't be opened, so the next try opens one
+func unlinkEventAttendance(ctx context.Context, eventId string, attendanceId string) {
+	if _, err := events.Update(ctx, eventId, func(e *events.Event) error {
+		if e.AttendanceId == attendanceId {
+			e.AttendanceId = ""
+		}
+		return nil
+	}); err != nil {
+		log.WithError(err).WithField("event", eventId).Error("unlinking event attendance")
+	}
+}
+
+func endEvent(ctx context.Context, event *events.Event) error {
+	ended, err := events.Update(ctx, event.Id, func(e *events.Event) error {
+		e.Status = events.Ended
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "ending event")
+	}
+
+	updateEventMessage(bot.Session, ended)
+
+	return nil
+}
@@ -0,0 +1,17 @@
+package bot
+
+import (
+	"github.com/bwmarrin/discordgo"
+	"github.com/sol-armada/sol-bot/members"
+)
+
+// the cached member is dropped whenever Discord tells us something about them
+// changed, so nicknames, roles and leaving show up on the next lookup
+
+func memberUpdateCacheHandler(_ *discordgo.Session, u *discordgo.GuildMemberUpdate) {
+	members.Invalidate(u.User.ID)
+}
+
+func memberRemoveCacheHandler(_ *discordgo.Session, r *discordgo.GuildMemberRemove) {
+	members.Invalidate(r.User.ID)
+}
@@ -5,12 +5,14 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"reflect"
 	"strings"
 	"time"
 
 	"github.com/apex/log"
 	"github.com/bwmarrin/discordgo"
 	"github.com/pkg/errors"
+	"github.com/sol-armada/sol-bot/audit"
 	"github.com/sol-armada/sol-bot/health"
 	"github.com/sol-armada/sol-bot/members"
 	"github.com/sol-armada/sol-bot/ranks"
@@ -45,6 +47,7 @@ func MemberMonitor(stop <-chan bool) {
 	}
 
 	logger.Info("monitoring discord for members")
+	ctx := bot.ctx
 	ticker := time.NewTicker(time.Second)
 	defer ticker.Stop()
 
@@ -90,7 +93,7 @@ func MemberMonitor(stop <-chan bool) {
 			}
 
 			// actually do the members update
-			if err := updateMembers(discordMembers, stop); err != nil {
+			if err := updateMembers(ctx, discordMembers, stop); err != nil {
 				if strings.Contains(err.Error(), "Forbidden") {
 					lastChecked = time.Now()
 					continue
@@ -102,13 +105,13 @@ func MemberMonitor(stop <-chan bool) {
 
 			// get the stored members
 			storedMembers := []*members.Member{}
-			cur, err := membersStore.List(bson.M{}, 0, 0)
+			cur, err := membersStore.List(ctx, bson.M{}, 0, 0)
 			if err != nil {
 				logger.Error("getting stored members", "error", err)
 				continue
 			}
 
-			if err := cur.All(context.Background(), &storedMembers); err != nil {
+			if err := cur.All(ctx, &storedMembers); err != nil {
 				logger.Error("reading in stored members", "error", err)
 				continue
 			}
@@ -122,17 +125,28 @@ func MemberMonitor(stop <-chan bool) {
 				default:
 				}
 
+				if storedMember.IsArchived() {
+					continue
+				}
+
 				if !stillInDiscord(storedMember, discordMembers) || storedMember.IsBot {
-					if err := storedMember.Delete(); err != nil {
-						logger.Error("deleting member", "error", err, "member", storedMember)
+					before := *storedMember
+					if err := storedMember.Archive(ctx); err != nil {
+						logger.Error("archiving member", "error", err, "member", storedMember)
 						continue
 					}
+
+					recordAudit(ctx, audit.System, audit.MemberArchived, memberTarget(storedMember), &before, storedMember)
+					refreshOnboardingMessage(ctx, bot.Session, storedMember)
 				}
 			}
 
 			lastChecked = time.Now()
 
 			logger.Info("members updated", "count", len(discordMembers), "duration", time.Since(start))
+
+			cacheStats := members.CacheReport()
+			logger.Info("member cache", "hits", cacheStats.Hits, "misses", cacheStats.Misses, "hit_rate", cacheStats.HitRate(), "size", cacheStats.Size, "evictions", cacheStats.Evictions)
 		}
 
 		continue
@@ -161,7 +175,7 @@ func (b *Bot) GetMember(id string) (*discordgo.Member, error) {
 	return member, nil
 }
 
-func updateMembers(discordMembers []*discordgo.Member, stop <-chan bool) error {
+func updateMembers(ctx context.Context, discordMembers []*discordgo.Member, stop <-chan bool) error {
 	logger.Debug("checking members", "discord_members", len(discordMembers))
 
 	logger.Info(fmt.Sprintf("updating %d members", len(discordMembers)))
@@ -186,7 +200,8 @@ func updateMembers(discordMembers []*discordgo.Member, stop <-chan bool) error {
 		}
 
 		// get the stord user, if we have one
-		member, err := members.Get(discordMember.User.ID)
+		var before *members.Member
+		member, err := members.Get(ctx, discordMember.User.ID)
 		if err != nil {
 			if !errors.Is(err, members.MemberNotFound) {
 				mlogger.Error("getting member for update", "error", err)
@@ -194,13 +209,24 @@ func updateMembers(discordMembers []*discordgo.Member, stop <-chan bool) error {
 			}
 
 			member = members.New(discordMember)
-		}
+		} else {
+			// they came back while we weren't watching
+			if member.IsArchived() {
+				if err := restoreMember(ctx, bot.Session, member, discordMember); err != nil {
+					mlogger.Error("restoring archived member", "error", err)
+					continue
+				}
+			}
 
-		member.Name = strings.ReplaceAll(member.GetTrueNick(discordMember), ".", "")
-		member.Joined = discordMember.JoinedAt.UTC()
+			b := *member
+			before = &b
+		}
 
-		// rsi related stuff
-		if err = rsi.UpdateRsiInfo(member); err != nil {
+		// scrape rsi into a copy, it is slow and the stored member may change
+		// in the meantime
+		scraped := *member
+		scraped.Name = strings.ReplaceAll(member.GetTrueNick(discordMember), ".", "")
+		if err = rsi.UpdateRsiInfo(&scraped); err != nil {
 			if strings.Contains(err.Error(), "Forbidden") || strings.Contains(err.Error(), "Bad Gateway") {
 				return err
 			}
@@ -215,39 +241,41 @@ func updateMembers(discordMembers []*discordgo.Member, stop <-chan bool) error {
 			}
 
 			mlogger.Debug("rsi user not found", "error", err)
-			member.RSIMember = false
+			scraped.RSIMember = false
 		}
 
-		// discord related stuff
-		member.Avatar = discordMember.Avatar
-		if slices.Contains(discordMember.Roles, settings.GetString("DISCORD.ROLE_IDS.RECRUIT")) {
-			mlogger.Debug("is recruit")
-			member.Rank = ranks.Recruit
-			member.IsAffiliate = false
-			member.IsAlly = false
-			member.IsGuest = false
-		}
-		if slices.Contains(discordMember.Roles, settings.GetString("DISCORD.ROLE_IDS.ALLY")) {
-			mlogger.Debug("is ally")
-			member.Rank = ranks.None
-			member.IsAffiliate = false
-			member.IsAlly = true
-			member.IsGuest = false
+		apply := func(m *members.Member) error {
+			applyMonitorChanges(m, &scraped, discordMember)
+			return nil
 		}
-		if discordMember.User.Bot {
-			logger.Debug("is bot")
-			member.Rank = ranks.None
-			member.IsAffiliate = false
-			member.IsAlly = false
-			member.IsGuest = false
-			member.IsBot = true
+
+		if before == nil {
+			// not stored yet, so there is nothing to reload
+			_ = apply(member)
+			err = member.Save(ctx)
+		} else {
+			// reloads and applies the changes again if someone else saved the
+			// member while we were fetching rsi info
+			member, err = members.Update(ctx, member.Id, apply)
 		}
+		if err != nil {
+			if errors.Is(err, members.MemberConflict) {
+				mlogger.Warn("member kept changing during update, the next run will pick it up")
+				continue
+			}
 
-		logger.Debug("updating member", "member", member)
-		if err := member.Save(); err != nil {
 			return err
 		}
 
+		if before != nil {
+			auditMonitorChanges(ctx, before, member)
+
+			// attendance doesn't change here, so only the member matters
+			if !reflect.DeepEqual(before.GetOnboardingMessage(0), member.GetOnboardingMessage(0)) {
+				refreshOnboardingMessage(ctx, bot.Session, member)
+			}
+		}
+
 		// handle rank updates on members
 		// rankRoles := settings.GetStringMapString("DISCORD.ROLES.RANKS")
 		// membersRoleId := rankRoles[strings.ToLower(member.Rank.String())]
@@ -296,6 +324,96 @@ func updateMembers(discordMembers []*discordgo.Member, stop <-chan bool) error {
 	return nil
 }
 
+// applyMonitorChanges copies what the monitor found out from rsi and discord
+// onto the member and tracks the status change it caused
+func applyMonitorChanges(member *members.Member, scraped *members.Member, discordMember *discordgo.Member) {
+	member.Name = strings.ReplaceAll(member.GetTrueNick(discordMember), ".", "")
+	member.Joined = discordMember.JoinedAt.UTC()
+
+	previousStatus := member.Status()
+	previousRank := member.Rank
+	promoted := member.OfficerRank()
+
+	// rsi related stuff
+	member.RSIMember = scraped.RSIMember
+	member.IsAlly = scraped.IsAlly
+	member.IsGuest = scraped.IsGuest
+	member.IsAffiliate = scraped.IsAffiliate
+	member.Rank = scraped.Rank
+	member.PrimaryOrg = scraped.PrimaryOrg
+	member.Affiliations = scraped.Affiliations
+
+	rsiStatus := member.Status()
+
+	// discord related stuff
+	member.Avatar = discordMember.Avatar
+	if slices.Contains(discordMember.Roles, settings.GetString("DISCORD.ROLE_IDS.RECRUIT")) {
+		logger.Debug("is recruit", "id", member.Id)
+		member.Rank = ranks.Lowest()
+		member.IsAffiliate = false
+		member.IsAlly = false
+		member.IsGuest = false
+	}
+	if slices.Contains(discordMember.Roles, settings.GetString("DISCORD.ROLE_IDS.ALLY")) {
+		logger.Debug("is ally", "id", member.Id)
+		member.Rank = ranks.None
+		member.IsAffiliate = false
+		member.IsAlly = true
+		member.IsGuest = false
+	}
+	if discordMember.User.Bot {
+		logger.Debug("is bot", "id", member.Id)
+		member.Rank = ranks.None
+		member.IsAffiliate = false
+		member.IsAlly = false
+		member.IsGuest = false
+		member.IsBot = true
+	}
+
+	// rsi and the recruit role lag behind promotions, so they can only raise
+	// a rank an officer gave or take the member out of the ranks altogether
+	if promoted && !member.IsAffiliate && previousRank != ranks.None && member.Rank != ranks.None && member.Rank > previousRank {
+		member.Rank = previousRank
+	}
+
+	// credit the roles for the change if they overrode what rsi said
+	cause := members.CauseRSI
+	switch {
+	case member.IsBot:
+		cause = members.CauseMonitor
+	case member.Status() != rsiStatus:
+		cause = members.CauseRole
+	}
+	member.TrackStatus(previousStatus, cause, "")
+
+	logger.Debug("updating member", "member", member)
+}
+
+// auditMonitorChanges records the rank and affiliation changes the monitor
+// made to the member
+func auditMonitorChanges(ctx context.Context, before *members.Member, after *members.Member) {
+	if before.Rank != after.Rank {
+		recordAudit(ctx, audit.System, audit.RankChanged, memberTarget(after),
+			map[string]interface{}{"rank": before.Rank.String()},
+			map[string]interface{}{"rank": after.Rank.String()})
+	}
+
+	affiliation := func(m *members.Member) map[string]interface{} {
+		return map[string]interface{}{
+			"primary_org":     m.PrimaryOrg,
+			"affiliations":    m.Affiliations,
+			"bad_affiliation": m.BadAffiliation,
+			"rsi_member":      m.RSIMember,
+			"is_ally":         m.IsAlly,
+			"is_affiliate":    m.IsAffiliate,
+			"is_guest":        m.IsGuest,
+		}
+	}
+	if len(audit.Diff(affiliation(before), affiliation(after))) > 0 {
+		recordAudit(ctx, audit.System, audit.AffiliationChanged, memberTarget(after), affiliation(before), affiliation(after))
+	}
+}
+
 func stillInDiscord(member *members.Member, discordMembers []*discordgo.Member) bool {
 	for _, discordMember := range discordMembers {
 		if member.Id == discordMember.User.ID {
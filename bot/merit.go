@@ -3,40 +3,103 @@ package bot
 import (
 	"context"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/apex/log"
 	"github.com/bwmarrin/discordgo"
 	"github.com/pkg/errors"
+	"github.com/sol-armada/sol-bot/audit"
+	"github.com/sol-armada/sol-bot/bank"
 	"github.com/sol-armada/sol-bot/members"
 	"github.com/sol-armada/sol-bot/utils"
 )
 
-func giveMeritCommandHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) error {
+// how many merits or demerits to list before cutting the embed short
+const maxMeritsListed = 15
+
+func meritCommandHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) error {
+	subcommand := i.ApplicationCommandData().Options[0]
+	switch subcommand.Name {
+	case "give":
+		return giveMeritCommandHandler(ctx, s, i, subcommand)
+	case "list":
+		return listMeritsCommandHandler(ctx, s, i, subcommand)
+	case "revoke":
+		return revokeMeritCommandHandler(ctx, s, i, subcommand)
+	case "balance":
+		return balanceCommandHandler(ctx, s, i, subcommand)
+	case "ledger":
+		return ledgerCommandHandler(ctx, s, i, subcommand)
+	case "redeem":
+		return redeemCommandHandler(ctx, s, i, subcommand)
+	case "transfer":
+		return transferCommandHandler(ctx, s, i, subcommand)
+	}
+	return errors.New("unknown merit subcommand " + subcommand.Name)
+}
+
+func giveMeritCommandHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, subcommand *discordgo.ApplicationCommandInteractionDataOption) error {
 	logger := utils.GetLoggerFromContext(ctx).(*log.Entry)
 	logger.Debug("merit command")
 
-	user, err := members.Get(i.Member.User.ID)
+	user, err := members.Get(ctx, i.Member.User.ID)
 	if err != nil {
 		return errors.Wrap(err, "getting user from storage for merit command")
 	}
 
-	if !allowed(i.Member, "MERIT") {
+	// bank holders give merits out of their allowance
+	holder := bankEnabled() && isHolder(i.Member)
+	if !allowed(i.Member, "MERIT") && !holder {
 		return InvalidPermissions
 	}
 
-	data := i.ApplicationCommandData()
+	options := optionsByName(subcommand.Options)
 
-	receivingDiscordUser := data.Options[0].UserValue(s)
+	receivingDiscordUser := options["member"].UserValue(s)
 
-	receivingMember, err := members.Get(receivingDiscordUser.ID)
+	receivingMember, err := members.Get(ctx, receivingDiscordUser.ID)
 	if err != nil {
 		return errors.Wrap(err, "getting receiving member")
 	}
 
-	if err := receivingMember.GiveMerit(data.Options[1].StringValue(), user); err != nil {
+	merit := members.NewMerit(options["reason"].StringValue(), user, meritDetails(options))
+
+	if bankEnabled() {
+		if holder {
+			if err := topUpAllowance(ctx, user.Id); err != nil {
+				return errors.Wrap(err, "topping up allowance")
+			}
+			err = bank.Spend(ctx, user.Id, receivingMember.Id, merit.Value(), merit.Id)
+		} else {
+			err = bank.Earn(ctx, receivingMember.Id, merit.Value(), merit.Id, user.Id)
+		}
+		if errors.Is(err, bank.InsufficientBalance) {
+			return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+				Type: discordgo.InteractionResponseChannelMessageWithSource,
+				Data: &discordgo.InteractionResponseData{
+					Content: fmt.Sprintf("You don't have %d merits left to give", merit.Value()),
+					Flags:   discordgo.MessageFlagsEphemeral,
+				},
+			})
+		}
+		if err != nil {
+			return errors.Wrap(err, "paying for merit")
+		}
+	}
+
+	if err := receivingMember.AddMerit(ctx, merit); err != nil {
+		// the merit was paid for above, so undo that
+		if bankEnabled() {
+			if cancelErr := bank.CancelMerit(ctx, merit.Id, user.Id); cancelErr != nil {
+				logger.WithError(cancelErr).Error("cancelling payment for merit that wasn't given")
+			}
+		}
 		return errors.Wrap(err, "giving member merit")
 	}
 
+	recordAudit(ctx, memberActor(user), audit.MeritGiven, memberTarget(receivingMember), nil, merit)
+
 	if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseChannelMessageWithSource,
 		Data: &discordgo.InteractionResponseData{
@@ -49,3 +112,214 @@ func giveMeritCommandHandler(ctx context.Context, s *discordgo.Session, i *disco
 
 	return nil
 }
+
+func listMeritsCommandHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, subcommand *discordgo.ApplicationCommandInteractionDataOption) error {
+	logger := utils.GetLoggerFromContext(ctx).(*log.Entry)
+	logger.Debug("list merits command")
+
+	options := optionsByName(subcommand.Options)
+
+	user := options["member"].UserValue(s)
+	if user.ID != i.Member.User.ID && !allowed(i.Member, "MERIT") {
+		return InvalidPermissions
+	}
+
+	member, err := members.Get(ctx, user.ID)
+	if err != nil {
+		return errors.Wrap(err, "getting member for merit list")
+	}
+
+	all := options["all"] != nil && options["all"].BoolValue()
+
+	if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags: discordgo.MessageFlagsEphemeral,
+			Embeds: []*discordgo.MessageEmbed{
+				{
+					Title: "Merits of " + member.Name,
+					Fields: []*discordgo.MessageEmbedField{
+						{Name: "Net Score", Value: fmt.Sprintf("%d", member.MeritScore())},
+						{Name: "Merits", Value: meritList(member.Merits, all)},
+						{Name: "Demerits", Value: meritList(member.Demerits, all)},
+					},
+				},
+			},
+		},
+	}); err != nil {
+		return errors.Wrap(err, "responding to merit list command")
+	}
+
+	return nil
+}
+
+// meritList lists the newest merits first, only the ones that still count
+// unless all is asked for
+func meritList(merits []*members.Merit, all bool) string {
+	lines := []string{}
+	for n := len(merits) - 1; n >= 0; n-- {
+		if !all && !merits[n].Active() {
+			continue
+		}
+		if len(lines) == maxMeritsListed {
+			lines = append(lines, "and more")
+			break
+		}
+		lines = append(lines, merits[n].String())
+	}
+
+	if len(lines) == 0 {
+		return "None"
+	}
+
+	list := strings.Join(lines, "\n")
+	if len(list) > 1024 {
+		list = list[:1020] + "…"
+	}
+	return list
+}
+
+func revokeMeritCommandHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, subcommand *discordgo.ApplicationCommandInteractionDataOption) error {
+	return revokeCommandHandler(ctx, s, i, subcommand, "merit", members.RevokeMerit, audit.MeritRevoked)
+}
+
+type revokeFunc func(ctx context.Context, memberId string, id string, who *members.Member, reason string) (*members.Member, *members.Merit, error)
+
+// revokeCommandHandler revokes a merit or demerit, which are handled the same
+func revokeCommandHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, subcommand *discordgo.ApplicationCommandInteractionDataOption, kind string, revoke revokeFunc, action audit.Action) error {
+	logger := utils.GetLoggerFromContext(ctx).(*log.Entry)
+	logger.WithField("kind", kind).Debug("revoke command")
+
+	if !allowed(i.Member, "MERIT") {
+		return InvalidPermissions
+	}
+
+	officer := utils.GetMemberFromContext(ctx).(*members.Member)
+
+	options := optionsByName(subcommand.Options)
+
+	user := options["member"].UserValue(s)
+	id := options[kind].StringValue()
+	reason := ""
+	if options["reason"] != nil {
+		reason = options["reason"].StringValue()
+	}
+
+	member, revoked, err := revoke(ctx, user.ID, id, officer, reason)
+	if err != nil {
+		var content string
+		switch {
+		case errors.Is(err, members.MemberNotFound):
+			content = "That member was not found in the system!"
+		case errors.Is(err, members.MeritNotFound):
+			content = fmt.Sprintf("That member has no such %s", kind)
+		case errors.Is(err, members.MeritAlreadyRevoked):
+			content = fmt.Sprintf("That %s was already revoked", kind)
+		default:
+			return errors.Wrapf(err, "revoking %s", kind)
+		}
+		return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: content,
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+	}
+
+	// what the merit paid into the member's balance goes with it
+	if kind == "merit" && bankEnabled() {
+		if err := bank.ClawBack(ctx, member.Id, revoked.Id, officer.Id); err != nil {
+			logger.WithError(err).Error("clawing back revoked merit")
+		}
+	}
+
+	recordAudit(ctx, memberActor(officer), action, memberTarget(member), nil, revoked)
+
+	if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: fmt.Sprintf("Revoked %s's %s \"%s\"", member.Name, kind, revoked.Reason),
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	}); err != nil {
+		return errors.Wrapf(err, "responding to %s revoke command", kind)
+	}
+
+	return nil
+}
+
+func meritAutocompleteHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) error {
+	return revokeAutocompleteHandler(ctx, s, i, "merit", func(m *members.Member) []*members.Merit { return m.Merits })
+}
+
+// revokeAutocompleteHandler offers the chosen member's merits or demerits that
+// still count
+func revokeAutocompleteHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate, kind string, from func(m *members.Member) []*members.Merit) error {
+	logger := utils.GetLoggerFromContext(ctx).(*log.Entry)
+	logger.WithField("kind", kind).Debug("revoke autocomplete")
+
+	choices := []*discordgo.ApplicationCommandOptionChoice{}
+	respond := func() error {
+		return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionApplicationCommandAutocompleteResult,
+			Data: &discordgo.InteractionResponseData{
+				Choices: choices,
+			},
+		})
+	}
+
+	if !allowed(i.Member, "MERIT") {
+		return respond()
+	}
+
+	subcommand := i.ApplicationCommandData().Options[0]
+	options := optionsByName(subcommand.Options)
+	if options["member"] == nil || options[kind] == nil || !options[kind].Focused {
+		return respond()
+	}
+
+	// the user isn't resolved while autocompleting, only its id is sent
+	memberId, _ := options["member"].Value.(string)
+	member, err := members.Get(ctx, memberId)
+	if err != nil {
+		if errors.Is(err, members.MemberNotFound) {
+			return respond()
+		}
+		return errors.Wrapf(err, "getting member for %s autocomplete", kind)
+	}
+
+	found := members.FindMerits(from(member), options[kind].StringValue(), true)
+	for n := len(found) - 1; n >= 0 && len(choices) < 25; n-- {
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{
+			Name:  found[n].Label(),
+			Value: found[n].Id,
+		})
+	}
+
+	return respond()
+}
+
+// meritDetails reads the optional parts of a merit or demerit from the command
+func meritDetails(options map[string]*discordgo.ApplicationCommandInteractionDataOption) members.MeritDetails {
+	details := members.MeritDetails{}
+	if o := options["category"]; o != nil {
+		details.Category = o.StringValue()
+	}
+	if o := options["weight"]; o != nil {
+		details.Weight = int(o.IntValue())
+	}
+	if o := options["expires_in_days"]; o != nil {
+		expires := time.Now().UTC().AddDate(0, 0, int(o.IntValue()))
+		details.ExpiresAt = &expires
+	}
+	return details
+}
+
+func optionsByName(options []*discordgo.ApplicationCommandInteractionDataOption) map[string]*discordgo.ApplicationCommandInteractionDataOption {
+	byName := map[string]*discordgo.ApplicationCommandInteractionDataOption{}
+	for _, o := range options {
+		byName[o.Name] = o
+	}
+	return byName
+}
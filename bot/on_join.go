@@ -1,8 +1,14 @@
 package bot
 
 import (
+	"context"
+	"fmt"
+	"time"
+
 	"github.com/apex/log"
 	"github.com/bwmarrin/discordgo"
+	"github.com/pkg/errors"
+	"github.com/sol-armada/sol-bot/audit"
 	"github.com/sol-armada/sol-bot/members"
 	"github.com/sol-armada/sol-bot/settings"
 )
@@ -20,15 +26,39 @@ func onJoinHandler(s *discordgo.Session, i *discordgo.GuildMemberAdd) {
 		return
 	}
 
-	member := members.New(i.Member)
+	ctx := bot.ctx
+
+	// welcome back anyone we already know about
+	member, err := members.Get(ctx, i.Member.User.ID)
+	if err != nil && !errors.Is(err, members.MemberNotFound) {
+		logger.WithError(err).Error("getting member")
+		return
+	}
+	if member != nil {
+		if !member.IsArchived() && member.LeftAt == nil {
+			logger.Debug("member is already known")
+			return
+		}
 
-	if err := member.Save(); err != nil {
+		if err := restoreMember(ctx, s, member, i.Member); err != nil {
+			logger.WithError(err).Error("restoring member")
+		}
+		return
+	}
+
+	member = members.New(i.Member)
+
+	if err := member.Save(ctx); err != nil {
 		logger.WithError(err).Error("saving member")
 		return
 	}
 
 	if settings.GetString("FEATURES.ONBOARDING.OUTPUT_CHANNEL_ID") != "" {
-		onBoardingMessage := member.GetOnboardingMessage()
+		onBoardingMessage, err := onboardingMessage(ctx, member)
+		if err != nil {
+			logger.WithError(err).Error("rendering onboarding message")
+			return
+		}
 
 		message, err := s.ChannelMessageSendComplex(settings.GetString("FEATURES.ONBOARDING.OUTPUT_CHANNEL_ID"), &discordgo.MessageSend{
 			Content: onBoardingMessage.Content,
@@ -39,11 +69,66 @@ func onJoinHandler(s *discordgo.Session, i *discordgo.GuildMemberAdd) {
 			return
 		}
 
-		member.ChannelId = message.ChannelID
-		member.MessageId = message.ID
-
-		if err := member.Save(); err != nil {
+		if _, err := members.Update(ctx, member.Id, func(member *members.Member) error {
+			member.ChannelId = message.ChannelID
+			member.MessageId = message.ID
+			return nil
+		}); err != nil {
 			logger.WithError(err).Error("saving member after onboarding message")
 		}
 	}
 }
+
+// restoreMember brings back a member who left and rejoined, keeping their
+// attendance, merits and onboarding answers, and lets the officers know
+func restoreMember(ctx context.Context, s *discordgo.Session, member *members.Member, discordMember *discordgo.Member) error {
+	logger := log.WithFields(log.Fields{
+		"func":   "restoreMember",
+		"member": member.Id,
+	})
+
+	before := *member
+	if err := member.Restore(ctx, discordMember.JoinedAt.UTC()); err != nil {
+		return errors.Wrap(err, "restoring member")
+	}
+
+	recordAudit(ctx, audit.System, audit.MemberRestored, memberTarget(member), &before, member)
+
+	// bring their onboarding message back up to date
+	refreshOnboardingMessage(ctx, s, member)
+
+	channelId := settings.GetString("DISCORD.OFFICER_CHANNEL_ID")
+	if channelId == "" {
+		logger.Debug("no officer channel to flag the rejoin to")
+		return nil
+	}
+
+	left := "unknown"
+	switch {
+	case before.LeftAt != nil:
+		left = fmt.Sprintf("<t:%d:R>", before.LeftAt.Unix())
+	case before.ArchivedAt != nil:
+		left = fmt.Sprintf("<t:%d:R>", before.ArchivedAt.Unix())
+	}
+
+	if _, err := s.ChannelMessageSendComplex(channelId, &discordgo.MessageSend{
+		Content: fmt.Sprintf("%s has rejoined", discordMember.Mention()),
+		Embeds: []*discordgo.MessageEmbed{
+			{
+				Title: "Returning Member",
+				Fields: []*discordgo.MessageEmbedField{
+					{Name: "Previous Rank", Value: member.Rank.String(), Inline: true},
+					{Name: "Left", Value: left, Inline: true},
+					{Name: "Onboarded", Value: fmt.Sprintf("%t", member.OnboardedAt != nil), Inline: true},
+					{Name: "Merits", Value: fmt.Sprintf("%d", len(member.ActiveMerits())), Inline: true},
+					{Name: "Demerits", Value: fmt.Sprintf("%d", len(member.ActiveDemerits())), Inline: true},
+				},
+				Timestamp: time.Now().Format(time.RFC3339),
+			},
+		},
+	}); err != nil {
+		return errors.Wrap(err, "flagging rejoin to officers")
+	}
+
+	return nil
+}
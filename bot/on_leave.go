@@ -6,6 +6,7 @@ import (
 	"github.com/apex/log"
 	"github.com/bwmarrin/discordgo"
 	"github.com/pkg/errors"
+	"github.com/sol-armada/sol-bot/audit"
 	"github.com/sol-armada/sol-bot/members"
 )
 
@@ -16,24 +17,24 @@ func onLeaveHandler(s *discordgo.Session, m *discordgo.GuildMemberRemove) {
 		return
 	}
 
-	member, err := members.Get(m.User.ID)
-	if err != nil && !errors.Is(err, members.MemberNotFound) {
-		log.WithError(err).Error("getting member")
-		return
-	}
+	ctx := bot.ctx
 
+	var before members.Member
 	now := time.Now().UTC()
-	member.LeftAt = &now
-
-	_ = member.Save()
-
-	memberMessage := member.GetOnboardingMessage()
-	if _, err = s.ChannelMessageEditComplex(&discordgo.MessageEdit{
-		Channel: member.ChannelId,
-		ID:      member.MessageId,
-		Content: &memberMessage.Content,
-		Embeds:  &memberMessage.Embeds,
-	}); err != nil {
-		log.WithError(err).Error("editing member message on leave")
+	member, err := members.Update(ctx, m.User.ID, func(member *members.Member) error {
+		before = *member
+		member.LeftAt = &now
+		member.ArchivedAt = &now
+		return nil
+	})
+	if err != nil {
+		if !errors.Is(err, members.MemberNotFound) {
+			log.WithError(err).Error("saving member on leave")
+		}
+		return
 	}
+
+	recordAudit(ctx, audit.System, audit.MemberArchived, memberTarget(member), &before, member)
+
+	refreshOnboardingMessage(ctx, s, member)
 }
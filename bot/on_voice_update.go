@@ -16,7 +16,9 @@ func onVoiceUpdate(_ *discordgo.Session, v *discordgo.VoiceStateUpdate) {
 		return
 	}
 
-	member, err := members.Get(v.Member.User.ID)
+	ctx := bot.ctx
+
+	member, err := members.Get(ctx, v.Member.User.ID)
 	if err != nil {
 		if !errors.Is(err, members.MemberNotFound) {
 			log.WithError(err).Error("getting member")
@@ -50,7 +52,7 @@ func onVoiceUpdate(_ *discordgo.Session, v *discordgo.VoiceStateUpdate) {
 			Where: where,
 		},
 	}
-	if err = newActivity.Save(); err != nil {
+	if err = newActivity.Save(ctx); err != nil {
 		log.WithError(err).Error("saving activity")
 	}
 }
@@ -8,6 +8,7 @@ import (
 	"github.com/apex/log"
 	"github.com/bwmarrin/discordgo"
 	"github.com/pkg/errors"
+	"github.com/sol-armada/sol-bot/audit"
 	"github.com/sol-armada/sol-bot/members"
 	"github.com/sol-armada/sol-bot/rsi"
 	"github.com/sol-armada/sol-bot/settings"
@@ -216,6 +217,8 @@ func onboardingModalHandler(ctx context.Context, s *discordgo.Session, i *discor
 		return nil
 	}
 
+	before := *member
+
 	data := i.ModalSubmitData()
 
 	rsiHandle := data.Components[0].(*discordgo.ActionsRow).Components[0].(*discordgo.TextInput).Value
@@ -238,19 +241,22 @@ func onboardingModalHandler(ctx context.Context, s *discordgo.Session, i *discor
 		}
 	}
 
-	member.LegacyPlaytime = playTime
-	member.LegacyGamplay = gameplay
-	member.LegacyAge = age
+	member, err := members.Update(ctx, member.Id, func(member *members.Member) error {
+		now := time.Now().UTC()
+		member.OnboardedAt = &now
+		member.SetOnboardingAnswers(age, playTime, gameplay)
 
-	if recruiter != "" {
-		member.LegacyRecruiter = recruiter
-	}
+		if recruiter != "" {
+			member.LegacyRecruiter = recruiter
+		}
 
-	if other != "" {
-		member.LegacyOther = other
-	}
+		if other != "" {
+			member.LegacyOther = other
+		}
 
-	if err := member.Save(); err != nil {
+		return nil
+	})
+	if err != nil {
 		return errors.Wrap(err, "onboarding modal handler: failed to save member first")
 	}
 
@@ -278,12 +284,22 @@ func onboardingModalHandler(ctx context.Context, s *discordgo.Session, i *discor
 		}
 	}
 
-	member.Name = rsiHandle
-
-	if err := member.Save(); err != nil {
+	member, err = members.Update(ctx, member.Id, func(member *members.Member) error {
+		member.Name = rsiHandle
+		return nil
+	})
+	if err != nil {
 		return errors.Wrap(err, "onboarding modal handler: saving member second")
 	}
 
+	recordAudit(ctx, memberActor(member), audit.MemberOnboarded, memberTarget(member), &before, member)
+
+	refreshOnboardingMessage(ctx, s, member)
+
+	if err := resolveRecruiter(ctx, s, member); err != nil {
+		logger.WithError(err).Warn("resolving recruiter")
+	}
+
 	ctx = utils.SetMemberToContext(ctx, member)
 
 	return finishOnboarding(ctx, s, i)
@@ -327,6 +343,8 @@ func onboardingTryAgainModalHandler(ctx context.Context, s *discordgo.Session, i
 
 	member := utils.GetMemberFromContext(ctx).(*members.Member)
 
+	before := *member
+
 	data := i.ModalSubmitData()
 	rsiHandle := data.Components[0].(*discordgo.ActionsRow).Components[0].(*discordgo.TextInput).Value
 
@@ -352,12 +370,18 @@ func onboardingTryAgainModalHandler(ctx context.Context, s *discordgo.Session, i
 		}
 	}
 
-	member.Name = rsiHandle
-
-	if err := member.Save(); err != nil {
+	member, err := members.Update(ctx, member.Id, func(member *members.Member) error {
+		member.Name = rsiHandle
+		return nil
+	})
+	if err != nil {
 		return errors.Wrap(err, "onboarding try again modal handler: saving member")
 	}
 
+	recordAudit(ctx, memberActor(member), audit.MemberOnboarded, memberTarget(member), &before, member)
+
+	refreshOnboardingMessage(ctx, s, member)
+
 	ctx = utils.SetMemberToContext(ctx, member)
 
 	return finishOnboarding(ctx, s, i)
@@ -389,11 +413,9 @@ func finishOnboarding(ctx context.Context, s *discordgo.Session, i *discordgo.In
 	fields := []*discordgo.MessageEmbedField{
 		{Name: "RSI Profile", Value: "https://robertsspaceindustries.com/citizens/" + member.Name},
 		{Name: "Primary Org", Value: "https://robertsspaceindustries.com/orgs/" + member.PrimaryOrg},
-		{Name: "Affiliate Orgs", Value: strings.Join(member.Affilations, ", ")},
-		{Name: "Playtime", Value: member.LegacyPlaytime},
-		{Name: "Gameplay", Value: member.LegacyGamplay},
-		{Name: "Age", Value: member.LegacyAge},
+		{Name: "Affiliate Orgs", Value: strings.Join(member.Affiliations, ", ")},
 	}
+	fields = append(fields, onboardingAnswerFields(member)...)
 
 	if member.LegacyRecruiter != "" {
 		fields = append(fields, &discordgo.MessageEmbedField{Name: "Recruiter", Value: member.LegacyRecruiter})
@@ -411,6 +433,7 @@ func finishOnboarding(ctx context.Context, s *discordgo.Session, i *discordgo.In
 				Timestamp: member.Joined.Format(time.RFC3339),
 			},
 		},
+		Components: onboardingReviewComponents(member),
 	}); err != nil {
 		return errors.Wrap(err, "finishing onboarding: sending onboarded message")
 	}
@@ -0,0 +1,50 @@
+package bot
+
+import (
+	"context"
+
+	"github.com/apex/log"
+	"github.com/bwmarrin/discordgo"
+	"github.com/pkg/errors"
+	"github.com/sol-armada/sol-bot/attendance"
+	"github.com/sol-armada/sol-bot/members"
+)
+
+// onboardingMessage renders the member's officer channel post
+func onboardingMessage(ctx context.Context, member *members.Member) (*discordgo.Message, error) {
+	count, err := attendance.GetMemberAttendanceCount(ctx, member.Id)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting attendance count for onboarding message")
+	}
+
+	return member.GetOnboardingMessage(count), nil
+}
+
+// refreshOnboardingMessage edits the member's officer channel post to match
+// the member. The post is only informational, so failing to update it is
+// logged rather than stopping whatever changed the member.
+func refreshOnboardingMessage(ctx context.Context, s *discordgo.Session, member *members.Member) {
+	if member.ChannelId == "" || member.MessageId == "" {
+		return
+	}
+
+	logger := log.WithFields(log.Fields{
+		"func":   "refreshOnboardingMessage",
+		"member": member.Id,
+	})
+
+	message, err := onboardingMessage(ctx, member)
+	if err != nil {
+		logger.WithError(err).Warn("rendering onboarding message")
+		return
+	}
+
+	if _, err := s.ChannelMessageEditComplex(&discordgo.MessageEdit{
+		Channel: member.ChannelId,
+		ID:      member.MessageId,
+		Content: &message.Content,
+		Embeds:  &message.Embeds,
+	}); err != nil {
+		logger.WithError(err).Warn("editing onboarding message")
+	}
+}
@@ -0,0 +1,230 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/apex/log"
+	"github.com/bwmarrin/discordgo"
+	"github.com/pkg/errors"
+	"github.com/sol-armada/sol-bot/audit"
+	"github.com/sol-armada/sol-bot/members"
+	"github.com/sol-armada/sol-bot/utils"
+)
+
+// onboardingAnswerFields shows what was read from the onboarding answers,
+// falling back to what was typed for anything that still needs a review
+func onboardingAnswerFields(member *members.Member) []*discordgo.MessageEmbedField {
+	playtime := members.FormatPlaytime(member.Playtime)
+	gameplay := members.FormatGameplay(member.Gameplay)
+	age := fmt.Sprint(member.Age)
+
+	for _, review := range member.NeedsReview {
+		switch review {
+		case members.ReviewPlaytime:
+			playtime = needsReview(member.LegacyPlaytime)
+		case members.ReviewGameplay:
+			gameplay = needsReview(member.LegacyGameplay)
+			if unmatched := member.UnmatchedGameplay(); unmatched != "" {
+				gameplay += "\nCouldn't match: " + unmatched
+			}
+		case members.ReviewAge:
+			age = needsReview(member.LegacyAge)
+		}
+	}
+
+	return []*discordgo.MessageEmbedField{
+		{Name: "Playtime", Value: playtime},
+		{Name: "Gameplay", Value: gameplay},
+		{Name: "Age", Value: age},
+	}
+}
+
+func needsReview(answer string) string {
+	if answer == "" {
+		answer = "no answer"
+	}
+	return fmt.Sprintf("%s (needs review)", answer)
+}
+
+// onboardingReviewComponents is the review button for onboarding answers that
+// couldn't be read, or nothing if they were all fine
+func onboardingReviewComponents(member *members.Member) []discordgo.MessageComponent {
+	if len(member.NeedsReview) == 0 {
+		return []discordgo.MessageComponent{}
+	}
+
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{
+					Label:    "Review Answers",
+					Style:    discordgo.PrimaryButton,
+					CustomID: "onboarding:review:" + member.Id,
+				},
+			},
+		},
+	}
+}
+
+func onboardingReviewButtonHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) error {
+	logger := utils.GetLoggerFromContext(ctx).(*log.Entry)
+	logger.Debug("onboarding review button handler")
+
+	if !allowed(i.Member, "ONBOARDING") {
+		return InvalidPermissions
+	}
+
+	memberId := strings.Split(i.MessageComponentData().CustomID, ":")[2]
+
+	member, err := members.Get(ctx, memberId)
+	if err != nil {
+		return errors.Wrap(err, "onboarding review button handler: getting member")
+	}
+
+	if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseModal,
+		Data: &discordgo.InteractionResponseData{
+			CustomID: "onboarding:review:" + member.Id,
+			Title:    "Review " + member.Name,
+			Components: []discordgo.MessageComponent{
+				discordgo.ActionsRow{
+					Components: []discordgo.MessageComponent{
+						discordgo.TextInput{
+							CustomID:    "play_time",
+							Label:       "How long have they played?",
+							Style:       discordgo.TextInputShort,
+							Placeholder: "2 years, 6 months, since 2018...",
+							Value:       member.LegacyPlaytime,
+							Required:    true,
+						},
+					},
+				},
+				discordgo.ActionsRow{
+					Components: []discordgo.MessageComponent{
+						discordgo.TextInput{
+							CustomID:    "gameplay",
+							Label:       "What gameplay are they into?",
+							Style:       discordgo.TextInputShort,
+							Placeholder: "Mining, Hauling, Ship Combat...",
+							Value:       member.LegacyGameplay,
+							Required:    true,
+						},
+					},
+				},
+				discordgo.ActionsRow{
+					Components: []discordgo.MessageComponent{
+						discordgo.TextInput{
+							CustomID: "age",
+							Label:    "How old are they?",
+							Style:    discordgo.TextInputShort,
+							Value:    member.LegacyAge,
+							Required: true,
+						},
+					},
+				},
+			},
+		},
+	}); err != nil {
+		return errors.Wrap(err, "onboarding review button handler: responding")
+	}
+
+	return nil
+}
+
+func onboardingReviewModalHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) error {
+	logger := utils.GetLoggerFromContext(ctx).(*log.Entry)
+	logger.Debug("onboarding review modal handler")
+
+	if !allowed(i.Member, "ONBOARDING") {
+		return InvalidPermissions
+	}
+
+	reviewer := utils.GetMemberFromContext(ctx).(*members.Member)
+
+	data := i.ModalSubmitData()
+	memberId := strings.Split(data.CustomID, ":")[2]
+
+	playTime := data.Components[0].(*discordgo.ActionsRow).Components[0].(*discordgo.TextInput).Value
+	gameplay := data.Components[1].(*discordgo.ActionsRow).Components[0].(*discordgo.TextInput).Value
+	age := data.Components[2].(*discordgo.ActionsRow).Components[0].(*discordgo.TextInput).Value
+
+	problems := []string{}
+	parsedPlaytime, ok := members.ParsePlaytime(playTime)
+	if !ok {
+		problems = append(problems, fmt.Sprintf("I couldn't read a playtime from \"%s\"", playTime))
+	}
+	parsedGameplay, unmatched := members.ParseGameplayAnswer(gameplay)
+	if len(parsedGameplay) == 0 || len(unmatched) > 0 {
+		if len(unmatched) == 0 {
+			unmatched = []string{gameplay}
+		}
+		problems = append(problems, fmt.Sprintf("I couldn't match \"%s\" to a type of gameplay", strings.Join(unmatched, ", ")))
+	}
+	parsedAge, ok := members.ParseAge(age)
+	if !ok {
+		problems = append(problems, fmt.Sprintf("I couldn't read an age from \"%s\"", age))
+	}
+
+	if len(problems) > 0 {
+		if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: strings.Join(problems, "\n") + "\n\nThe gameplay types I know are: " + members.FormatGameplay(members.GameplayTypes),
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		}); err != nil {
+			return errors.Wrap(err, "onboarding review modal handler: responding with problems")
+		}
+		return nil
+	}
+
+	var before members.Member
+	member, err := members.Update(ctx, memberId, func(member *members.Member) error {
+		before = *member
+		member.Playtime = parsedPlaytime
+		member.Gameplay = parsedGameplay
+		member.Age = parsedAge
+		member.NeedsReview = []string{}
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "onboarding review modal handler: saving member")
+	}
+
+	recordAudit(ctx, memberActor(reviewer), audit.OnboardingReviewed, memberTarget(member), &before, member)
+
+	refreshOnboardingMessage(ctx, s, member)
+
+	// the review came from the onboarding message, so swap its answers for
+	// the reviewed ones
+	embeds := []*discordgo.MessageEmbed{}
+	if i.Message != nil {
+		embeds = i.Message.Embeds
+	}
+	for _, embed := range embeds {
+		fields := []*discordgo.MessageEmbedField{}
+		for _, field := range embed.Fields {
+			switch field.Name {
+			case "Playtime", "Gameplay", "Age":
+			default:
+				fields = append(fields, field)
+			}
+		}
+		embed.Fields = append(fields, onboardingAnswerFields(member)...)
+		embed.Footer = &discordgo.MessageEmbedFooter{Text: "Reviewed by " + reviewer.Name}
+	}
+
+	if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     embeds,
+			Components: []discordgo.MessageComponent{},
+		},
+	}); err != nil {
+		return errors.Wrap(err, "onboarding review modal handler: updating onboarding message")
+	}
+
+	return nil
+}
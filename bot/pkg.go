@@ -9,8 +9,11 @@ import (
 	"github.com/apex/log"
 	"github.com/bwmarrin/discordgo"
 	"github.com/pkg/errors"
+	attdnc "github.com/sol-armada/sol-bot/attendance"
+	"github.com/sol-armada/sol-bot/events"
 	"github.com/sol-armada/sol-bot/members"
 	"github.com/sol-armada/sol-bot/settings"
+	"github.com/sol-armada/sol-bot/stores"
 	"github.com/sol-armada/sol-bot/utils"
 )
 
@@ -18,7 +21,8 @@ type Bot struct {
 	GuildId  string
 	ClientId string
 
-	ctx context.Context
+	ctx    context.Context
+	cancel context.CancelFunc
 
 	*discordgo.Session
 }
@@ -27,31 +31,78 @@ type Handler func(ctx context.Context, s *discordgo.Session, i *discordgo.Intera
 
 var bot *Bot
 
+// how long an interaction that has deferred its response has to finish before
+// its queries are cancelled
+const defaultHandlerTimeout = 10 * time.Second
+
+// discord drops an interaction that isn't answered within 3 seconds, anything
+// run before answering gives up a bit sooner so the member still hears back
+const respondTimeout = 2500 * time.Millisecond
+
+// holds the handler's full context while it still has to answer discord
+const handlerContextKey utils.ContextKey = "handler"
+
+const timeoutMessage = "That took longer than it should have, please try again in a moment"
+
 // command handlers
 var commandHandlers = map[string]Handler{
 	"takeattendance":   takeAttendanceCommandHandler,
+	"voiceattendance":  voiceAttendanceCommandHandler,
 	"removeattendance": removeAttendanceCommandHandler,
 	"profile":          profileCommandHandler,
-	"merit":            giveMeritCommandHandler,
-	"demerit":          giveDemeritCommandHandler,
+	"merit":            meritCommandHandler,
+	"demerit":          demeritCommandHandler,
 	"validate":         validateCommandHandler,
 	"rankups":          rankUpsCommandHandler,
+	"audit":            auditCommandHandler,
+	"roster":           rosterCommandHandler,
+	"recruits":         recruitsCommandHandler,
+	"event":            eventCommandHandler,
 }
 
 var autocompleteHandlers = map[string]Handler{
 	"takeattendance":   takeAttendanceAutocompleteHandler,
+	"voiceattendance":  takeAttendanceAutocompleteHandler,
 	"removeattendance": removeAttendanceAutocompleteHandler,
+	"merit":            meritAutocompleteHandler,
+	"demerit":          demeritAutocompleteHandler,
+	"event":            eventAutocompleteHandler,
 }
 
 var onboardingButtonHanlders = map[string]Handler{
-	"validate": validateButtonHandler,
-	"choice":   onboardingButtonHandler,
-	"tryagain": onboardingTryAgainHandler,
+	"validate":  validateButtonHandler,
+	"choice":    onboardingButtonHandler,
+	"tryagain":  onboardingTryAgainHandler,
+	"review":    onboardingReviewButtonHandler,
+	"recruiter": confirmRecruiterHandler,
 }
 
 var onboardingModalHandlers = map[string]Handler{
 	"onboard":   onboardingModalHandler,
 	"rsihandle": onboardingTryAgainModalHandler,
+	"review":    onboardingReviewModalHandler,
+}
+
+var meritButtonHandlers = map[string]Handler{
+	"acknowledge": escalationButtonHandler,
+	"dismiss":     escalationButtonHandler,
+	"fulfil":      settleRedemptionButtonHandler,
+	"refund":      settleRedemptionButtonHandler,
+}
+
+var rankUpButtonHandlers = map[string]Handler{
+	"approve": approveRankUpButtonHandler,
+	"deny":    denyRankUpButtonHandler,
+}
+
+var rankUpModalHandlers = map[string]Handler{
+	"deny": denyRankUpModalHandler,
+}
+
+var eventButtonHandlers = map[string]Handler{
+	string(events.Going):    rsvpButtonHandler,
+	string(events.Maybe):    rsvpButtonHandler,
+	string(events.Declined): rsvpButtonHandler,
 }
 
 var attendanceButtonHandlers = map[string]Handler{
@@ -73,14 +124,18 @@ func New() (*Bot, error) {
 		return nil, err
 	}
 
-	b.Identify.Intents = discordgo.IntentGuildMembers + discordgo.IntentGuildVoiceStates + discordgo.IntentsGuildMessageReactions + discordgo.PermissionAdministrator
+	b.Identify.Intents = discordgo.IntentGuildMembers + discordgo.IntentGuildVoiceStates + discordgo.IntentsGuildMessageReactions + discordgo.IntentGuildScheduledEvents + discordgo.PermissionAdministrator
 	// b.Identify.Intents = discordgo.PermissionAdministrator
 	b.Client.Timeout = 5 * time.Second
 
+	// cancelled on Close so in flight queries don't hold up shutdown
+	ctx, cancel := context.WithCancel(context.Background())
+
 	bot = &Bot{
 		settings.GetString("DISCORD.GUILD_ID"),
 		settings.GetString("DISCORD.CLIENT_ID"),
-		context.Background(),
+		ctx,
+		cancel,
 		b,
 	}
 
@@ -104,12 +159,43 @@ func ready(s *discordgo.Session, event *discordgo.Ready) {
 	log.Info("bot is ready")
 }
 
+// deferredContext keeps the values of an interaction's context but runs on
+// the handler's full deadline
+type deferredContext struct {
+	context.Context
+	values context.Context
+}
+
+func (c *deferredContext) Value(key any) any {
+	return c.values.Value(key)
+}
+
+// deferred gives a handler that has deferred its response the rest of the
+// handler timeout, it no longer has to answer within discord's window
+func deferred(ctx context.Context) context.Context {
+	handlerCtx, ok := ctx.Value(handlerContextKey).(context.Context)
+	if !ok {
+		return ctx
+	}
+
+	return &deferredContext{Context: handlerCtx, values: ctx}
+}
+
 func (b *Bot) Setup() error {
 	// setup state when bot is ready
 	b.AddHandler(ready)
 
+	handlerTimeout := settings.GetDurationWithDefault("DISCORD.HANDLER_TIMEOUT", defaultHandlerTimeout)
+
 	b.AddHandler(func(s *discordgo.Session, i *discordgo.InteractionCreate) {
-		member, err := members.Get(i.Member.User.ID)
+		handlerCtx, cancel := context.WithTimeout(b.ctx, handlerTimeout)
+		defer cancel()
+
+		ctx, cancelRespond := context.WithTimeout(handlerCtx, respondTimeout)
+		defer cancelRespond()
+		ctx = context.WithValue(ctx, handlerContextKey, handlerCtx)
+
+		member, err := members.Get(ctx, i.Member.User.ID)
 		if err != nil {
 			if errors.Is(err, members.MemberNotFound) {
 				_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
@@ -122,18 +208,24 @@ func (b *Bot) Setup() error {
 				return
 			}
 
-			log.WithError(err).Error("getting member for incomming interaction")
+			msg := "Ran into an error, please try again in a few minutes"
+			if stores.IsTimeout(err) {
+				log.WithError(err).Warn("getting member for incomming interaction timed out")
+				msg = timeoutMessage
+			} else {
+				log.WithError(err).Error("getting member for incomming interaction")
+			}
 			_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 				Type: discordgo.InteractionResponseChannelMessageWithSource,
 				Data: &discordgo.InteractionResponseData{
-					Content: "Ran into an error, please try again in a few minutes",
+					Content: msg,
 					Flags:   discordgo.MessageFlagsEphemeral,
 				},
 			})
 			return
 		}
 
-		ctx := utils.SetMemberToContext(b.ctx, member)
+		ctx = utils.SetMemberToContext(ctx, member)
 
 		logger := log.WithFields(log.Fields{
 			"guild": b.GuildId,
@@ -175,6 +267,18 @@ func (b *Bot) Setup() error {
 				if h, ok := onboardingButtonHanlders[id[1]]; ok {
 					err = h(ctx, s, i)
 				}
+			case "merit":
+				if h, ok := meritButtonHandlers[id[1]]; ok {
+					err = h(ctx, s, i)
+				}
+			case "rankup":
+				if h, ok := rankUpButtonHandlers[id[1]]; ok {
+					err = h(ctx, s, i)
+				}
+			case "event":
+				if h, ok := eventButtonHandlers[id[1]]; ok {
+					err = h(ctx, s, i)
+				}
 			}
 		case discordgo.InteractionModalSubmit:
 			logger = logger.WithFields(log.Fields{
@@ -187,6 +291,10 @@ func (b *Bot) Setup() error {
 				if h, ok := onboardingModalHandlers[id[1]]; ok {
 					err = h(ctx, s, i)
 				}
+			case "rankup":
+				if h, ok := rankUpModalHandlers[id[1]]; ok {
+					err = h(ctx, s, i)
+				}
 			}
 		}
 
@@ -202,13 +310,22 @@ func (b *Bot) Setup() error {
 				return
 			}
 
-			logger.WithFields(log.Fields{
-				"command_data":   i.ApplicationCommandData(),
-				"component_data": i.MessageComponentData(),
-				"modal_data":     i.ModalSubmitData(),
-			}).WithError(err).Error("running command")
+			msg := "It looks like I ran into an error. I have logged it and someone will look into it. Ask an @Officer if you need help"
+
+			// a slow database isn't a bug, let them try again without
+			// bothering anyone
+			if stores.IsTimeout(err) {
+				logger.WithError(err).Warn("command timed out")
+				msg = timeoutMessage
+			} else {
+				logger.WithFields(log.Fields{
+					"command_data":   i.ApplicationCommandData(),
+					"component_data": i.MessageComponentData(),
+					"modal_data":     i.ModalSubmitData(),
+				}).WithError(err).Error("running command")
+			}
 
-			if settings.GetString("DISCORD.ERROR_CHANNEL_ID") != "" {
+			if !stores.IsTimeout(err) && settings.GetString("DISCORD.ERROR_CHANNEL_ID") != "" {
 				_, _ = b.ChannelMessageSendComplex(settings.GetString("DISCORD.ERROR_CHANNEL_ID"), &discordgo.MessageSend{
 					Content: "Ran into an error",
 					Embeds: []*discordgo.MessageEmbed{
@@ -225,7 +342,6 @@ func (b *Bot) Setup() error {
 				})
 			}
 
-			msg := "It looks like I ran into an error. I have logged it and someone will look into it. Ask an @Officer if you need help"
 			switch i.Interaction.Type {
 			case discordgo.InteractionApplicationCommand:
 				if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
@@ -265,6 +381,10 @@ func (b *Bot) Setup() error {
 		}
 	})
 
+	// keep the member cache up to date
+	b.AddHandler(memberUpdateCacheHandler)
+	b.AddHandler(memberRemoveCacheHandler)
+
 	// onboarding
 	if settings.GetBool("FEATURES.ONBOARDING.ENABLE") {
 		// watch for on join and leave
@@ -323,6 +443,32 @@ func (b *Bot) Setup() error {
 	if settings.GetBool("FEATURES.ATTENDANCE.ENABLE") {
 		log.Debug("using attendance feature")
 
+		voiceChannelTypes := []discordgo.ChannelType{
+			discordgo.ChannelTypeGuildVoice,
+			discordgo.ChannelTypeGuildStageVoice,
+			discordgo.ChannelTypeGuildCategory,
+		}
+		roleChoices := []*discordgo.ApplicationCommandOptionChoice{}
+		for _, r := range attdnc.Roles {
+			roleChoices = append(roleChoices, &discordgo.ApplicationCommandOptionChoice{
+				Name:  r.String(),
+				Value: string(r),
+			})
+		}
+		typeChoices := []*discordgo.ApplicationCommandOptionChoice{}
+		for _, t := range attdnc.EventTypes {
+			typeChoices = append(typeChoices, &discordgo.ApplicationCommandOptionChoice{
+				Name:  t.String(),
+				Value: string(t),
+			})
+		}
+		typeOption := &discordgo.ApplicationCommandOption{
+			Name:        "type",
+			Description: "the type of event",
+			Type:        discordgo.ApplicationCommandOptionString,
+			Choices:     typeChoices,
+		}
+
 		options := []*discordgo.ApplicationCommandOption{
 			{
 				Name:         "event",
@@ -339,11 +485,25 @@ func (b *Bot) Setup() error {
 				Type:         discordgo.ApplicationCommandOptionUser,
 				Autocomplete: true,
 			}
-			if i == 0 {
-				o.Required = true
-			}
 			options = append(options, o)
 		}
+		for i := 0; i < maxAttendanceChannels; i++ {
+			options = append(options, &discordgo.ApplicationCommandOption{
+				Name:         fmt.Sprintf("channel-%d", i+1),
+				Description:  "take attendance for everyone in this voice channel or category",
+				Type:         discordgo.ApplicationCommandOptionChannel,
+				ChannelTypes: voiceChannelTypes,
+			})
+		}
+		options = append(options,
+			&discordgo.ApplicationCommandOption{
+				Name:        "role",
+				Description: "what the users and channels given did at the event, participant if not given",
+				Type:        discordgo.ApplicationCommandOptionString,
+				Choices:     roleChoices,
+			},
+			typeOption,
+		)
 		if _, err := b.ApplicationCommandCreate(b.ClientId, b.GuildId, &discordgo.ApplicationCommand{
 			Name:        "takeattendance",
 			Description: "take or add to attendance",
@@ -353,6 +513,53 @@ func (b *Bot) Setup() error {
 			return errors.Wrap(err, "creating takeattendance command")
 		}
 
+		options = []*discordgo.ApplicationCommandOption{
+			{
+				Name:         "event",
+				Description:  "the event to take attendance for",
+				Type:         discordgo.ApplicationCommandOptionString,
+				Required:     true,
+				Autocomplete: true,
+			},
+			{
+				Name:        "start",
+				Description: "when the event started, how long ago like 2h30m or UTC like 2024-05-01 19:00",
+				Type:        discordgo.ApplicationCommandOptionString,
+				Required:    true,
+			},
+		}
+		for i := 0; i < maxAttendanceChannels; i++ {
+			options = append(options, &discordgo.ApplicationCommandOption{
+				Name:         fmt.Sprintf("channel-%d", i+1),
+				Description:  "a voice channel or category the event was in",
+				Type:         discordgo.ApplicationCommandOptionChannel,
+				ChannelTypes: voiceChannelTypes,
+				Required:     i == 0,
+			})
+		}
+		options = append(options,
+			&discordgo.ApplicationCommandOption{
+				Name:        "end",
+				Description: "when the event ended, now if not given",
+				Type:        discordgo.ApplicationCommandOptionString,
+			},
+			&discordgo.ApplicationCommandOption{
+				Name:        "min-minutes",
+				Description: "how many minutes someone has to have been there to count",
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				MinValue:    utils.Float64Pointer(0),
+			},
+			typeOption,
+		)
+		if _, err := b.ApplicationCommandCreate(b.ClientId, b.GuildId, &discordgo.ApplicationCommand{
+			Name:        "voiceattendance",
+			Description: "take attendance from who was in voice during an event",
+			Type:        discordgo.ChatApplicationCommand,
+			Options:     options,
+		}); err != nil {
+			return errors.Wrap(err, "creating voiceattendance command")
+		}
+
 		options = []*discordgo.ApplicationCommandOption{
 			{
 				Name:         "event",
@@ -387,45 +594,218 @@ func (b *Bot) Setup() error {
 	// merit
 	if settings.GetBool("FEATURES.MERIT.ENABLE") {
 		log.Debug("using merit feature")
-		if _, err := b.ApplicationCommandCreate(b.ClientId, b.GuildId, &discordgo.ApplicationCommand{
-			Name:        "merit",
-			Description: "give a merit to a member",
-			Type:        discordgo.ChatApplicationCommand,
-			Options: []*discordgo.ApplicationCommandOption{
+		categoryChoices := []*discordgo.ApplicationCommandOptionChoice{}
+		for _, category := range members.MeritCategories {
+			categoryChoices = append(categoryChoices, &discordgo.ApplicationCommandOptionChoice{
+				Name:  category,
+				Value: category,
+			})
+		}
+		giveOptions := func(kind string) []*discordgo.ApplicationCommandOption {
+			return []*discordgo.ApplicationCommandOption{
+				{
+					Name:        "member",
+					Description: "who to give the " + kind + " to",
+					Type:        discordgo.ApplicationCommandOptionUser,
+					Required:    true,
+				},
+				{
+					Name:        "reason",
+					Description: "why are you giving this member a " + kind,
+					Type:        discordgo.ApplicationCommandOptionString,
+					Required:    true,
+				},
+				{
+					Name:        "category",
+					Description: "what kind of " + kind + " this is",
+					Type:        discordgo.ApplicationCommandOptionString,
+					Choices:     categoryChoices,
+				},
+				{
+					Name:        "weight",
+					Description: "how much the " + kind + " counts for, 1 if not given",
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					MinValue:    utils.Float64Pointer(1),
+				},
+				{
+					Name:        "expires_in_days",
+					Description: "how many days until the " + kind + " stops counting",
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					MinValue:    utils.Float64Pointer(1),
+				},
+			}
+		}
+		revokeOptions := func(kind string) []*discordgo.ApplicationCommandOption {
+			return []*discordgo.ApplicationCommandOption{
+				{
+					Name:        "member",
+					Description: "whose " + kind + " to revoke",
+					Type:        discordgo.ApplicationCommandOptionUser,
+					Required:    true,
+				},
 				{
-					Name:         "member",
-					Description:  "who to give the merit to",
-					Type:         discordgo.ApplicationCommandOptionUser,
+					Name:         kind,
+					Description:  "the " + kind + " to revoke",
+					Type:         discordgo.ApplicationCommandOptionString,
 					Required:     true,
 					Autocomplete: true,
 				},
 				{
 					Name:        "reason",
-					Description: "why are you giving this member a merit",
+					Description: "why the " + kind + " is being revoked",
 					Type:        discordgo.ApplicationCommandOptionString,
-					Required:    true,
 				},
-			},
-		}); err != nil {
+			}
+		}
+
+		var bankOptions []*discordgo.ApplicationCommandOption
+		if bankEnabled() {
+			catalog, err := rewards()
+			if err != nil {
+				return err
+			}
+			rewardChoices := []*discordgo.ApplicationCommandOptionChoice{}
+			for _, reward := range catalog {
+				rewardChoices = append(rewardChoices, &discordgo.ApplicationCommandOptionChoice{
+					Name:  fmt.Sprintf("%s (%d)", reward.Name, reward.Cost),
+					Value: reward.Name,
+				})
+			}
+			if len(rewardChoices) > 25 {
+				rewardChoices = rewardChoices[:25]
+			}
+
+			bankOptions = []*discordgo.ApplicationCommandOption{
+				{
+					Name:        "balance",
+					Description: "see how many merits you have to give and redeem",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Name:        "member",
+							Description: "whose balance to see, officers only",
+							Type:        discordgo.ApplicationCommandOptionUser,
+						},
+					},
+				},
+				{
+					Name:        "ledger",
+					Description: "see every merit that came in and went out",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Name:        "member",
+							Description: "whose ledger to see, officers only",
+							Type:        discordgo.ApplicationCommandOptionUser,
+						},
+						{
+							Name:        "page",
+							Description: "which page of the ledger",
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							MinValue:    utils.Float64Pointer(1),
+						},
+					},
+				},
+				{
+					Name:        "transfer",
+					Description: "give some of your merit balance to another member",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Name:        "member",
+							Description: "who to give the merits to",
+							Type:        discordgo.ApplicationCommandOptionUser,
+							Required:    true,
+						},
+						{
+							Name:        "amount",
+							Description: "how many merits to give",
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Required:    true,
+							MinValue:    utils.Float64Pointer(1),
+						},
+						{
+							Name:        "reason",
+							Description: "why you are giving them",
+							Type:        discordgo.ApplicationCommandOptionString,
+						},
+					},
+				},
+			}
+			if len(rewardChoices) > 0 {
+				bankOptions = append(bankOptions, &discordgo.ApplicationCommandOption{
+					Name:        "redeem",
+					Description: "spend your merits on a reward",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Name:        "reward",
+							Description: "the reward to redeem",
+							Type:        discordgo.ApplicationCommandOptionString,
+							Required:    true,
+							Choices:     rewardChoices,
+						},
+					},
+				})
+			}
+		}
+
+		meritCommand := &discordgo.ApplicationCommand{
+			Name:        "merit",
+			Description: "give, list or revoke merits",
+			Type:        discordgo.ChatApplicationCommand,
+			Options: append([]*discordgo.ApplicationCommandOption{
+				{
+					Name:        "give",
+					Description: "give a merit to a member",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Options:     giveOptions("merit"),
+				},
+				{
+					Name:        "list",
+					Description: "list a member's merits and demerits",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Name:        "member",
+							Description: "whose merits to list",
+							Type:        discordgo.ApplicationCommandOptionUser,
+							Required:    true,
+						},
+						{
+							Name:        "all",
+							Description: "include revoked and expired merits",
+							Type:        discordgo.ApplicationCommandOptionBoolean,
+						},
+					},
+				},
+				{
+					Name:        "revoke",
+					Description: "revoke a member's merit",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Options:     revokeOptions("merit"),
+				},
+			}, bankOptions...),
+		}
+		if _, err := b.ApplicationCommandCreate(b.ClientId, b.GuildId, meritCommand); err != nil {
 			return errors.Wrap(err, "failed creating merit command")
 		}
 		if _, err := b.ApplicationCommandCreate(b.ClientId, b.GuildId, &discordgo.ApplicationCommand{
 			Name:        "demerit",
-			Description: "give a demerit to a member",
+			Description: "give or revoke demerits",
 			Type:        discordgo.ChatApplicationCommand,
 			Options: []*discordgo.ApplicationCommandOption{
 				{
-					Name:         "member",
-					Description:  "who to give the merit to",
-					Type:         discordgo.ApplicationCommandOptionUser,
-					Required:     true,
-					Autocomplete: true,
+					Name:        "give",
+					Description: "give a demerit to a member",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Options:     giveOptions("demerit"),
 				},
 				{
-					Name:        "reason",
-					Description: "why are you giving this member a demerit",
-					Type:        discordgo.ApplicationCommandOptionString,
-					Required:    true,
+					Name:        "revoke",
+					Description: "revoke a member's demerit",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Options:     revokeOptions("demerit"),
 				},
 			},
 		}); err != nil {
@@ -433,6 +813,254 @@ func (b *Bot) Setup() error {
 		}
 	}
 
+	// audit
+	if settings.GetBool("FEATURES.AUDIT.ENABLE") {
+		log.Debug("using audit feature")
+		pageOption := &discordgo.ApplicationCommandOption{
+			Name:        "page",
+			Description: "which page of entries to show",
+			Type:        discordgo.ApplicationCommandOptionInteger,
+			MinValue:    utils.Float64Pointer(1),
+		}
+		if _, err := b.ApplicationCommandCreate(b.ClientId, b.GuildId, &discordgo.ApplicationCommand{
+			Name:        "audit",
+			Description: "view the audit log",
+			Type:        discordgo.ChatApplicationCommand,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Name:        "member",
+					Description: "changes made to or by a member",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Name:        "member",
+							Description: "the member to look up",
+							Type:        discordgo.ApplicationCommandOptionUser,
+							Required:    true,
+						},
+						pageOption,
+					},
+				},
+				{
+					Name:        "record",
+					Description: "changes made to a record, like an attendance record",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Name:        "id",
+							Description: "the id of the record",
+							Type:        discordgo.ApplicationCommandOptionString,
+							Required:    true,
+						},
+						pageOption,
+					},
+				},
+			},
+		}); err != nil {
+			return errors.Wrap(err, "failed creating audit command")
+		}
+	}
+
+	// roster
+	if settings.GetBool("FEATURES.ROSTER.ENABLE") {
+		log.Debug("using roster feature")
+		gameplayChoices := []*discordgo.ApplicationCommandOptionChoice{}
+		for _, g := range members.GameplayTypes {
+			gameplayChoices = append(gameplayChoices, &discordgo.ApplicationCommandOptionChoice{
+				Name:  g.String(),
+				Value: string(g),
+			})
+		}
+		if _, err := b.ApplicationCommandCreate(b.ClientId, b.GuildId, &discordgo.ApplicationCommand{
+			Name:        "roster",
+			Description: "search the roster by onboarding answers",
+			Type:        discordgo.ChatApplicationCommand,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Name:        "gameplay",
+					Description: "members into this gameplay",
+					Type:        discordgo.ApplicationCommandOptionString,
+					Choices:     gameplayChoices,
+				},
+				{
+					Name:        "min_playtime",
+					Description: "played for at least this many months",
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					MinValue:    utils.Float64Pointer(0),
+				},
+				{
+					Name:        "max_playtime",
+					Description: "played for at most this many months",
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					MinValue:    utils.Float64Pointer(0),
+				},
+				{
+					Name:        "min_age",
+					Description: "at least this old",
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					MinValue:    utils.Float64Pointer(0),
+				},
+				{
+					Name:        "max_age",
+					Description: "at most this old",
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					MinValue:    utils.Float64Pointer(0),
+				},
+				{
+					Name:        "needs_review",
+					Description: "only members with onboarding answers waiting on a review",
+					Type:        discordgo.ApplicationCommandOptionBoolean,
+				},
+				{
+					Name:        "page",
+					Description: "which page of members to show",
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					MinValue:    utils.Float64Pointer(1),
+				},
+			},
+		}); err != nil {
+			return errors.Wrap(err, "failed creating roster command")
+		}
+	}
+
+	// recruits
+	if settings.GetBool("FEATURES.RECRUITS.ENABLE") {
+		log.Debug("using recruits feature")
+		if _, err := b.ApplicationCommandCreate(b.ClientId, b.GuildId, &discordgo.ApplicationCommand{
+			Name:        "recruits",
+			Description: "see who members have recruited",
+			Type:        discordgo.ChatApplicationCommand,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Name:        "member",
+					Description: "who a member has recruited",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Name:        "member",
+							Description: "the recruiter, yourself if not given (Officer only)",
+							Type:        discordgo.ApplicationCommandOptionUser,
+						},
+					},
+				},
+				{
+					Name:        "leaderboard",
+					Description: "the members who have recruited the most",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Name:        "days",
+							Description: "how many days back to count recruits",
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							MinValue:    utils.Float64Pointer(1),
+						},
+					},
+				},
+			},
+		}); err != nil {
+			return errors.Wrap(err, "failed creating recruits command")
+		}
+	}
+
+	// events
+	if settings.GetBool("FEATURES.EVENTS.ENABLE") {
+		log.Debug("using events feature")
+
+		// mirror the scheduled events made in discord
+		b.AddHandler(syncScheduledEvents)
+		b.AddHandler(onScheduledEventCreate)
+		b.AddHandler(onScheduledEventUpdate)
+		b.AddHandler(onScheduledEventDelete)
+		b.AddHandler(onScheduledEventUserAdd)
+		b.AddHandler(onScheduledEventUserRemove)
+
+		gameplayChoices := []*discordgo.ApplicationCommandOptionChoice{}
+		for _, g := range members.GameplayTypes {
+			gameplayChoices = append(gameplayChoices, &discordgo.ApplicationCommandOptionChoice{
+				Name:  g.String(),
+				Value: string(g),
+			})
+		}
+		eventOptions := func(required bool) []*discordgo.ApplicationCommandOption {
+			return []*discordgo.ApplicationCommandOption{
+				{
+					Name:        "name",
+					Description: "what the event is called",
+					Type:        discordgo.ApplicationCommandOptionString,
+					Required:    required,
+				},
+				{
+					Name:        "start",
+					Description: "when it starts, how long from now like 2h or UTC like 2024-05-01 19:00",
+					Type:        discordgo.ApplicationCommandOptionString,
+					Required:    required,
+				},
+				{
+					Name:        "duration",
+					Description: "how long it runs for, like 2h",
+					Type:        discordgo.ApplicationCommandOptionString,
+					Required:    required,
+				},
+				{
+					Name:         "channel",
+					Description:  "the voice channel it is held in",
+					Type:         discordgo.ApplicationCommandOptionChannel,
+					ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildVoice, discordgo.ChannelTypeGuildStageVoice},
+				},
+				{
+					Name:        "gameplay",
+					Description: "what kind of gameplay it is",
+					Type:        discordgo.ApplicationCommandOptionString,
+					Choices:     gameplayChoices,
+				},
+				{
+					Name:        "host",
+					Description: "who is hosting, you if not given",
+					Type:        discordgo.ApplicationCommandOptionUser,
+				},
+			}
+		}
+		eventOption := &discordgo.ApplicationCommandOption{
+			Name:         "event",
+			Description:  "the event",
+			Type:         discordgo.ApplicationCommandOptionString,
+			Required:     true,
+			Autocomplete: true,
+		}
+		if _, err := b.ApplicationCommandCreate(b.ClientId, b.GuildId, &discordgo.ApplicationCommand{
+			Name:        "event",
+			Description: "schedule events and see what is coming up",
+			Type:        discordgo.ChatApplicationCommand,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Name:        "create",
+					Description: "schedule an event",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Options:     eventOptions(true),
+				},
+				{
+					Name:        "edit",
+					Description: "change an event",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Options:     append([]*discordgo.ApplicationCommandOption{eventOption}, eventOptions(false)...),
+				},
+				{
+					Name:        "cancel",
+					Description: "cancel an event",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Options:     []*discordgo.ApplicationCommandOption{eventOption},
+				},
+				{
+					Name:        "list",
+					Description: "see the upcoming events",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+				},
+			},
+		}); err != nil {
+			return errors.Wrap(err, "failed creating event command")
+		}
+	}
+
 	// activity tracking
 	if settings.GetBool("FEATURES.ACTIVITY_TRACKING.ENABLE") {
 		b.AddHandler(onVoiceUpdate)
@@ -444,6 +1072,9 @@ func (b *Bot) Setup() error {
 func (b *Bot) Close() error {
 	log.Info("stopping bot")
 
+	// stop anything still waiting on the database
+	b.cancel()
+
 	// clear commands
 	cmds, err := b.ApplicationCommands(b.ClientId, b.GuildId)
 	if err != nil {
@@ -17,6 +17,9 @@ import (
 	"golang.org/x/exp/slices"
 )
 
+// how many of the latest rank changes to show on a profile
+const profileHistoryLength = 10
+
 func profileCommandHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) error {
 	logger := utils.GetLoggerFromContext(ctx).(*log.Entry)
 
@@ -26,6 +29,7 @@ func profileCommandHandler(ctx context.Context, s *discordgo.Session, i *discord
 			Flags: discordgo.MessageFlagsEphemeral,
 		},
 	})
+	ctx = deferred(ctx)
 
 	member := utils.GetMemberFromContext(ctx).(*members.Member)
 
@@ -55,14 +59,14 @@ func profileCommandHandler(ctx context.Context, s *discordgo.Session, i *discord
 
 	if len(data.Options) > 0 {
 		logger.Debug("getting profile of other member")
-		if member.Rank > ranks.Lieutenant {
+		if !member.Rank.IsOfficer() {
 			return InvalidPermissions
 		}
 
 		otherMemberId := data.Options[0].UserValue(s).ID
 
 		if otherMemberId != "" {
-			otherMember, err := members.Get(otherMemberId)
+			otherMember, err := members.Get(ctx, otherMemberId)
 			if err != nil {
 				if !errors.Is(err, members.MemberNotFound) {
 					return errors.Wrap(err, "getting member for profile command")
@@ -89,6 +93,7 @@ func profileCommandHandler(ctx context.Context, s *discordgo.Session, i *discord
 
 			if len(data.Options) > 1 && data.Options[1].BoolValue() { // update the member before getting their profile
 				logger.Debug("force updating member")
+				previousStatus := otherMember.Status()
 				if err := rsi.UpdateRsiInfo(otherMember); err != nil {
 					if strings.Contains(err.Error(), "Forbidden") || strings.Contains(err.Error(), "Bad Gateway") {
 						return err
@@ -106,6 +111,8 @@ func profileCommandHandler(ctx context.Context, s *discordgo.Session, i *discord
 					otherMember.RSIMember = false
 				}
 
+				rsiStatus := otherMember.Status()
+
 				discordMember, err := s.GuildMember(i.GuildID, otherMember.Id)
 				if err != nil {
 					return errors.Wrap(err, "getting discord member")
@@ -113,7 +120,7 @@ func profileCommandHandler(ctx context.Context, s *discordgo.Session, i *discord
 
 				if slices.Contains(discordMember.Roles, settings.GetString("DISCORD.ROLE_IDS.RECRUIT")) {
 					logger.Debug("is recruit")
-					otherMember.Rank = ranks.Recruit
+					otherMember.Rank = ranks.Lowest()
 					otherMember.IsAffiliate = false
 					otherMember.IsAlly = false
 					otherMember.IsGuest = false
@@ -134,7 +141,20 @@ func profileCommandHandler(ctx context.Context, s *discordgo.Session, i *discord
 					otherMember.IsBot = true
 				}
 
-				if err := otherMember.Save(); err != nil {
+				cause := members.CauseRSI
+				switch {
+				case otherMember.IsBot:
+					cause = members.CauseMonitor
+				case otherMember.Status() != rsiStatus:
+					cause = members.CauseRole
+				}
+				otherMember.TrackStatus(previousStatus, cause, "")
+
+				if err := otherMember.SetRSIInfo(ctx); err != nil {
+					return err
+				}
+
+				if err := otherMember.SetDiscordInfo(ctx); err != nil {
 					return err
 				}
 			}
@@ -143,9 +163,9 @@ func profileCommandHandler(ctx context.Context, s *discordgo.Session, i *discord
 		}
 	}
 
-	attendedEventCount, err := attdnc.GetMemberAttendanceCount(member.Id)
+	promotion, attendedEventCount, err := attdnc.EvaluatePromotion(ctx, member)
 	if err != nil {
-		return errors.Wrap(err, "getting member attendance count")
+		return errors.Wrap(err, "evaluating member promotion")
 	}
 
 	rank := member.Rank.String()
@@ -212,6 +232,46 @@ func profileCommandHandler(ctx context.Context, s *discordgo.Session, i *discord
 		emFields = append(emFields, rsiFields...)
 	}
 
+	if timeInRank, ok := member.TimeInRank(); ok {
+		emFields = append(emFields, &discordgo.MessageEmbedField{
+			Name:   "Time in Rank",
+			Value:  members.FormatDuration(timeInRank),
+			Inline: true,
+		})
+	}
+
+	if len(member.Merits) > 0 || len(member.Demerits) > 0 {
+		emFields = append(emFields, &discordgo.MessageEmbedField{
+			Name:   "Merit Score",
+			Value:  fmt.Sprintf("%d (%d merits, %d demerits)", member.MeritScore(), len(member.ActiveMerits()), len(member.ActiveDemerits())),
+			Inline: true,
+		})
+	}
+
+	if len(member.History) > 0 {
+		history := []string{}
+		for n := len(member.History) - 1; n >= 0 && len(history) < profileHistoryLength; n-- {
+			history = append(history, member.History[n].String())
+		}
+		emFields = append(emFields, &discordgo.MessageEmbedField{
+			Name:   "Rank History",
+			Value:  strings.Join(history, "\n"),
+			Inline: false,
+		})
+	}
+
+	if promotion.Next != ranks.None {
+		next := "Ready for promotion"
+		if !promotion.Eligible() {
+			next = "Needs " + strings.Join(promotion.Unmet, ", ")
+		}
+		emFields = append(emFields, &discordgo.MessageEmbedField{
+			Name:   "Next Rank: " + promotion.Next.String(),
+			Value:  next,
+			Inline: false,
+		})
+	}
+
 	memberIssues := attdnc.Issues(member)
 	if len(memberIssues) > 0 {
 		emFields = append(emFields, &discordgo.MessageEmbedField{
@@ -3,15 +3,28 @@ package bot
 import (
 	"context"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/apex/log"
 	"github.com/bwmarrin/discordgo"
+	"github.com/pkg/errors"
 	"github.com/sol-armada/sol-bot/attendance"
+	"github.com/sol-armada/sol-bot/audit"
 	"github.com/sol-armada/sol-bot/members"
 	"github.com/sol-armada/sol-bot/ranks"
+	"github.com/sol-armada/sol-bot/settings"
 	"github.com/sol-armada/sol-bot/utils"
 )
 
+// how many candidates to list, each gets its own message with buttons
+const maxRankUpsListed = 10
+
+// the longest nickname discord allows
+const maxNicknameLength = 32
+
+var errRankChanged = errors.New("rank changed since the rank up was listed")
+
 func rankUpsCommandHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) error {
 	logger := utils.GetLoggerFromContext(ctx).(*log.Entry)
 	logger.Debug("rank ups command handler")
@@ -26,49 +39,42 @@ func rankUpsCommandHandler(ctx context.Context, s *discordgo.Session, i *discord
 			Flags: discordgo.MessageFlagsEphemeral,
 		},
 	})
+	ctx = deferred(ctx)
 
 	// get members
-	membersList, err := members.List(0)
+	membersList, err := members.List(ctx, 0)
 	if err != nil {
 		return err
 	}
 
 	// check if any members need to rank up
 	type t struct {
-		Member   members.Member
-		NextRank ranks.Rank
-		Count    int
+		Member     members.Member
+		NextRank   ranks.Rank
+		Count      int
+		TimeInRank time.Duration
 	}
 	needsRankUp := []t{}
 	for _, member := range membersList {
-		if !member.IsRanked() || member.IsGuest || member.IsAlly || member.IsAffiliate || member.Rank == ranks.Recruit {
+		if member.Rank == ranks.None || member.IsGuest || member.IsAlly || member.IsAffiliate {
 			continue
 		}
 
-		count, err := attendance.GetMemberAttendanceCount(member.Id)
+		evaluation, count, err := attendance.EvaluatePromotion(ctx, &member)
 		if err != nil {
 			return err
 		}
 
-		tt := t{Member: member, Count: count}
-
-		if member.Rank == ranks.Recruit && count >= 3 {
-			tt.NextRank = ranks.Member
-		}
-
-		if member.Rank == ranks.Member && count >= 10 {
-			tt.NextRank = ranks.Technician
-		}
+		logger.WithField("member", member).WithField("count", count).WithField("evaluation", evaluation).Debug("checking if member needs rank up")
 
-		if member.Rank == ranks.Technician && count >= 20 {
-			tt.NextRank = ranks.Specialist
-		}
-
-		if tt.NextRank == 0 {
+		if !evaluation.Eligible() {
 			continue
 		}
 
-		logger.WithField("member", member).WithField("count", count).WithField("nextRank", tt.NextRank).Debug("checking if member needs rank up")
+		tt := t{Member: member, NextRank: evaluation.Next, Count: count}
+		if timeInRank, ok := member.TimeInRank(); ok {
+			tt.TimeInRank = timeInRank
+		}
 
 		needsRankUp = append(needsRankUp, tt)
 	}
@@ -82,28 +88,272 @@ func rankUpsCommandHandler(ctx context.Context, s *discordgo.Session, i *discord
 		return nil
 	}
 
-	// output the list of members that need to be ranked up
+	// output the list of members that need to be ranked up, each with their
+	// own buttons
 	logger.WithField("members", needsRankUp).Debug("need to rank up")
 
-	embed := &discordgo.MessageEmbed{
-		Title:  "Members that need a Rank Up",
-		Fields: []*discordgo.MessageEmbedField{},
+	content := "These members need a rank up"
+	if len(needsRankUp) > maxRankUpsListed {
+		content += fmt.Sprintf(", showing the first %d of %d", maxRankUpsListed, len(needsRankUp))
+		needsRankUp = needsRankUp[:maxRankUpsListed]
+	}
+	if _, err := s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+		Content: content,
+		Flags:   discordgo.MessageFlagsEphemeral,
+	}); err != nil {
+		return err
 	}
+
 	for _, member := range needsRankUp {
-		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
-			Name:  "",
-			Value: fmt.Sprintf("<@%s> to %s (%d Events)", member.Member.Id, member.NextRank.String(), member.Count),
-		})
+		inRank := "time in rank unknown"
+		if member.TimeInRank > 0 {
+			inRank = members.FormatDuration(member.TimeInRank) + " in rank"
+		}
+
+		id := fmt.Sprintf("%s:%s", member.Member.Id, member.NextRank.String())
+		if _, err := s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Content: fmt.Sprintf("<@%s> to %s (%d Events, %s)", member.Member.Id, member.NextRank.String(), member.Count, inRank),
+			Flags:   discordgo.MessageFlagsEphemeral,
+			Components: []discordgo.MessageComponent{
+				discordgo.ActionsRow{
+					Components: []discordgo.MessageComponent{
+						discordgo.Button{
+							Label:    "Approve",
+							Style:    discordgo.SuccessButton,
+							CustomID: "rankup:approve:" + id,
+						},
+						discordgo.Button{
+							Label:    "Deny",
+							Style:    discordgo.DangerButton,
+							CustomID: "rankup:deny:" + id,
+						},
+					},
+				},
+			},
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// rankUpTarget reads the member and the rank they are up for from the custom id
+func rankUpTarget(ctx context.Context, customId string) (*members.Member, ranks.Rank, error) {
+	id := strings.Split(customId, ":")
+
+	next := ranks.GetRankByName(id[3])
+	if next == ranks.None {
+		return nil, ranks.None, errors.New("rank up rank " + id[3] + " is not on the ladder")
+	}
+
+	member, err := members.Get(ctx, id[2])
+	if err != nil {
+		return nil, ranks.None, errors.Wrap(err, "getting member to rank up")
+	}
+
+	return member, next, nil
+}
+
+func approveRankUpButtonHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) error {
+	logger := utils.GetLoggerFromContext(ctx).(*log.Entry)
+	logger.Debug("approve rank up button handler")
+
+	if !allowed(i.Member, "ATTENDANCE") {
+		return InvalidPermissions
 	}
 
-	// create followup
-	_, err = s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
-		Content: "These members need a rank up",
-		Embeds:  []*discordgo.MessageEmbed{embed},
+	officer := utils.GetMemberFromContext(ctx).(*members.Member)
+
+	// swapping roles and announcing can take longer than discord waits for a
+	// response
+	if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredMessageUpdate,
+	}); err != nil {
+		return errors.Wrap(err, "deferring rank up approval")
+	}
+	ctx = deferred(ctx)
+
+	member, next, err := rankUpTarget(ctx, i.MessageComponentData().CustomID)
+	if err != nil {
+		return err
+	}
+
+	before := *member
+	member, err = members.Update(ctx, member.Id, func(m *members.Member) error {
+		// someone else got to them first
+		if m.Rank.Above() != next {
+			return errRankChanged
+		}
+
+		previous := m.Status()
+		m.Rank = next
+		m.TrackStatus(previous, members.CauseOfficer, officer.Id)
+		return nil
 	})
+	if err != nil {
+		if !errors.Is(err, errRankChanged) {
+			return errors.Wrap(err, "promoting member")
+		}
+		content := fmt.Sprintf("<@%s> is no longer up for %s", before.Id, next.String())
+		_, err := s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+			Content:    &content,
+			Components: &[]discordgo.MessageComponent{},
+		})
+		return err
+	}
+
+	recordAudit(ctx, memberActor(officer), audit.RankChanged, memberTarget(member), &before, member)
+
+	// the rank is saved either way, anything discord won't let us do the
+	// officer can fix by hand
+	problems := []string{}
+	if err := swapRankRoles(s, member.Id, before.Rank, member.Rank); err != nil {
+		logger.WithError(err).Warn("swapping rank roles")
+		problems = append(problems, "couldn't swap their roles")
+	}
+	if err := setRankNickname(s, member); err != nil {
+		logger.WithError(err).Warn("setting rank nickname")
+		problems = append(problems, "couldn't change their nickname")
+	}
+
+	if channelId := settings.GetString("FEATURES.ATTENDANCE.PROMOTION_CHANNEL_ID"); channelId != "" {
+		if _, err := s.ChannelMessageSend(channelId, fmt.Sprintf("Congratulations <@%s> on your promotion to %s!", member.Id, member.Rank.String())); err != nil {
+			logger.WithError(err).Warn("announcing promotion")
+			problems = append(problems, "couldn't announce it")
+		}
+	}
+
+	refreshOnboardingMessage(ctx, s, member)
+
+	content := fmt.Sprintf("<@%s> was promoted to %s by <@%s>", member.Id, member.Rank.String(), officer.Id)
+	if len(problems) > 0 {
+		content += ", but " + strings.Join(problems, ", ")
+	}
+
+	if _, err := s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Content:    &content,
+		Components: &[]discordgo.MessageComponent{},
+	}); err != nil {
+		return errors.Wrap(err, "responding to rank up approval")
+	}
+
+	return nil
+}
+
+// swapRankRoles takes away the Discord role of the old rank and gives the
+// role of the new one, for the ranks that have one
+func swapRankRoles(s *discordgo.Session, memberId string, from ranks.Rank, to ranks.Rank) error {
+	if definition, ok := from.Definition(); ok && definition.RoleId != "" {
+		if err := s.GuildMemberRoleRemove(bot.GuildId, memberId, definition.RoleId); err != nil {
+			return errors.Wrap(err, "removing old rank role")
+		}
+	}
+	if definition, ok := to.Definition(); ok && definition.RoleId != "" {
+		if err := s.GuildMemberRoleAdd(bot.GuildId, memberId, definition.RoleId); err != nil {
+			return errors.Wrap(err, "adding new rank role")
+		}
+	}
+	return nil
+}
+
+// setRankNickname puts the member's rank in front of their nickname, like
+// "[TEC] name"
+func setRankNickname(s *discordgo.Session, member *members.Member) error {
+	discordMember, err := s.GuildMember(bot.GuildId, member.Id)
+	if err != nil {
+		return errors.Wrap(err, "getting discord member")
+	}
+
+	nick := member.GetTrueNick(discordMember)
+	if prefix := member.Rank.Prefix(); prefix != "" {
+		nick = prefix + " " + nick
+	}
+	if member.Suffix != "" {
+		nick += " (" + member.Suffix + ")"
+	}
+	if len([]rune(nick)) > maxNicknameLength {
+		nick = string([]rune(nick)[:maxNicknameLength])
+	}
+
+	if nick == discordMember.Nick {
+		return nil
+	}
+
+	return s.GuildMemberNickname(bot.GuildId, member.Id, nick)
+}
+
+func denyRankUpButtonHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) error {
+	logger := utils.GetLoggerFromContext(ctx).(*log.Entry)
+	logger.Debug("deny rank up button handler")
+
+	if !allowed(i.Member, "ATTENDANCE") {
+		return InvalidPermissions
+	}
+
+	member, next, err := rankUpTarget(ctx, i.MessageComponentData().CustomID)
 	if err != nil {
 		return err
 	}
 
+	if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseModal,
+		Data: &discordgo.InteractionResponseData{
+			CustomID: fmt.Sprintf("rankup:deny:%s:%s", member.Id, next.String()),
+			Title:    "Deny promotion to " + next.String(),
+			Components: []discordgo.MessageComponent{
+				discordgo.ActionsRow{
+					Components: []discordgo.MessageComponent{
+						discordgo.TextInput{
+							CustomID:  "reason",
+							Label:     "Why is the promotion denied?",
+							Style:     discordgo.TextInputParagraph,
+							Required:  true,
+							MaxLength: 500,
+						},
+					},
+				},
+			},
+		},
+	}); err != nil {
+		return errors.Wrap(err, "deny rank up button handler: responding")
+	}
+
+	return nil
+}
+
+func denyRankUpModalHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) error {
+	logger := utils.GetLoggerFromContext(ctx).(*log.Entry)
+	logger.Debug("deny rank up modal handler")
+
+	if !allowed(i.Member, "ATTENDANCE") {
+		return InvalidPermissions
+	}
+
+	officer := utils.GetMemberFromContext(ctx).(*members.Member)
+
+	data := i.ModalSubmitData()
+	member, next, err := rankUpTarget(ctx, data.CustomID)
+	if err != nil {
+		return err
+	}
+
+	reason := data.Components[0].(*discordgo.ActionsRow).Components[0].(*discordgo.TextInput).Value
+
+	recordAudit(ctx, memberActor(officer), audit.PromotionDenied, memberTarget(member), nil, map[string]interface{}{
+		"rank":   next.String(),
+		"reason": reason,
+	})
+
+	if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Content:    fmt.Sprintf("<@%s> was denied %s by <@%s>: %s", member.Id, next.String(), officer.Id, reason),
+			Components: []discordgo.MessageComponent{},
+		},
+	}); err != nil {
+		return errors.Wrap(err, "responding to rank up denial")
+	}
+
 	return nil
 }
@@ -0,0 +1,359 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/bwmarrin/discordgo"
+	"github.com/pkg/errors"
+	"github.com/sol-armada/sol-bot/audit"
+	"github.com/sol-armada/sol-bot/members"
+	"github.com/sol-armada/sol-bot/settings"
+	"github.com/sol-armada/sol-bot/utils"
+)
+
+// how far back the recruiter leaderboard looks when no period is given
+const defaultRecruitsLeaderboardDays = 30
+
+// how many recruits or recruiters to list before cutting the embed short
+const maxRecruitsListed = 25
+
+var mentionReg = regexp.MustCompile(`^<@!?(\d+)>$|^(\d{17,20})$`)
+
+// recruiterCandidates finds the members the recruiter answer could mean, by
+// mention, RSI handle or Discord name
+func recruiterCandidates(ctx context.Context, s *discordgo.Session, recruit *members.Member, answer string) ([]*members.Member, error) {
+	answer = strings.TrimPrefix(strings.TrimSpace(answer), "@")
+	if answer == "" {
+		return nil, nil
+	}
+
+	candidates := []*members.Member{}
+	seen := map[string]bool{recruit.Id: true}
+	add := func(member *members.Member) {
+		if seen[member.Id] || member.IsBot || member.IsArchived() {
+			return
+		}
+		seen[member.Id] = true
+		candidates = append(candidates, member)
+	}
+	get := func(id string) error {
+		member, err := members.Get(ctx, id)
+		if err != nil {
+			if errors.Is(err, members.MemberNotFound) {
+				return nil
+			}
+			return err
+		}
+		add(member)
+		return nil
+	}
+
+	// a mention or id is as good as it gets
+	if match := mentionReg.FindStringSubmatch(answer); match != nil {
+		id := match[1] + match[2]
+		if err := get(id); err != nil {
+			return nil, errors.Wrap(err, "getting recruiter by id")
+		}
+		return candidates, nil
+	}
+
+	byHandle, err := members.FindByName(ctx, answer)
+	if err != nil {
+		return nil, errors.Wrap(err, "finding recruiter by rsi handle")
+	}
+	for i := range byHandle {
+		add(&byHandle[i])
+	}
+
+	discordMembers, err := s.GuildMembersSearch(bot.GuildId, answer, 10)
+	if err != nil {
+		return nil, errors.Wrap(err, "searching discord for recruiter")
+	}
+	for _, discordMember := range discordMembers {
+		names := []string{discordMember.User.Username, discordMember.User.GlobalName, discordMember.Nick}
+		for _, name := range names {
+			if strings.EqualFold(name, answer) {
+				if err := get(discordMember.User.ID); err != nil {
+					return nil, errors.Wrap(err, "getting recruiter found on discord")
+				}
+				break
+			}
+		}
+	}
+
+	return candidates, nil
+}
+
+// resolveRecruiter links the member to who recruited them from their
+// onboarding answer. If more than one member could be meant, the officers are
+// asked to pick.
+func resolveRecruiter(ctx context.Context, s *discordgo.Session, member *members.Member) error {
+	if member.HasRecruiter() || member.LegacyRecruiter == "" {
+		return nil
+	}
+
+	logger := log.WithFields(log.Fields{
+		"func":      "resolveRecruiter",
+		"member":    member.Id,
+		"recruiter": member.LegacyRecruiter,
+	})
+
+	candidates, err := recruiterCandidates(ctx, s, member, member.LegacyRecruiter)
+	if err != nil {
+		return err
+	}
+
+	switch len(candidates) {
+	case 0:
+		logger.Debug("no member matches the recruiter")
+		return nil
+	case 1:
+		return setRecruiter(ctx, s, audit.System, member, candidates[0])
+	}
+
+	channelId := settings.GetString("DISCORD.OFFICER_CHANNEL_ID")
+	if channelId == "" {
+		logger.Debug("no officer channel to confirm the recruiter in")
+		return nil
+	}
+
+	if len(candidates) > maxRecruitsListed-1 {
+		candidates = candidates[:maxRecruitsListed-1]
+	}
+
+	options := []discordgo.SelectMenuOption{}
+	for _, candidate := range candidates {
+		options = append(options, discordgo.SelectMenuOption{
+			Label:       candidate.Name,
+			Value:       candidate.Id,
+			Description: candidate.Rank.String(),
+		})
+	}
+	options = append(options, discordgo.SelectMenuOption{
+		Label: "None of these",
+		Value: "none",
+	})
+
+	if _, err := s.ChannelMessageSendComplex(channelId, &discordgo.MessageSend{
+		Content: fmt.Sprintf("<@%s> said they were recruited by \"%s\", which could be more than one member. Who recruited them?", member.Id, member.LegacyRecruiter),
+		Components: []discordgo.MessageComponent{
+			discordgo.ActionsRow{
+				Components: []discordgo.MessageComponent{
+					discordgo.SelectMenu{
+						MenuType:    discordgo.StringSelectMenu,
+						CustomID:    "onboarding:recruiter:" + member.Id,
+						Placeholder: "Pick the recruiter",
+						Options:     options,
+					},
+				},
+			},
+		},
+	}); err != nil {
+		return errors.Wrap(err, "asking officers to confirm recruiter")
+	}
+
+	return nil
+}
+
+func setRecruiter(ctx context.Context, s *discordgo.Session, actor audit.Actor, member *members.Member, recruiter *members.Member) error {
+	before := *member
+	if err := member.SetRecruiter(ctx, recruiter); err != nil {
+		return errors.Wrap(err, "setting recruiter")
+	}
+
+	recordAudit(ctx, actor, audit.RecruiterSet, memberTarget(member), &before, member)
+
+	refreshOnboardingMessage(ctx, s, member)
+
+	return nil
+}
+
+func confirmRecruiterHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) error {
+	logger := utils.GetLoggerFromContext(ctx).(*log.Entry)
+	logger.Debug("confirm recruiter handler")
+
+	if !allowed(i.Member, "ONBOARDING") {
+		return InvalidPermissions
+	}
+
+	officer := utils.GetMemberFromContext(ctx).(*members.Member)
+
+	data := i.MessageComponentData()
+	memberId := strings.Split(data.CustomID, ":")[2]
+
+	member, err := members.Get(ctx, memberId)
+	if err != nil {
+		return errors.Wrap(err, "getting member for recruiter")
+	}
+
+	content := fmt.Sprintf("<@%s> left the recruiter for <@%s> unset", officer.Id, member.Id)
+	if len(data.Values) > 0 && data.Values[0] != "none" {
+		recruiter, err := members.Get(ctx, data.Values[0])
+		if err != nil {
+			return errors.Wrap(err, "getting recruiter")
+		}
+
+		if err := setRecruiter(ctx, s, memberActor(officer), member, recruiter); err != nil {
+			return err
+		}
+
+		content = fmt.Sprintf("<@%s> confirmed <@%s> recruited <@%s>", officer.Id, recruiter.Id, member.Id)
+	}
+
+	if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Content:    content,
+			Components: []discordgo.MessageComponent{},
+		},
+	}); err != nil {
+		return errors.Wrap(err, "responding to recruiter confirmation")
+	}
+
+	return nil
+}
+
+func recruitsCommandHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) error {
+	logger := utils.GetLoggerFromContext(ctx).(*log.Entry)
+	logger.Debug("recruits command")
+
+	member := utils.GetMemberFromContext(ctx).(*members.Member)
+
+	subcommand := i.ApplicationCommandData().Options[0]
+
+	var embed *discordgo.MessageEmbed
+	switch subcommand.Name {
+	case "member":
+		recruiter := member
+		for _, o := range subcommand.Options {
+			if o.Name != "member" {
+				continue
+			}
+
+			user := o.UserValue(s)
+			if user.ID == member.Id {
+				continue
+			}
+			if !allowed(i.Member, "RECRUITS") {
+				return InvalidPermissions
+			}
+
+			other, err := members.Get(ctx, user.ID)
+			if err != nil {
+				if !errors.Is(err, members.MemberNotFound) {
+					return errors.Wrap(err, "getting member for recruits")
+				}
+				return s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+					Type: discordgo.InteractionResponseChannelMessageWithSource,
+					Data: &discordgo.InteractionResponseData{
+						Content: "That member was not found in the system!",
+						Flags:   discordgo.MessageFlagsEphemeral,
+					},
+				})
+			}
+			recruiter = other
+		}
+
+		_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Flags: discordgo.MessageFlagsEphemeral,
+			},
+		})
+		ctx = deferred(ctx)
+
+		stats, err := members.ListRecruits(ctx, recruiter)
+		if err != nil {
+			return err
+		}
+		embed = recruitsEmbed(stats)
+	case "leaderboard":
+		days := settings.GetIntWithDefault("FEATURES.RECRUITS.LEADERBOARD_DAYS", defaultRecruitsLeaderboardDays)
+		for _, o := range subcommand.Options {
+			if o.Name == "days" {
+				days = int(o.IntValue())
+			}
+		}
+
+		_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Flags: discordgo.MessageFlagsEphemeral,
+			},
+		})
+		ctx = deferred(ctx)
+
+		leaderboard, err := members.RecruiterLeaderboard(ctx, time.Now().AddDate(0, 0, -days))
+		if err != nil {
+			return err
+		}
+		embed = recruiterLeaderboardEmbed(leaderboard, days)
+	default:
+		return errors.New("unknown recruits subcommand " + subcommand.Name)
+	}
+
+	if _, err := s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+		Flags:  discordgo.MessageFlagsEphemeral,
+		Embeds: []*discordgo.MessageEmbed{embed},
+	}); err != nil {
+		return errors.Wrap(err, "responding to recruits command")
+	}
+
+	return nil
+}
+
+func recruitsEmbed(stats members.RecruitStats) *discordgo.MessageEmbed {
+	lines := []string{}
+	for n, recruit := range stats.Recruits {
+		if n == maxRecruitsListed {
+			lines = append(lines, fmt.Sprintf("and %d more", len(stats.Recruits)-n))
+			break
+		}
+
+		line := fmt.Sprintf("<@%s> %s, joined <t:%d:D>", recruit.Id, recruit.Rank.String(), recruit.Joined.Unix())
+		if recruit.LeftAt != nil || recruit.IsArchived() {
+			line += " (left)"
+		}
+		lines = append(lines, line)
+	}
+
+	description := strings.Join(lines, "\n")
+	if description == "" {
+		description = "No recruits yet"
+	}
+
+	return &discordgo.MessageEmbed{
+		Title:       "Recruits of " + stats.Recruiter.Name,
+		Description: description,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Recruited", Value: fmt.Sprintf("%d", len(stats.Recruits)), Inline: true},
+			{Name: "Ranked Up", Value: fmt.Sprintf("%d", stats.RankedUp), Inline: true},
+			{Name: "Left", Value: fmt.Sprintf("%d", stats.Left), Inline: true},
+		},
+	}
+}
+
+func recruiterLeaderboardEmbed(leaderboard []members.RecruitStats, days int) *discordgo.MessageEmbed {
+	lines := []string{}
+	for n, stats := range leaderboard {
+		if n == maxRecruitsListed {
+			break
+		}
+		lines = append(lines, fmt.Sprintf("%d. <@%s> %d recruited, %d ranked up, %d left", n+1, stats.Recruiter.Id, len(stats.Recruits), stats.RankedUp, stats.Left))
+	}
+
+	description := strings.Join(lines, "\n")
+	if description == "" {
+		description = "No one has recruited anyone in this period"
+	}
+
+	return &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("Top recruiters of the last %d days", days),
+		Description: description,
+	}
+}
@@ -0,0 +1,107 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/apex/log"
+	"github.com/bwmarrin/discordgo"
+	"github.com/pkg/errors"
+	"github.com/sol-armada/sol-bot/members"
+	"github.com/sol-armada/sol-bot/utils"
+)
+
+// how many members to show per page of the roster
+const rosterPageSize = 20
+
+func rosterCommandHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) error {
+	logger := utils.GetLoggerFromContext(ctx).(*log.Entry)
+	logger.Debug("roster command")
+
+	if !allowed(i.Member, "ROSTER") {
+		return InvalidPermissions
+	}
+
+	_ = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	})
+	ctx = deferred(ctx)
+
+	filter := members.RosterFilter{}
+	page := 1
+	searched := []string{}
+	for _, o := range i.ApplicationCommandData().Options {
+		switch o.Name {
+		case "gameplay":
+			filter.Gameplay = members.ToGameplayType(o.StringValue())
+			searched = append(searched, filter.Gameplay.String())
+		case "min_playtime":
+			filter.MinPlaytime = int(o.IntValue())
+			searched = append(searched, fmt.Sprintf("at least %d months played", filter.MinPlaytime))
+		case "max_playtime":
+			filter.MaxPlaytime = int(o.IntValue())
+			searched = append(searched, fmt.Sprintf("at most %d months played", filter.MaxPlaytime))
+		case "min_age":
+			filter.MinAge = int(o.IntValue())
+			searched = append(searched, fmt.Sprintf("at least %d years old", filter.MinAge))
+		case "max_age":
+			filter.MaxAge = int(o.IntValue())
+			searched = append(searched, fmt.Sprintf("at most %d years old", filter.MaxAge))
+		case "needs_review":
+			filter.NeedsReview = o.BoolValue()
+			if filter.NeedsReview {
+				searched = append(searched, "needs review")
+			}
+		case "page":
+			page = int(o.IntValue())
+		}
+	}
+
+	roster, err := members.Roster(ctx, filter, page, rosterPageSize)
+	if err != nil {
+		return errors.Wrap(err, "getting roster")
+	}
+
+	if len(roster) == 0 {
+		_, _ = s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Content: "No members found",
+			Flags:   discordgo.MessageFlagsEphemeral,
+		})
+		return nil
+	}
+
+	lines := []string{}
+	for _, member := range roster {
+		line := fmt.Sprintf("<@%s> %s, %s, %s", member.Id, member.Rank.ShortString(), members.FormatPlaytime(member.Playtime), members.FormatGameplay(member.Gameplay))
+		if len(member.NeedsReview) > 0 {
+			line += " (needs review)"
+		}
+		lines = append(lines, line)
+	}
+
+	title := "Roster"
+	if len(searched) > 0 {
+		title += ": " + strings.Join(searched, ", ")
+	}
+
+	if _, err := s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+		Flags: discordgo.MessageFlagsEphemeral,
+		Embeds: []*discordgo.MessageEmbed{
+			{
+				Title:       title,
+				Description: strings.Join(lines, "\n"),
+				Footer: &discordgo.MessageEmbedFooter{
+					Text: fmt.Sprintf("Page %d", page),
+				},
+			},
+		},
+	}); err != nil {
+		return errors.Wrap(err, "responding to roster command")
+	}
+
+	return nil
+}
@@ -0,0 +1,171 @@
+package bot
+
+import (
+	"context"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/bwmarrin/discordgo"
+	"github.com/pkg/errors"
+	"github.com/sol-armada/sol-bot/events"
+)
+
+// how long a scheduled event is taken to run when it has no end time
+const defaultScheduledEventDuration = time.Hour
+
+// how many interested users are asked for at a time
+const scheduledEventUsersPage = 100
+
+// scheduledEventStatuses are the event statuses for Discord's scheduled event
+// statuses
+var scheduledEventStatuses = map[discordgo.GuildScheduledEventStatus]events.Status{
+	discordgo.GuildScheduledEventStatusScheduled: events.Scheduled,
+	discordgo.GuildScheduledEventStatusActive:    events.Live,
+	discordgo.GuildScheduledEventStatusCompleted: events.Ended,
+	discordgo.GuildScheduledEventStatusCanceled:  events.Cancelled,
+}
+
+func onScheduledEventCreate(s *discordgo.Session, e *discordgo.GuildScheduledEventCreate) {
+	syncScheduledEvent(s, e.GuildScheduledEvent, nil)
+}
+
+func onScheduledEventUpdate(s *discordgo.Session, e *discordgo.GuildScheduledEventUpdate) {
+	syncScheduledEvent(s, e.GuildScheduledEvent, nil)
+}
+
+func onScheduledEventDelete(s *discordgo.Session, e *discordgo.GuildScheduledEventDelete) {
+	// deleting a scheduled event doesn't always say it was cancelled
+	e.Status = discordgo.GuildScheduledEventStatusCanceled
+	syncScheduledEvent(s, e.GuildScheduledEvent, nil)
+}
+
+func onScheduledEventUserAdd(s *discordgo.Session, e *discordgo.GuildScheduledEventUserAdd) {
+	setScheduledEventInterest(s, e.GuildID, e.GuildScheduledEventID, e.UserID, true)
+}
+
+func onScheduledEventUserRemove(s *discordgo.Session, e *discordgo.GuildScheduledEventUserRemove) {
+	setScheduledEventInterest(s, e.GuildID, e.GuildScheduledEventID, e.UserID, false)
+}
+
+// syncScheduledEvents mirrors the guild's scheduled events and who is
+// interested in them, to catch up on anything missed while the bot was down
+func syncScheduledEvents(s *discordgo.Session, _ *discordgo.Ready) {
+	logger := log.WithField("func", "syncScheduledEvents")
+
+	scheduled, err := s.GuildScheduledEvents(bot.GuildId, false)
+	if err != nil {
+		logger.WithError(err).Error("getting scheduled events")
+		return
+	}
+
+	for _, scheduledEvent := range scheduled {
+		interested, err := scheduledEventUsers(s, scheduledEvent.ID)
+		if err != nil {
+			logger.WithError(err).WithField("scheduled_event", scheduledEvent.ID).Error("getting interested users")
+			continue
+		}
+		syncScheduledEvent(s, scheduledEvent, interested)
+	}
+}
+
+// scheduledEventUsers are the ids of everyone interested in the scheduled event
+func scheduledEventUsers(s *discordgo.Session, scheduledEventId string) ([]string, error) {
+	userIds := []string{}
+	after := ""
+	for {
+		users, err := s.GuildScheduledEventUsers(bot.GuildId, scheduledEventId, scheduledEventUsersPage, false, "", after)
+		if err != nil {
+			return nil, err
+		}
+		for _, user := range users {
+			userIds = append(userIds, user.User.ID)
+		}
+		if len(users) < scheduledEventUsersPage {
+			return userIds, nil
+		}
+		after = users[len(users)-1].User.ID
+	}
+}
+
+// syncScheduledEvent mirrors the scheduled event, and opens attendance for it
+// once it has ended. The interested users are replaced when given.
+func syncScheduledEvent(s *discordgo.Session, scheduledEvent *discordgo.GuildScheduledEvent, interested []string) {
+	if scheduledEvent.GuildID != bot.GuildId {
+		return
+	}
+
+	ctx := bot.ctx
+	logger := log.WithField("scheduled_event", scheduledEvent.ID)
+
+	event, err := mirrorScheduledEvent(ctx, scheduledEvent, interested)
+	if err != nil {
+		logger.WithError(err).Error("mirroring scheduled event")
+		return
+	}
+
+	if event.Status != events.Ended || event.AttendanceId != "" {
+		return
+	}
+
+	if err := openEventAttendance(ctx, event, event.Interested); err != nil {
+		logger.WithError(err).Error("opening scheduled event attendance")
+	}
+}
+
+func mirrorScheduledEvent(ctx context.Context, scheduledEvent *discordgo.GuildScheduledEvent, interested []string) (*events.Event, error) {
+	return events.Mirror(ctx, scheduledEvent.ID, func(e *events.Event) {
+		e.Name = scheduledEvent.Name
+		e.Start = scheduledEvent.ScheduledStartTime.UTC()
+		e.Duration = defaultScheduledEventDuration
+		if end := scheduledEvent.ScheduledEndTime; end != nil && end.After(scheduledEvent.ScheduledStartTime) {
+			e.Duration = end.Sub(scheduledEvent.ScheduledStartTime)
+		}
+		e.ChannelId = scheduledEvent.ChannelID
+		if scheduledEvent.CreatorID != "" {
+			e.HostId = scheduledEvent.CreatorID
+		}
+		if status, ok := scheduledEventStatuses[scheduledEvent.Status]; ok {
+			e.Status = status
+		}
+		if interested != nil {
+			e.Interested = interested
+		}
+	})
+}
+
+// setScheduledEventInterest adds or removes the user from those interested in
+// the scheduled event, mirroring it first if it wasn't yet
+func setScheduledEventInterest(s *discordgo.Session, guildId string, scheduledEventId string, userId string, interested bool) {
+	if guildId != bot.GuildId {
+		return
+	}
+
+	ctx := bot.ctx
+	logger := log.WithFields(log.Fields{
+		"scheduled_event": scheduledEventId,
+		"user":            userId,
+	})
+
+	event, err := events.GetByScheduledEvent(ctx, scheduledEventId)
+	if err != nil {
+		if !errors.Is(err, events.ErrEventNotFound) {
+			logger.WithError(err).Error("getting scheduled event")
+			return
+		}
+
+		scheduledEvent, err := s.GuildScheduledEvent(guildId, scheduledEventId, false)
+		if err != nil {
+			logger.WithError(err).Error("getting scheduled event from discord")
+			return
+		}
+		event, err = mirrorScheduledEvent(ctx, scheduledEvent, nil)
+		if err != nil {
+			logger.WithError(err).Error("mirroring scheduled event")
+			return
+		}
+	}
+
+	if _, err := events.SetInterested(ctx, event.Id, userId, interested); err != nil {
+		logger.WithError(err).Error("setting scheduled event interest")
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/bwmarrin/discordgo"
+	"github.com/sol-armada/sol-bot/audit"
 	"github.com/sol-armada/sol-bot/members"
 	"github.com/sol-armada/sol-bot/rsi"
 	"github.com/sol-armada/sol-bot/utils"
@@ -14,7 +15,7 @@ import (
 
 func validateCommandHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) error {
 
-	member, err := members.Get(i.Member.User.ID)
+	member, err := members.Get(ctx, i.Member.User.ID)
 	if err != nil {
 		return err
 	}
@@ -34,8 +35,7 @@ func validateCommandHandler(ctx context.Context, s *discordgo.Session, i *discor
 	}
 
 	code := utils.GenerateRandomAlphaNumeric(8)
-	member.ValidationCode = code
-	if err := member.Save(); err != nil {
+	if err := member.SetValidationCode(ctx, code); err != nil {
 		return err
 	}
 
@@ -66,7 +66,7 @@ func validateCommandHandler(ctx context.Context, s *discordgo.Session, i *discor
 func validateButtonHandler(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) error {
 	memberId := strings.Split(i.MessageComponentData().CustomID, ":")[2]
 
-	member, err := members.Get(memberId)
+	member, err := members.Get(ctx, memberId)
 	if err != nil {
 		return err
 	}
@@ -91,6 +91,7 @@ func validateButtonHandler(ctx context.Context, s *discordgo.Session, i *discord
 			Flags: discordgo.MessageFlagsEphemeral,
 		},
 	})
+	ctx = deferred(ctx)
 
 	waitTime := time.Duration(2 * time.Second)
 	ticker := time.NewTicker(waitTime)
@@ -124,12 +125,15 @@ func validateButtonHandler(ctx context.Context, s *discordgo.Session, i *discord
 		<-ticker.C
 	}
 
-	member.Validated = true
-	member.ValidationCode = ""
-	if err := member.Save(); err != nil {
+	before := *member
+	if err := member.SetValidated(ctx, true); err != nil {
 		return err
 	}
 
+	recordAudit(ctx, memberActor(member), audit.MemberValidated, memberTarget(member), &before, member)
+
+	refreshOnboardingMessage(ctx, s, member)
+
 	if _, err := s.FollowupMessageCreate(i.Interaction, false, &discordgo.WebhookParams{
 		Flags:   discordgo.MessageFlagsEphemeral,
 		Content: "Your account has been validated! You can remove the code from your bio.",
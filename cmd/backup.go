@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sol-armada/sol-bot/stores"
+)
+
+// backup runs the backup subcommand
+//
+//	solbot backup [-out file]
+func backup(args []string) error {
+	fs := flag.NewFlagSet("backup", flag.ExitOnError)
+	out := fs.String("out", "solbot-"+time.Now().UTC().Format("20060102-150405")+".jsonl.gz", "file to write the archive to")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(*out, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return errors.Wrap(err, "creating archive")
+	}
+
+	footer, err := stores.Get().Backup(context.Background(), f)
+	if err != nil {
+		_ = f.Close()
+		_ = os.Remove(*out)
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		return errors.Wrap(err, "closing archive")
+	}
+
+	for _, collection := range stores.BackupCollections {
+		fmt.Printf("%s: %d\n", collection, footer.Counts[collection])
+	}
+	fmt.Println("wrote " + *out)
+
+	return nil
+}
+
+// restore runs the restore subcommand
+//
+//	solbot restore [-validate] [-dry-run] [-collection name] [-until time] file
+func restore(args []string) error {
+	fs := flag.NewFlagSet("restore", flag.ExitOnError)
+	validate := fs.Bool("validate", false, "only check the archive is complete and readable")
+	dryRun := fs.Bool("dry-run", false, "report what would be restored without writing anything")
+	collection := fs.String("collection", "", "restore only this collection")
+	until := fs.String("until", "", "skip attendance, activity, audit, ledger and event documents after this RFC3339 time")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() != 1 {
+		return errors.New("restore needs the archive to restore from")
+	}
+
+	opts := stores.RestoreOptions{
+		Collection: stores.Collection(*collection),
+		DryRun:     *dryRun,
+	}
+	if *until != "" {
+		t, err := time.Parse(time.RFC3339, *until)
+		if err != nil {
+			return errors.Wrap(err, "reading -until")
+		}
+		opts.Until = t
+	}
+
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return errors.Wrap(err, "opening archive")
+	}
+	defer f.Close()
+
+	var report *stores.RestoreReport
+	if *validate {
+		report, err = stores.ValidateBackup(f)
+	} else {
+		report, err = stores.Get().Restore(context.Background(), f, opts)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Print(report.String())
+	if *validate {
+		fmt.Println("archive is valid")
+	}
+
+	return nil
+}
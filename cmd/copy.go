@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/sol-armada/sol-bot/settings"
+	"github.com/sol-armada/sol-bot/stores"
+)
+
+// copyStore runs the copy subcommand, copying everything from the configured
+// storage backend into the other one
+//
+//	solbot copy -to mongo|file [-path file]
+func copyStore(args []string) error {
+	fs := flag.NewFlagSet("copy", flag.ExitOnError)
+	to := fs.String("to", "", "backend to copy into, mongo or file")
+	path := fs.String("path", storeFilePath(), "store file to copy into or out of")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	from := settings.GetStringWithDefault("STORAGE.BACKEND", "mongo")
+	if *to == from && (*to != "file" || *path == storeFilePath()) {
+		return errors.Errorf("storage is already using %s, copy to the other backend", from)
+	}
+
+	ctx := context.Background()
+
+	var dest *stores.Client
+	var err error
+	switch *to {
+	case "mongo":
+		dest, err = stores.Connect(ctx, mongoConfig())
+	case "file":
+		dest, err = stores.OpenFile(ctx, *path)
+	default:
+		return errors.New("-to needs to be mongo or file")
+	}
+	if err != nil {
+		return errors.Wrap(err, "opening the backend to copy into")
+	}
+	defer dest.Disconnect()
+
+	counts, err := stores.Get().CopyTo(ctx, dest)
+	if err != nil {
+		return err
+	}
+
+	for _, collection := range stores.BackupCollections {
+		fmt.Printf("%s: %d\n", collection, counts[collection])
+	}
+	fmt.Printf("copied %s to %s\n", from, *to)
+
+	return nil
+}
@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
-	"strings"
 	"syscall"
 	"time"
 
@@ -14,9 +13,13 @@ import (
 	jsn "github.com/apex/log/handlers/json"
 	"github.com/sol-armada/sol-bot/activity"
 	"github.com/sol-armada/sol-bot/attendance"
+	"github.com/sol-armada/sol-bot/audit"
+	"github.com/sol-armada/sol-bot/bank"
 	"github.com/sol-armada/sol-bot/bot"
+	"github.com/sol-armada/sol-bot/events"
 	"github.com/sol-armada/sol-bot/health"
 	"github.com/sol-armada/sol-bot/members"
+	"github.com/sol-armada/sol-bot/ranks"
 	"github.com/sol-armada/sol-bot/settings"
 	"github.com/sol-armada/sol-bot/stores"
 )
@@ -60,18 +63,20 @@ func init() {
 	}
 
 	// setup storage
-	host := settings.GetStringWithDefault("mongo.host", "localhost")
-	port := settings.GetIntWithDefault("mongo.port", 27017)
-	username := settings.GetString("MONGO.USERNAME")
-	pswd := strings.ReplaceAll(settings.GetString("MONGO.PASSWORD"), "@", `%40`)
-	database := settings.GetStringWithDefault("MONGO.DATABASE", "org")
 	ctx := context.Background()
 
-	if _, err := stores.New(ctx, host, port, username, pswd, database); err != nil {
+	stores.QueryTimeout = settings.GetDurationWithDefault("MONGO.QUERY_TIMEOUT", stores.QueryTimeout)
+
+	if _, err := openStore(ctx); err != nil {
 		log.WithError(err).Error("failed to create storage client")
 		os.Exit(1)
 	}
 
+	if err := ranks.Setup(); err != nil {
+		log.WithError(err).Error("failed to setup ranks")
+		os.Exit(1)
+	}
+
 	if err := members.Setup(); err != nil {
 		log.WithError(err).Error("failed to setup members")
 		os.Exit(1)
@@ -87,15 +92,106 @@ func init() {
 		os.Exit(1)
 	}
 
+	if err := audit.Setup(); err != nil {
+		log.WithError(err).Error("failed to setup audit")
+		os.Exit(1)
+	}
+
+	if err := bank.Setup(); err != nil {
+		log.WithError(err).Error("failed to setup bank")
+		os.Exit(1)
+	}
+
+	if err := events.Setup(); err != nil {
+		log.WithError(err).Error("failed to setup events")
+		os.Exit(1)
+	}
+
 	// monitor health of the server
 	go health.Monitor()
 }
 
+// openStore sets up the storage backend picked in the [storage] settings
+func openStore(ctx context.Context) (*stores.Client, error) {
+	switch backend := settings.GetStringWithDefault("STORAGE.BACKEND", "mongo"); backend {
+	case "mongo":
+		return stores.New(ctx, mongoConfig())
+	case "file":
+		return stores.NewFile(ctx, storeFilePath())
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", backend)
+	}
+}
+
+func storeFilePath() string {
+	return settings.GetStringWithDefault("STORAGE.PATH", "solbot.db")
+}
+
+// mongoConfig reads the [mongo] settings
+func mongoConfig() stores.Config {
+	return stores.Config{
+		URI:            settings.GetString("MONGO.URI"),
+		Host:           settings.GetString("MONGO.HOST"),
+		Port:           settings.GetInt("MONGO.PORT"),
+		SRV:            settings.GetBool("MONGO.SRV"),
+		Username:       settings.GetString("MONGO.USERNAME"),
+		Password:       settings.GetString("MONGO.PASSWORD"),
+		Database:       settings.GetStringWithDefault("MONGO.DATABASE", "org"),
+		AuthSource:     settings.GetString("MONGO.AUTH_SOURCE"),
+		AuthMechanism:  settings.GetString("MONGO.AUTH_MECHANISM"),
+		ReplicaSet:     settings.GetString("MONGO.REPLICA_SET"),
+		ReadPreference: settings.GetString("MONGO.READ_PREFERENCE"),
+		TLS: stores.TLSConfig{
+			Enabled:            settings.GetBool("MONGO.TLS.ENABLE"),
+			CAFile:             settings.GetString("MONGO.TLS.CA_FILE"),
+			CertificateKeyFile: settings.GetString("MONGO.TLS.CERTIFICATE_KEY_FILE"),
+			CertificateFile:    settings.GetString("MONGO.TLS.CERTIFICATE_FILE"),
+			PrivateKeyFile:     settings.GetString("MONGO.TLS.PRIVATE_KEY_FILE"),
+			CertificateKeyPass: settings.GetString("MONGO.TLS.CERTIFICATE_KEY_PASSWORD"),
+			Insecure:           settings.GetBool("MONGO.TLS.INSECURE"),
+		},
+		MinPoolSize:    uint64(settings.GetInt("MONGO.MIN_POOL_SIZE")),
+		MaxPoolSize:    uint64(settings.GetInt("MONGO.MAX_POOL_SIZE")),
+		ConnectTimeout: settings.GetDurationWithDefault("MONGO.CONNECT_TIMEOUT", 5*time.Second),
+	}
+}
+
 func main() {
+	if len(os.Args) > 1 {
+		var err error
+		switch os.Args[1] {
+		case "migrate":
+			err = migrate(os.Args[2:])
+		case "backup":
+			err = backup(os.Args[2:])
+		case "restore":
+			err = restore(os.Args[2:])
+		case "copy":
+			err = copyStore(os.Args[2:])
+		default:
+			err = fmt.Errorf("unknown command %q", os.Args[1])
+		}
+		if err != nil {
+			log.WithError(err).Error("running " + os.Args[1])
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+		stores.Get().Disconnect()
+		return
+	}
+
 	defer func() {
 		log.Info("gracefully shutdown")
 	}()
 
+	// bring the database up to date
+	if settings.GetBoolWithDefault("MONGO.MIGRATE_ON_START", true) {
+		if _, err := stores.Get().Migrate(context.Background(), false); err != nil {
+			log.WithError(err).Error("failed to migrate the database")
+			return
+		}
+	}
+
 	// start up the bot
 	b, err := bot.New()
 	if err != nil {
@@ -116,6 +212,10 @@ func main() {
 	if settings.GetBool("FEATURES.ATTENDANCE.MONITOR") { // only enable if attendance is enabled
 		go bot.MonitorAttendance(stopAttendanceMonitor)
 	}
+	stopEventMonitor := make(chan bool, 1)
+	if settings.GetBool("FEATURES.EVENTS.ENABLE") {
+		go bot.MonitorEvents(stopEventMonitor)
+	}
 	defer func() {
 		log.Info("shutting down")
 		if err := b.Close(); err != nil {
@@ -123,7 +223,9 @@ func main() {
 		}
 		stopMemberMonitor <- true
 		stopAttendanceMonitor <- true
+		stopEventMonitor <- true
 		time.Sleep(20 * time.Second)
+		stores.Get().Disconnect()
 		log.Info("shutdown complete")
 	}()
 
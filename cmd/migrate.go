@@ -0,0 +1,34 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"github.com/sol-armada/sol-bot/stores"
+)
+
+// migrate runs the migrate subcommand
+//
+//	solbot migrate [-dry-run]
+func migrate(args []string) error {
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	dryRun := fs.Bool("dry-run", false, "report what would change without writing anything")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	reports, err := stores.Get().Migrate(context.Background(), *dryRun)
+	for _, report := range reports {
+		fmt.Println(report.String())
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(reports) == 0 {
+		fmt.Println("no migrations registered")
+	}
+
+	return nil
+}
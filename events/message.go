@@ -0,0 +1,110 @@
+package events
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/sol-armada/sol-bot/members"
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
+)
+
+// how many members are listed under a response before just counting them
+const maxListed = 30
+
+func (e *Event) ToDiscordMessage() *discordgo.MessageSend {
+	description := fmt.Sprintf("<t:%d:F> (<t:%d:R>) for %s", e.Start.Unix(), e.Start.Unix(), e.Duration.String())
+
+	fields := []*discordgo.MessageEmbedField{
+		{Name: "Host", Value: "<@" + e.HostId + ">", Inline: true},
+	}
+	if e.ChannelId != "" {
+		fields = append(fields, &discordgo.MessageEmbedField{Name: "Channel", Value: "<#" + e.ChannelId + ">", Inline: true})
+	}
+	if e.Gameplay != "" && e.Gameplay != members.Unknown {
+		fields = append(fields, &discordgo.MessageEmbedField{Name: "Gameplay", Value: e.Gameplay.String(), Inline: true})
+	}
+
+	for _, response := range Responses {
+		ids := e.Responded(response)
+
+		mentions := []string{}
+		for n, id := range ids {
+			if n == maxListed {
+				mentions = append(mentions, fmt.Sprintf("and %d more", len(ids)-maxListed))
+				break
+			}
+			mentions = append(mentions, "<@"+id+">")
+		}
+		value := strings.Join(mentions, "\n")
+		if value == "" {
+			value = "-"
+		}
+
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:   fmt.Sprintf("%s (%d)", cases.Title(language.English).String(string(response)), len(ids)),
+			Value:  value,
+			Inline: true,
+		})
+	}
+
+	title := e.Name
+	color := 0x00AAFF
+	switch e.Status {
+	case Live:
+		title += " (Live)"
+		color = 0x00FF00
+	case Ended:
+		title += " (Ended)"
+		color = 0x808080
+	case Cancelled:
+		title += " (Cancelled)"
+		color = 0xFF0000
+	}
+
+	return &discordgo.MessageSend{
+		Embeds: []*discordgo.MessageEmbed{
+			{
+				Title:       title,
+				Description: description,
+				Color:       color,
+				Fields:      fields,
+				Timestamp:   e.Start.Format(time.RFC3339),
+				Footer: &discordgo.MessageEmbedFooter{
+					Text: e.Id,
+				},
+			},
+		},
+		Components: e.components(),
+	}
+}
+
+func (e *Event) components() []discordgo.MessageComponent {
+	if e.Over() {
+		return []discordgo.MessageComponent{}
+	}
+
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{
+					Label:    "Going",
+					Style:    discordgo.SuccessButton,
+					CustomID: "event:" + string(Going) + ":" + e.Id,
+				},
+				discordgo.Button{
+					Label:    "Maybe",
+					Style:    discordgo.SecondaryButton,
+					CustomID: "event:" + string(Maybe) + ":" + e.Id,
+				},
+				discordgo.Button{
+					Label:    "Declined",
+					Style:    discordgo.DangerButton,
+					CustomID: "event:" + string(Declined) + ":" + e.Id,
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,343 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rs/xid"
+	customerrors "github.com/sol-armada/sol-bot/errors"
+	"github.com/sol-armada/sol-bot/members"
+	"github.com/sol-armada/sol-bot/stores"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	ErrEventNotFound = errors.New("event not found")
+	ErrEventOver     = errors.New("event already ended or was cancelled")
+)
+
+// TimeLayout is how start times are written, always in UTC
+const TimeLayout = "2006-01-02 15:04"
+
+type Status string
+
+const (
+	Scheduled Status = "scheduled"
+	Live      Status = "live"
+	Ended     Status = "ended"
+	Cancelled Status = "cancelled"
+)
+
+type Response string
+
+const (
+	Going    Response = "going"
+	Maybe    Response = "maybe"
+	Declined Response = "declined"
+)
+
+// Responses are the RSVP responses in the order they are shown
+var Responses = []Response{Going, Maybe, Declined}
+
+type Event struct {
+	Id       string               `json:"id" bson:"_id"`
+	Name     string               `json:"name" bson:"name"`
+	Start    time.Time            `json:"start" bson:"start"`
+	Duration time.Duration        `json:"duration" bson:"duration"`
+	HostId   string               `json:"host_id" bson:"host_id"`
+	Gameplay members.GameplayType `json:"gameplay" bson:"gameplay"`
+	// ChannelId is the voice channel the event is held in
+	ChannelId string `json:"channel_id" bson:"channel_id"`
+	Status    Status `json:"status" bson:"status"`
+
+	// RSVPs are the responses by member id
+	RSVPs map[string]Response `json:"rsvps" bson:"rsvps"`
+	// RemindersSent are the offsets before the start reminders went out for
+	RemindersSent []time.Duration `json:"reminders_sent" bson:"reminders_sent"`
+
+	// the RSVP message
+	MessageChannelId string `json:"message_channel_id" bson:"message_channel_id"`
+	MessageId        string `json:"message_id" bson:"message_id"`
+
+	// AttendanceId is the attendance record opened for the event
+	AttendanceId string `json:"attendance_id" bson:"attendance_id"`
+
+	// ScheduledEventId is the Discord scheduled event this one mirrors. Those
+	// are run from Discord, so the bot doesn't remind, start or end them.
+	ScheduledEventId string `json:"scheduled_event_id,omitempty" bson:"scheduled_event_id,omitempty"`
+	// Interested are the ids of the members interested in the scheduled event
+	Interested []string `json:"interested,omitempty" bson:"interested,omitempty"`
+
+	DateCreated time.Time `json:"date_created" bson:"date_created"`
+	DateUpdated time.Time `json:"date_updated" bson:"date_updated"`
+}
+
+var eventsStore stores.EventsStore
+
+// events are read then written, so changes go through this lock to keep two
+// RSVPs at once from losing one
+var mu sync.Mutex
+
+func Setup() error {
+	storesClient := stores.Get()
+	es, ok := storesClient.GetEventsStore()
+	if !ok {
+		return errors.New("events store not found")
+	}
+	eventsStore = es
+	return nil
+}
+
+func New(name string, start time.Time, duration time.Duration, hostId string) (*Event, error) {
+	if name == "" {
+		return nil, customerrors.ErrMissingName
+	}
+	if start.IsZero() {
+		return nil, customerrors.ErrMissingStart
+	}
+	if duration <= 0 {
+		return nil, customerrors.ErrMissingDuration
+	}
+
+	return &Event{
+		Id:          xid.New().String(),
+		Name:        name,
+		Start:       start.UTC(),
+		Duration:    duration,
+		HostId:      hostId,
+		Status:      Scheduled,
+		RSVPs:       map[string]Response{},
+		DateCreated: time.Now().UTC(),
+		DateUpdated: time.Now().UTC(),
+	}, nil
+}
+
+// ParseStart reads a start time, either how long from now like "2h" or a UTC
+// time written in TimeLayout
+func ParseStart(value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, customerrors.ErrMissingStart
+	}
+
+	if in, err := time.ParseDuration(value); err == nil {
+		return now.Add(in).UTC(), nil
+	}
+
+	start, err := time.Parse(TimeLayout, value)
+	if err != nil {
+		return time.Time{}, customerrors.ErrStartWrongFormat
+	}
+	return start, nil
+}
+
+func Get(ctx context.Context, id string) (*Event, error) {
+	if eventsStore == nil {
+		return nil, errors.New("events store not initialized")
+	}
+
+	event := &Event{}
+	if err := eventsStore.Get(ctx, id).Decode(event); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrEventNotFound
+		}
+		return nil, err
+	}
+	return event, nil
+}
+
+func List(ctx context.Context, filter interface{}, limit int, page int) ([]*Event, error) {
+	if eventsStore == nil {
+		return nil, errors.New("events store not initialized")
+	}
+
+	cur, err := eventsStore.List(ctx, filter, limit, page)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	events := []*Event{}
+	if err := cur.All(ctx, &events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// Upcoming are the events that haven't ended or been cancelled, soonest first
+func Upcoming(ctx context.Context, limit int) ([]*Event, error) {
+	return List(ctx, bson.D{{Key: "status", Value: bson.D{{Key: "$in", Value: bson.A{string(Scheduled), string(Live)}}}}}, limit, 0)
+}
+
+// Recent are the events starting after since that weren't cancelled, soonest
+// first
+func Recent(ctx context.Context, since time.Time, limit int) ([]*Event, error) {
+	return List(ctx, bson.D{
+		{Key: "status", Value: bson.D{{Key: "$ne", Value: string(Cancelled)}}},
+		{Key: "start", Value: bson.D{{Key: "$gte", Value: since.UTC()}}},
+	}, limit, 0)
+}
+
+// GetByScheduledEvent gets the event mirroring the Discord scheduled event
+func GetByScheduledEvent(ctx context.Context, scheduledEventId string) (*Event, error) {
+	found, err := List(ctx, bson.D{{Key: "scheduled_event_id", Value: scheduledEventId}}, 1, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(found) == 0 {
+		return nil, ErrEventNotFound
+	}
+	return found[0], nil
+}
+
+func (e *Event) Save(ctx context.Context) error {
+	if eventsStore == nil {
+		return errors.New("events store not initialized")
+	}
+
+	e.DateUpdated = time.Now().UTC()
+	return eventsStore.Upsert(ctx, e.Id, e)
+}
+
+// Update makes a change to the stored event and saves it
+func Update(ctx context.Context, id string, fn func(e *Event) error) (*Event, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	event, err := Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := fn(event); err != nil {
+		return nil, err
+	}
+
+	if err := event.Save(ctx); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+// Mirror makes a change to the event mirroring the Discord scheduled event,
+// creating it if there isn't one yet
+func Mirror(ctx context.Context, scheduledEventId string, fn func(e *Event)) (*Event, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	event, err := GetByScheduledEvent(ctx, scheduledEventId)
+	if err != nil {
+		if !errors.Is(err, ErrEventNotFound) {
+			return nil, err
+		}
+		event = &Event{
+			Id:               xid.New().String(),
+			Status:           Scheduled,
+			RSVPs:            map[string]Response{},
+			ScheduledEventId: scheduledEventId,
+			DateCreated:      time.Now().UTC(),
+		}
+	}
+
+	fn(event)
+
+	if err := event.Save(ctx); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
+
+// RSVP records the member's response to the event
+func RSVP(ctx context.Context, id string, memberId string, response Response) (*Event, error) {
+	return Update(ctx, id, func(e *Event) error {
+		if e.Over() {
+			return ErrEventOver
+		}
+		if e.RSVPs == nil {
+			e.RSVPs = map[string]Response{}
+		}
+		e.RSVPs[memberId] = response
+		return nil
+	})
+}
+
+// SetInterested adds or removes the member from the interested members
+func SetInterested(ctx context.Context, id string, memberId string, interested bool) (*Event, error) {
+	return Update(ctx, id, func(e *Event) error {
+		kept := []string{}
+		for _, existing := range e.Interested {
+			if existing != memberId {
+				kept = append(kept, existing)
+			}
+		}
+		if interested {
+			kept = append(kept, memberId)
+		}
+		e.Interested = kept
+		return nil
+	})
+}
+
+// End is when the event is scheduled to finish
+func (e *Event) End() time.Time {
+	return e.Start.Add(e.Duration)
+}
+
+// Over is if the event ended or was cancelled
+func (e *Event) Over() bool {
+	return e.Status == Ended || e.Status == Cancelled
+}
+
+// Responded are the ids of the members who gave the response, sorted so the
+// RSVP message doesn't shuffle around
+func (e *Event) Responded(response Response) []string {
+	ids := []string{}
+	for memberId, r := range e.RSVPs {
+		if r == response {
+			ids = append(ids, memberId)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// DueReminder is the reminder offset that should go out now, if any. Offsets
+// that were missed, like while the bot was down, are returned with it so they
+// can be marked as sent without reminding several times at once.
+func (e *Event) DueReminder(offsets []time.Duration, now time.Time) (time.Duration, []time.Duration, bool) {
+	if e.Status != Scheduled || !now.Before(e.Start) {
+		return 0, nil, false
+	}
+
+	due := []time.Duration{}
+	for _, offset := range offsets {
+		if offset <= 0 || now.Before(e.Start.Add(-offset)) || e.reminded(offset) {
+			continue
+		}
+		due = append(due, offset)
+	}
+	if len(due) == 0 {
+		return 0, nil, false
+	}
+
+	// the closest to the start is the one worth sending
+	closest := due[0]
+	for _, offset := range due {
+		if offset < closest {
+			closest = offset
+		}
+	}
+	return closest, due, true
+}
+
+func (e *Event) reminded(offset time.Duration) bool {
+	for _, sent := range e.RemindersSent {
+		if sent == offset {
+			return true
+		}
+	}
+	return false
+}
@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/apex/log"
+	"github.com/sol-armada/sol-bot/members"
 	"github.com/sol-armada/sol-bot/stores"
 )
 
@@ -28,3 +29,25 @@ func Monitor() {
 func IsHealthy() bool {
 	return healthy
 }
+
+// IndexReport returns the state of the store indexes from when they were last
+// reconciled
+func IndexReport() []stores.IndexStatus {
+	return stores.Get().IndexStatus()
+}
+
+// IndexesHealthy reports if none of the store indexes need attention. Missing
+// indexes only slow queries down so this does not affect IsHealthy.
+func IndexesHealthy() bool {
+	for _, status := range IndexReport() {
+		if !status.Healthy() {
+			return false
+		}
+	}
+	return true
+}
+
+// MemberCacheReport returns the member cache hit and miss counters
+func MemberCacheReport() members.CacheStats {
+	return members.CacheReport()
+}
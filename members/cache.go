@@ -0,0 +1,178 @@
+package members
+
+import (
+	linkedlist "container/list"
+	"slices"
+	"sync"
+	"time"
+)
+
+// CacheStats are counters for the member cache since the bot started
+type CacheStats struct {
+	Hits          int64 `json:"hits"`
+	Misses        int64 `json:"misses"`
+	Evictions     int64 `json:"evictions"`
+	Expirations   int64 `json:"expirations"`
+	Invalidations int64 `json:"invalidations"`
+	Size          int   `json:"size"`
+	Capacity      int   `json:"capacity"`
+}
+
+// HitRate is the share of lookups answered by the cache, between 0 and 1
+func (s CacheStats) HitRate() float64 {
+	total := s.Hits + s.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(total)
+}
+
+type cacheEntry struct {
+	member  *Member
+	expires time.Time
+}
+
+// memberCache is a bounded least recently used cache of members keyed by
+// Discord ID. Entries expire after the ttl so changes made outside of this
+// process are picked up eventually.
+type memberCache struct {
+	mu       sync.Mutex
+	capacity int
+	ttl      time.Duration
+	entries  map[string]*linkedlist.Element
+	order    *linkedlist.List // most recently used at the front
+	stats    CacheStats
+}
+
+func newMemberCache(capacity int, ttl time.Duration) *memberCache {
+	return &memberCache{
+		capacity: capacity,
+		ttl:      ttl,
+		entries:  map[string]*linkedlist.Element{},
+		order:    linkedlist.New(),
+	}
+}
+
+// get returns a copy of the cached member so callers can change it freely
+func (c *memberCache) get(id string) (*Member, bool) {
+	if c == nil || c.capacity <= 0 {
+		return nil, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[id]
+	if !ok {
+		c.stats.Misses++
+		return nil, false
+	}
+
+	entry := element.Value.(*cacheEntry)
+	if time.Now().After(entry.expires) {
+		c.remove(element)
+		c.stats.Expirations++
+		c.stats.Misses++
+		return nil, false
+	}
+
+	c.order.MoveToFront(element)
+	c.stats.Hits++
+
+	return entry.member.clone(), true
+}
+
+// put stores a copy of the member, evicting the least recently used member if
+// the cache is full
+func (c *memberCache) put(member *Member) {
+	if c == nil || c.capacity <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := &cacheEntry{
+		member:  member.clone(),
+		expires: time.Now().Add(c.ttl),
+	}
+
+	if element, ok := c.entries[member.Id]; ok {
+		element.Value = entry
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.entries[member.Id] = c.order.PushFront(entry)
+
+	for c.order.Len() > c.capacity {
+		c.remove(c.order.Back())
+		c.stats.Evictions++
+	}
+}
+
+func (c *memberCache) invalidate(id string) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[id]; ok {
+		c.remove(element)
+		c.stats.Invalidations++
+	}
+}
+
+func (c *memberCache) snapshot() CacheStats {
+	if c == nil {
+		return CacheStats{}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := c.stats
+	stats.Size = c.order.Len()
+	stats.Capacity = c.capacity
+	return stats
+}
+
+func (c *memberCache) remove(element *linkedlist.Element) {
+	c.order.Remove(element)
+	delete(c.entries, element.Value.(*cacheEntry).member.Id)
+}
+
+// clone copies the member deep enough that changing the copy, its lists or
+// the records in them, doesn't change the original
+func (m *Member) clone() *Member {
+	c := *m
+	c.Affiliations = slices.Clone(m.Affiliations)
+	c.Gameplay = slices.Clone(m.Gameplay)
+	c.Merits = clonePointers(m.Merits)
+	c.Demerits = clonePointers(m.Demerits)
+	c.Escalations = clonePointers(m.Escalations)
+	c.NeedsReview = slices.Clone(m.NeedsReview)
+	c.History = clonePointers(m.History)
+	if m.Recruiter != nil {
+		c.Recruiter = m.Recruiter.clone()
+	}
+	return &c
+}
+
+// clonePointers copies the list and the records it points to
+func clonePointers[T any](items []*T) []*T {
+	if items == nil {
+		return nil
+	}
+
+	c := make([]*T, len(items))
+	for i, item := range items {
+		if item != nil {
+			copied := *item
+			c[i] = &copied
+		}
+	}
+	return c
+}
@@ -0,0 +1,46 @@
+package members
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCacheGetReturnsCopy(t *testing.T) {
+	cache := newMemberCache(10, time.Minute)
+	cache.put(&Member{
+		Id:          "1",
+		Merits:      []*Merit{{Id: "m", Reason: "helped"}},
+		Demerits:    []*Demerit{{Id: "d", Reason: "late"}},
+		Escalations: []*Escalation{{Id: "e", Status: EscalationOpen}},
+		History:     []*StatusChange{{Cause: CauseRole}},
+		Recruiter:   &Member{Id: "2", Name: "recruiter"},
+	})
+
+	member, ok := cache.get("1")
+	if !ok {
+		t.Fatal("member not cached")
+	}
+	now := time.Now()
+	member.Merits[0].RevokedAt = &now
+	member.Demerits[0].Reason = "changed"
+	member.Escalations[0].Status = EscalationDismissed
+	member.History[0].Cause = CauseOfficer
+	member.Recruiter.Name = "changed"
+
+	cached, _ := cache.get("1")
+	if cached.Merits[0].RevokedAt != nil {
+		t.Error("revoking the copy's merit revoked the cached one")
+	}
+	if cached.Demerits[0].Reason != "late" {
+		t.Errorf("cached demerit reason is %q", cached.Demerits[0].Reason)
+	}
+	if cached.Escalations[0].Status != EscalationOpen {
+		t.Errorf("cached escalation status is %q", cached.Escalations[0].Status)
+	}
+	if cached.History[0].Cause != CauseRole {
+		t.Errorf("cached history cause is %q", cached.History[0].Cause)
+	}
+	if cached.Recruiter.Name != "recruiter" {
+		t.Errorf("cached recruiter name is %q", cached.Recruiter.Name)
+	}
+}
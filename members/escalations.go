@@ -0,0 +1,164 @@
+package members
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/xid"
+	"github.com/sol-armada/sol-bot/stores"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+var (
+	EscalationNotFound        MemberError = errors.New("escalation not found")
+	EscalationAlreadyResolved MemberError = errors.New("escalation was already resolved")
+)
+
+type EscalationStatus string
+
+const (
+	EscalationOpen         EscalationStatus = "open"
+	EscalationAcknowledged EscalationStatus = "acknowledged"
+	EscalationDismissed    EscalationStatus = "dismissed"
+)
+
+// EscalationRule is what happens once a member collects enough demerits
+type EscalationRule struct {
+	Name string `mapstructure:"name"`
+	// Demerits is how many active demerits it takes
+	Demerits int `mapstructure:"demerits"`
+	// Days is how far back the demerits are counted, all of them if 0
+	Days int `mapstructure:"days"`
+	// RoleId is a Discord role to give the member
+	RoleId string `mapstructure:"role_id"`
+	// Timeout is how long to time the member out for, like "24h"
+	Timeout time.Duration `mapstructure:"timeout"`
+	// DM is a message to send the member, none if empty
+	DM string `mapstructure:"dm"`
+}
+
+// Escalation is a rule that was applied to the member
+type Escalation struct {
+	Id       string        `json:"id" bson:"id"`
+	Rule     string        `json:"rule" bson:"rule"`
+	Demerits int           `json:"demerits" bson:"demerits"`
+	RoleId   string        `json:"role_id" bson:"role_id"`
+	Timeout  time.Duration `json:"timeout" bson:"timeout"`
+	DMSent   bool          `json:"dm_sent" bson:"dm_sent"`
+	When     time.Time     `json:"when" bson:"when"`
+
+	// the officer alert, so it can be updated once resolved
+	ChannelId string `json:"channel_id" bson:"channel_id"`
+	MessageId string `json:"message_id" bson:"message_id"`
+
+	Status     EscalationStatus `json:"status" bson:"status"`
+	ResolvedBy string           `json:"resolved_by" bson:"resolved_by"`
+	ResolvedAt *time.Time       `json:"resolved_at" bson:"resolved_at"`
+}
+
+func NewEscalation(rule EscalationRule, demerits int) *Escalation {
+	return &Escalation{
+		Id:       xid.New().String(),
+		Rule:     rule.Name,
+		Demerits: demerits,
+		When:     time.Now().UTC(),
+		Status:   EscalationOpen,
+	}
+}
+
+// DemeritsWithin are the member's active demerits given in the last days, all
+// of them if days is 0
+func (m *Member) DemeritsWithin(days int) []*Demerit {
+	active := m.ActiveDemerits()
+	if days <= 0 {
+		return active
+	}
+
+	since := time.Now().AddDate(0, 0, -days)
+	within := []*Demerit{}
+	for _, demerit := range active {
+		if demerit.When.After(since) {
+			within = append(within, demerit)
+		}
+	}
+	return within
+}
+
+// DueEscalations are the rules the member has reached that haven't already
+// been applied within the rule's period
+func (m *Member) DueEscalations(rules []EscalationRule) []EscalationRule {
+	due := []EscalationRule{}
+	for _, rule := range rules {
+		if rule.Demerits < 1 || len(m.DemeritsWithin(rule.Days)) < rule.Demerits {
+			continue
+		}
+
+		applied := false
+		for _, escalation := range m.Escalations {
+			if escalation.Rule != rule.Name {
+				continue
+			}
+			if rule.Days <= 0 || escalation.When.After(time.Now().AddDate(0, 0, -rule.Days)) {
+				applied = true
+				break
+			}
+		}
+		if !applied {
+			due = append(due, rule)
+		}
+	}
+	return due
+}
+
+// AddEscalation appends the escalation to the stored member
+func (m *Member) AddEscalation(ctx context.Context, escalation *Escalation) error {
+	m.Escalations = append(m.Escalations, escalation)
+	return m.update(ctx, &stores.Change{Push: bson.M{"escalations": escalation}})
+}
+
+// GetEscalation finds one of the member's escalations
+func (m *Member) GetEscalation(id string) (*Escalation, bool) {
+	for _, escalation := range m.Escalations {
+		if escalation.Id == id {
+			return escalation, true
+		}
+	}
+	return nil, false
+}
+
+// ResolveEscalation marks one of the member's open escalations as acknowledged
+// or dismissed by the officer
+func ResolveEscalation(ctx context.Context, memberId string, id string, status EscalationStatus, who *Member) (*Member, *Escalation, error) {
+	var resolved *Escalation
+	member, err := Update(ctx, memberId, func(m *Member) error {
+		resolved = nil
+		for i, escalation := range m.Escalations {
+			if escalation.Id != id {
+				continue
+			}
+			if escalation.Status != EscalationOpen {
+				return EscalationAlreadyResolved
+			}
+
+			// the escalation may be shared with the cached member, so change
+			// a copy
+			changed := *escalation
+			now := time.Now().UTC()
+			changed.Status = status
+			changed.ResolvedBy = who.Id
+			changed.ResolvedAt = &now
+			m.Escalations[i] = &changed
+			resolved = &changed
+		}
+		if resolved == nil {
+			return EscalationNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return member, resolved, nil
+}
@@ -0,0 +1,61 @@
+package members
+
+import (
+	"context"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestMigrateNullEscalations(t *testing.T) {
+	ctx := context.Background()
+	c := setupMemory(t)
+
+	// members saved before escalations were always arrays
+	store, _ := c.GetMembersStore()
+	if err := store.Upsert(ctx, "1", 0, bson.M{"_id": "1", "name": "one", "merits": bson.A{}, "demerits": bson.A{}, "escalations": nil, "version": int64(1)}); err != nil {
+		t.Fatal(err)
+	}
+
+	member, err := Get(ctx, "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := member.AddEscalation(ctx, &Escalation{Id: "e1"}); err == nil {
+		t.Fatal("pushing onto null escalations should fail like it does in mongo")
+	}
+
+	if _, err := c.Migrate(ctx, false); err != nil {
+		t.Fatal(err)
+	}
+
+	member, err = Get(ctx, "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := member.AddEscalation(ctx, &Escalation{Id: "e1"}); err != nil {
+		t.Fatalf("adding an escalation after migrating: %v", err)
+	}
+}
+
+func TestAddEscalationToSavedMember(t *testing.T) {
+	ctx := context.Background()
+	setupMemory(t)
+
+	member := &Member{Id: "1", Name: "one"}
+	if err := member.Save(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := member.AddEscalation(ctx, &Escalation{Id: "e1"}); err != nil {
+		t.Fatalf("adding an escalation: %v", err)
+	}
+
+	stored, err := Get(ctx, "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stored.Escalations) != 1 {
+		t.Errorf("got %d escalations, want 1", len(stored.Escalations))
+	}
+}
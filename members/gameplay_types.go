@@ -26,6 +26,23 @@ const (
 	Trading        GameplayType = "trading"
 )
 
+// GameplayTypes are all the known gameplay types
+var GameplayTypes = []GameplayType{
+	BountyHunting,
+	Engineering,
+	Exporation,
+	FpsCombat,
+	Hauling,
+	Medical,
+	Mining,
+	Reconnaissance,
+	Racing,
+	Scrapping,
+	ShipCrew,
+	ShipCombat,
+	Trading,
+}
+
 func ToGameplayType(s string) GameplayType {
 	switch strings.ToLower(s) {
 	case "bounty_hunting":
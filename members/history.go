@@ -0,0 +1,153 @@
+package members
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/sol-armada/sol-bot/ranks"
+)
+
+// StatusCause is what changed a member's rank or status
+type StatusCause string
+
+const (
+	// CauseMonitor is a change the member monitor made on its own, like
+	// flagging a bot, or the status the member had when tracking started
+	CauseMonitor StatusCause = "monitor"
+	// CauseRSI is a change read from the member's RSI profile
+	CauseRSI StatusCause = "rsi"
+	// CauseRole is a change from the member's Discord roles
+	CauseRole StatusCause = "role"
+	// CauseOfficer is a change an officer made
+	CauseOfficer StatusCause = "officer"
+)
+
+// Status is where a member stands in the org
+type Status struct {
+	Rank        ranks.Rank `json:"rank" bson:"rank"`
+	IsAlly      bool       `json:"is_ally" bson:"is_ally"`
+	IsAffiliate bool       `json:"is_affiliate" bson:"is_affiliate"`
+	IsGuest     bool       `json:"is_guest" bson:"is_guest"`
+}
+
+func (s Status) String() string {
+	switch {
+	case s.IsAlly:
+		return "Ally"
+	case s.IsAffiliate:
+		return "Affiliate"
+	case s.IsGuest:
+		return "Guest"
+	case s.Rank == ranks.None:
+		return "None"
+	}
+	return s.Rank.String()
+}
+
+// StatusChange is the member's status from a point in time
+type StatusChange struct {
+	Status `bson:",inline"`
+	Cause  StatusCause `json:"cause" bson:"cause"`
+	// By is who made an officer change
+	By   string    `json:"by,omitempty" bson:"by,omitempty"`
+	When time.Time `json:"when" bson:"when"`
+}
+
+func (c *StatusChange) String() string {
+	s := fmt.Sprintf("<t:%d:D> %s (%s", c.When.Unix(), c.Status.String(), c.Cause)
+	if c.By != "" {
+		s += " by <@" + c.By + ">"
+	}
+	return s + ")"
+}
+
+// Status is the member's current status
+func (m *Member) Status() Status {
+	return Status{
+		Rank:        m.Rank,
+		IsAlly:      m.IsAlly,
+		IsAffiliate: m.IsAffiliate,
+		IsGuest:     m.IsGuest,
+	}
+}
+
+// TrackStatus adds the member's current status to their history if it changed.
+// previous is the status before the change was made, it starts the history off
+// for members who had one before it was tracked. by is who made an officer
+// change. It reports if anything was added.
+func (m *Member) TrackStatus(previous Status, cause StatusCause, by string) bool {
+	added := false
+	if len(m.History) == 0 {
+		// all we know is they were like this when they joined, or at least when
+		// we started tracking
+		when := m.Joined
+		if when.IsZero() {
+			when = time.Now().UTC()
+		}
+		m.History = append(m.History, &StatusChange{Status: previous, Cause: CauseMonitor, When: when})
+		added = true
+	}
+
+	current := m.Status()
+	if m.History[len(m.History)-1].Status == current {
+		return added
+	}
+
+	m.History = append(m.History, &StatusChange{
+		Status: current,
+		Cause:  cause,
+		By:     by,
+		When:   time.Now().UTC(),
+	})
+
+	return true
+}
+
+// OfficerRank is if an officer gave the member their current rank, like with a
+// promotion, rather than it coming from RSI or their roles
+func (m *Member) OfficerRank() bool {
+	given := -1
+	for i := len(m.History) - 1; i >= 0 && m.History[i].Rank == m.Rank; i-- {
+		given = i
+	}
+	return given >= 0 && m.History[given].Cause == CauseOfficer
+}
+
+// RankSince is when the member got their current rank, false if their history
+// doesn't say
+func (m *Member) RankSince() (time.Time, bool) {
+	since := time.Time{}
+	for i := len(m.History) - 1; i >= 0; i-- {
+		if m.History[i].Rank != m.Rank {
+			break
+		}
+		since = m.History[i].When
+	}
+	return since, !since.IsZero()
+}
+
+// TimeInRank is how long the member has had their current rank
+func (m *Member) TimeInRank() (time.Duration, bool) {
+	since, ok := m.RankSince()
+	if !ok {
+		return 0, false
+	}
+	return time.Since(since), true
+}
+
+// FormatDuration describes a long duration, like time in rank, for people to
+// read
+func FormatDuration(d time.Duration) string {
+	days := int(d.Hours() / 24)
+	switch {
+	case days < 1:
+		return "less than a day"
+	case days == 1:
+		return "1 day"
+	case days < 60:
+		return fmt.Sprintf("%d days", days)
+	case days < 730:
+		return fmt.Sprintf("%d months", days/30)
+	}
+	return fmt.Sprintf("%.1f years", float64(days)/365)
+}
@@ -0,0 +1,54 @@
+package members
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sol-armada/sol-bot/ranks"
+)
+
+func TestOfficerRank(t *testing.T) {
+	when := time.Date(2024, 5, 1, 19, 0, 0, 0, time.UTC)
+	change := func(rank ranks.Rank, cause StatusCause) *StatusChange {
+		when = when.Add(24 * time.Hour)
+		return &StatusChange{Status: Status{Rank: rank}, Cause: cause, When: when}
+	}
+
+	tests := []struct {
+		name    string
+		rank    ranks.Rank
+		history []*StatusChange
+		want    bool
+	}{
+		{"no history", ranks.Member, nil, false},
+		{"promoted", ranks.Member, []*StatusChange{
+			change(ranks.Recruit, CauseRole),
+			change(ranks.Member, CauseOfficer),
+		}, true},
+		{"promoted then status changed without the rank", ranks.Member, []*StatusChange{
+			change(ranks.Recruit, CauseRole),
+			change(ranks.Member, CauseOfficer),
+			{Status: Status{Rank: ranks.Member, IsAffiliate: true}, Cause: CauseRSI, When: when.Add(time.Hour)},
+		}, true},
+		{"from rsi", ranks.Member, []*StatusChange{
+			change(ranks.Recruit, CauseRole),
+			change(ranks.Member, CauseRSI),
+		}, false},
+		{"promoted then changed on rsi", ranks.Technician, []*StatusChange{
+			change(ranks.Member, CauseOfficer),
+			change(ranks.Technician, CauseRSI),
+		}, false},
+		{"rank changed without being tracked", ranks.Technician, []*StatusChange{
+			change(ranks.Member, CauseOfficer),
+		}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &Member{Rank: tt.rank, History: tt.history}
+			if got := m.OfficerRank(); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,253 @@
+package members
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/xid"
+	"github.com/sol-armada/sol-bot/stores"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+var (
+	MeritNotFound       MemberError = errors.New("merit not found")
+	MeritAlreadyRevoked MemberError = errors.New("merit was already revoked")
+)
+
+// the kinds of merits and demerits that can be given
+const (
+	CategoryConduct       = "conduct"
+	CategoryLeadership    = "leadership"
+	CategoryTeamwork      = "teamwork"
+	CategoryParticipation = "participation"
+	CategorySkill         = "skill"
+	CategoryOther         = "other"
+)
+
+// MeritCategories are all the categories, in the order they are offered
+var MeritCategories = []string{
+	CategoryConduct,
+	CategoryLeadership,
+	CategoryTeamwork,
+	CategoryParticipation,
+	CategorySkill,
+	CategoryOther,
+}
+
+type Merit struct {
+	Id        string    `json:"id" bson:"id"`
+	GiverId   string    `json:"giver_id" bson:"giver_id"`
+	GiverName string    `json:"giver_name" bson:"giver_name"`
+	Reason    string    `json:"reason" bson:"reason"`
+	Category  string    `json:"category" bson:"category"`
+	Weight    int       `json:"weight" bson:"weight"`
+	When      time.Time `json:"when" bson:"when"`
+	// ExpiresAt is when the merit stops counting, nil if it never does
+	ExpiresAt *time.Time `json:"expires_at" bson:"expires_at"`
+
+	RevokedAt    *time.Time `json:"revoked_at" bson:"revoked_at"`
+	RevokedBy    string     `json:"revoked_by" bson:"revoked_by"`
+	RevokeReason string     `json:"revoke_reason" bson:"revoke_reason"`
+}
+
+// Demerit is kept the same way as a merit, it only counts against the member
+type Demerit = Merit
+
+// MeritDetails are the optional parts of a merit or demerit
+type MeritDetails struct {
+	Category  string
+	Weight    int
+	ExpiresAt *time.Time
+}
+
+// NewMerit makes a merit or demerit from the member without giving it to
+// anyone yet
+func NewMerit(reason string, who *Member, details MeritDetails) *Merit {
+	category := details.Category
+	if category == "" {
+		category = CategoryOther
+	}
+
+	weight := details.Weight
+	if weight < 1 {
+		weight = 1
+	}
+
+	return &Merit{
+		Id:        xid.New().String(),
+		GiverId:   who.Id,
+		GiverName: who.Name,
+		Reason:    reason,
+		Category:  category,
+		Weight:    weight,
+		When:      time.Now().UTC(),
+		ExpiresAt: details.ExpiresAt,
+	}
+}
+
+// Active is if the merit still counts, it hasn't been revoked or expired
+func (r *Merit) Active() bool {
+	if r.RevokedAt != nil {
+		return false
+	}
+	return r.ExpiresAt == nil || r.ExpiresAt.After(time.Now())
+}
+
+// Value is how much the merit counts for
+func (r *Merit) Value() int {
+	if r.Weight < 1 {
+		return 1
+	}
+	return r.Weight
+}
+
+func (r *Merit) String() string {
+	s := fmt.Sprintf("%s (%s", r.Reason, r.Category)
+	if r.Value() != 1 {
+		s += fmt.Sprintf(", x%d", r.Value())
+	}
+	s += fmt.Sprintf(") by <@%s> <t:%d:D>", r.GiverId, r.When.Unix())
+
+	switch {
+	case r.RevokedAt != nil:
+		s = "~~" + s + "~~ revoked"
+		if r.RevokeReason != "" {
+			s += ": " + r.RevokeReason
+		}
+	case r.ExpiresAt != nil && !r.Active():
+		s = "~~" + s + "~~ expired"
+	case r.ExpiresAt != nil:
+		s += fmt.Sprintf(", expires <t:%d:R>", r.ExpiresAt.Unix())
+	}
+
+	return s
+}
+
+// Label is a short description of the merit, for picking it from a list
+func (r *Merit) Label() string {
+	label := []rune(fmt.Sprintf("%s: %s", r.When.Format("2006-01-02"), r.Reason))
+	if len(label) > 100 {
+		label = append(label[:99], '…')
+	}
+	return string(label)
+}
+
+func activeOnly(merits []*Merit) []*Merit {
+	active := []*Merit{}
+	for _, merit := range merits {
+		if merit.Active() {
+			active = append(active, merit)
+		}
+	}
+	return active
+}
+
+// ActiveMerits are the merits that still count
+func (m *Member) ActiveMerits() []*Merit {
+	return activeOnly(m.Merits)
+}
+
+// ActiveDemerits are the demerits that still count
+func (m *Member) ActiveDemerits() []*Demerit {
+	return activeOnly(m.Demerits)
+}
+
+// MeritScore is the member's active merits less their active demerits, by
+// weight
+func (m *Member) MeritScore() int {
+	score := 0
+	for _, merit := range m.ActiveMerits() {
+		score += merit.Value()
+	}
+	for _, demerit := range m.ActiveDemerits() {
+		score -= demerit.Value()
+	}
+	return score
+}
+
+// AddMerit appends the merit to the stored member
+func (m *Member) AddMerit(ctx context.Context, merit *Merit) error {
+	m.Merits = append(m.Merits, merit)
+	return m.update(ctx, &stores.Change{Push: bson.M{"merits": merit}})
+}
+
+// AddDemerit appends the demerit to the stored member
+func (m *Member) AddDemerit(ctx context.Context, demerit *Demerit) error {
+	m.Demerits = append(m.Demerits, demerit)
+	return m.update(ctx, &stores.Change{Push: bson.M{"demerits": demerit}})
+}
+
+func (m *Member) GiveMerit(ctx context.Context, reason string, who *Member, details MeritDetails) (*Merit, error) {
+	merit := NewMerit(reason, who, details)
+	return merit, m.AddMerit(ctx, merit)
+}
+
+func (m *Member) GiveDemerit(ctx context.Context, reason string, who *Member, details MeritDetails) (*Demerit, error) {
+	demerit := NewMerit(reason, who, details)
+	return demerit, m.AddDemerit(ctx, demerit)
+}
+
+// RevokeMerit stops one of the member's merits from counting, it is kept so
+// the history stays intact
+func RevokeMerit(ctx context.Context, memberId string, meritId string, who *Member, reason string) (*Member, *Merit, error) {
+	return revoke(ctx, memberId, meritId, who, reason, func(m *Member) []*Merit { return m.Merits })
+}
+
+// RevokeDemerit stops one of the member's demerits from counting
+func RevokeDemerit(ctx context.Context, memberId string, demeritId string, who *Member, reason string) (*Member, *Demerit, error) {
+	return revoke(ctx, memberId, demeritId, who, reason, func(m *Member) []*Merit { return m.Demerits })
+}
+
+func revoke(ctx context.Context, memberId string, id string, who *Member, reason string, from func(m *Member) []*Merit) (*Member, *Merit, error) {
+	var revoked *Merit
+	member, err := Update(ctx, memberId, func(m *Member) error {
+		revoked = nil
+		merits := from(m)
+		for i, merit := range merits {
+			if merit.Id != id {
+				continue
+			}
+			if merit.RevokedAt != nil {
+				return MeritAlreadyRevoked
+			}
+
+			// the merit may be shared with the cached member, so change a copy
+			changed := *merit
+			now := time.Now().UTC()
+			changed.RevokedAt = &now
+			changed.RevokedBy = who.Id
+			changed.RevokeReason = reason
+			merits[i] = &changed
+			revoked = &changed
+		}
+		if revoked == nil {
+			return MeritNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return member, revoked, nil
+}
+
+// FindMerits are the member's merits or demerits that match the search, for
+// picking one out
+func FindMerits(merits []*Merit, search string, onlyActive bool) []*Merit {
+	search = strings.ToLower(search)
+	found := []*Merit{}
+	for _, merit := range merits {
+		if onlyActive && !merit.Active() {
+			continue
+		}
+		if search != "" && !strings.Contains(strings.ToLower(merit.Reason), search) && !strings.Contains(merit.Id, search) {
+			continue
+		}
+		found = append(found, merit)
+	}
+	return found
+}
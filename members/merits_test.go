@@ -0,0 +1,79 @@
+package members
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sol-armada/sol-bot/stores"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func setupMemory(t *testing.T) *stores.Client {
+	t.Helper()
+
+	c := stores.NewMemory(context.Background())
+	if err := Setup(); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestAddMeritToSavedMember(t *testing.T) {
+	ctx := context.Background()
+	setupMemory(t)
+
+	giver := &Member{Id: "giver", Name: "giver"}
+	member := &Member{Id: "1", Name: "one"}
+	if err := member.Save(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := member.GiveMerit(ctx, "good", giver, MeritDetails{}); err != nil {
+		t.Fatalf("giving a merit: %v", err)
+	}
+	if _, err := member.GiveDemerit(ctx, "bad", giver, MeritDetails{}); err != nil {
+		t.Fatalf("giving a demerit: %v", err)
+	}
+
+	stored, err := Get(ctx, "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stored.Merits) != 1 || len(stored.Demerits) != 1 {
+		t.Errorf("got %d merits and %d demerits, want 1 and 1", len(stored.Merits), len(stored.Demerits))
+	}
+}
+
+func TestMigrateNullMerits(t *testing.T) {
+	ctx := context.Background()
+	c := setupMemory(t)
+
+	// members saved before the lists were always arrays
+	store, _ := c.GetMembersStore()
+	if err := store.Upsert(ctx, "1", 0, bson.M{"_id": "1", "name": "one", "merits": nil, "version": int64(1)}); err != nil {
+		t.Fatal(err)
+	}
+
+	member, err := Get(ctx, "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := member.GiveMerit(ctx, "good", member, MeritDetails{}); err == nil {
+		t.Fatal("pushing onto a null merits list should fail like it does in mongo")
+	}
+
+	if _, err := c.Migrate(ctx, false); err != nil {
+		t.Fatal(err)
+	}
+
+	member, err = Get(ctx, "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := member.GiveMerit(ctx, "good", member, MeritDetails{}); err != nil {
+		t.Fatalf("giving a merit after migrating: %v", err)
+	}
+	if _, err := member.GiveDemerit(ctx, "bad", member, MeritDetails{}); err != nil {
+		t.Fatalf("giving a demerit after migrating: %v", err)
+	}
+}
@@ -0,0 +1,332 @@
+package members
+
+import (
+	"github.com/rs/xid"
+	"github.com/sol-armada/sol-bot/ranks"
+	"github.com/sol-armada/sol-bot/stores"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func init() {
+	stores.RegisterMigration(stores.Migration{
+		Version:     1,
+		Description: "rename misspelled member fields",
+		Collection:  stores.MEMBERS,
+		Migrate:     renameMemberFields,
+	})
+
+	stores.RegisterMigration(stores.Migration{
+		Version:     2,
+		Description: "parse legacy onboarding answers",
+		Collection:  stores.MEMBERS,
+		Migrate:     parseLegacyOnboarding,
+	})
+
+	stores.RegisterMigration(stores.Migration{
+		Version:     3,
+		Description: "reparse legacy onboarding answers and flag the unreadable ones for review",
+		Collection:  stores.MEMBERS,
+		Migrate:     reviewLegacyOnboarding,
+	})
+
+	stores.RegisterMigration(stores.Migration{
+		Version:     4,
+		Description: "give merits and demerits ids and keep only the giver's id and name",
+		Collection:  stores.MEMBERS,
+		Migrate:     meritRecords,
+	})
+
+	stores.RegisterMigration(stores.Migration{
+		Version:     5,
+		Description: "store missing merits and demerits as empty arrays so they can be pushed onto",
+		Collection:  stores.MEMBERS,
+		Migrate:     emptyListFieldsFor("merits", "demerits"),
+	})
+
+	stores.RegisterMigration(stores.Migration{
+		Version:     6,
+		Description: "store missing escalations as empty arrays so they can be pushed onto",
+		Collection:  stores.MEMBERS,
+		Migrate:     emptyListFieldsFor("escalations"),
+	})
+
+	stores.RegisterMigration(stores.Migration{
+		Version:     7,
+		Description: "store ranks by name instead of their place on the ladder",
+		Collection:  stores.MEMBERS,
+		Migrate:     rankNames,
+	})
+}
+
+// renameMemberFields moves values from the old misspelled fields to the new
+// ones. If both exist the new field wins since it was written last.
+func renameMemberFields(doc bson.M) (*stores.Change, error) {
+	renames := map[string]string{
+		"affilations":    "affiliations",
+		"gamplay":        "gameplay",
+		"legacy_gamplay": "legacy_gameplay",
+	}
+
+	change := &stores.Change{Set: bson.M{}}
+	for oldField, newField := range renames {
+		value, ok := doc[oldField]
+		if !ok {
+			continue
+		}
+
+		if current, ok := doc[newField]; !ok || current == nil {
+			change.Set[newField] = value
+		}
+		change.Unset = append(change.Unset, oldField)
+	}
+
+	return change, nil
+}
+
+// parseLegacyOnboarding fills in the typed onboarding fields from the free text
+// answers. Fields that already have a value are left alone.
+func parseLegacyOnboarding(doc bson.M) (*stores.Change, error) {
+	change := &stores.Change{Set: bson.M{}}
+
+	if answer, ok := doc["legacy_age"].(string); ok && answer != "" && isZero(doc["age"]) {
+		if age, ok := ParseAge(answer); ok {
+			change.Set["age"] = age
+		}
+	}
+
+	if answer, ok := doc["legacy_playtime"].(string); ok && answer != "" && isZero(doc["playtime"]) {
+		if playtime, ok := ParsePlaytime(answer); ok {
+			change.Set["playtime"] = playtime
+		}
+	}
+
+	if answer, ok := doc["legacy_gameplay"].(string); ok && answer != "" && isEmptyList(doc["gameplay"]) {
+		if gameplay := ParseGameplay(answer); len(gameplay) > 0 {
+			change.Set["gameplay"] = gameplay
+		}
+	}
+
+	return change, nil
+}
+
+// reviewLegacyOnboarding runs the onboarding answers through the parser again,
+// since it now understands more of them, and flags the answers it still can't
+// read so an officer can review them
+func reviewLegacyOnboarding(doc bson.M) (*stores.Change, error) {
+	change, err := parseLegacyOnboarding(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	if !isEmptyList(doc["needs_review"]) {
+		return change, nil
+	}
+
+	review := bson.A{}
+	if answer, ok := doc["legacy_age"].(string); ok && answer != "" && isZero(doc["age"]) && change.Set["age"] == nil {
+		review = append(review, ReviewAge)
+	}
+	if answer, ok := doc["legacy_playtime"].(string); ok && answer != "" {
+		if _, ok := ParsePlaytime(answer); !ok && isZero(doc["playtime"]) {
+			review = append(review, ReviewPlaytime)
+		}
+	}
+	if answer, ok := doc["legacy_gameplay"].(string); ok && answer != "" {
+		if _, unmatched := ParseGameplayAnswer(answer); len(unmatched) > 0 {
+			review = append(review, ReviewGameplay)
+		}
+	}
+	if len(review) > 0 {
+		change.Set["needs_review"] = review
+	}
+
+	return change, nil
+}
+
+// meritRecords turns the merits and demerits, which kept a whole copy of the
+// giver, into records that can be picked out and revoked
+func meritRecords(doc bson.M) (*stores.Change, error) {
+	change := &stores.Change{Set: bson.M{}}
+
+	for _, field := range []string{"merits", "demerits"} {
+		list, ok := toList(doc[field])
+		if !ok || len(list) == 0 {
+			continue
+		}
+
+		records := bson.A{}
+		changed := false
+		for _, item := range list {
+			record, ok := toMap(item)
+			if !ok {
+				records = append(records, item)
+				continue
+			}
+
+			if id, ok := record["id"].(string); ok && id != "" {
+				records = append(records, record)
+				continue
+			}
+
+			updated := bson.M{}
+			for k, v := range record {
+				updated[k] = v
+			}
+			delete(updated, "giver")
+			updated["id"] = xid.New().String()
+			updated["category"] = CategoryOther
+			updated["weight"] = 1
+			if giver, ok := toMap(record["giver"]); ok {
+				updated["giver_id"] = giver["_id"]
+				if updated["giver_id"] == nil {
+					updated["giver_id"] = giver["id"]
+				}
+				updated["giver_name"] = giver["name"]
+			}
+
+			records = append(records, updated)
+			changed = true
+		}
+
+		if changed {
+			change.Set[field] = records
+		}
+	}
+
+	return change, nil
+}
+
+// emptyListFieldsFor sets the given fields to empty arrays where they are null
+// or missing. Each migration names its own fields so adding a list field later
+// doesn't change what an already applied migration does.
+func emptyListFieldsFor(fields ...string) func(doc bson.M) (*stores.Change, error) {
+	return func(doc bson.M) (*stores.Change, error) {
+		change := &stores.Change{Set: bson.M{}}
+
+		for _, field := range fields {
+			if value, ok := doc[field]; !ok || value == nil {
+				change.Set[field] = bson.A{}
+			}
+		}
+
+		return change, nil
+	}
+}
+
+// rankNames replaces the ranks stored as numbers, on the member and in their
+// history, with the rank's name
+func rankNames(doc bson.M) (*stores.Change, error) {
+	change := &stores.Change{Set: bson.M{}}
+
+	if name, ok := legacyRankName(doc["rank"]); ok {
+		change.Set["rank"] = name
+	}
+
+	history, ok := toList(doc["history"])
+	if !ok {
+		return change, nil
+	}
+
+	records := bson.A{}
+	changed := false
+	for _, item := range history {
+		record, ok := toMap(item)
+		if !ok {
+			records = append(records, item)
+			continue
+		}
+
+		name, ok := legacyRankName(record["rank"])
+		if !ok {
+			records = append(records, record)
+			continue
+		}
+
+		updated := bson.M{}
+		for k, v := range record {
+			updated[k] = v
+		}
+		updated["rank"] = name
+		records = append(records, updated)
+		changed = true
+	}
+
+	if changed {
+		change.Set["history"] = records
+	}
+
+	return change, nil
+}
+
+// legacyRankName is the name of a rank stored as a number, nil for no rank.
+// ok is false if the rank isn't a number.
+func legacyRankName(v any) (interface{}, bool) {
+	var n int
+	switch number := v.(type) {
+	case int32:
+		n = int(number)
+	case int64:
+		n = int(number)
+	case float64:
+		n = int(number)
+	default:
+		return nil, false
+	}
+
+	if name := ranks.LegacyName(n); name != "" {
+		return name, true
+	}
+	return nil, true
+}
+
+func toList(v any) ([]interface{}, bool) {
+	switch l := v.(type) {
+	case bson.A:
+		return l, true
+	case []interface{}:
+		return l, true
+	}
+	return nil, false
+}
+
+func toMap(v any) (map[string]interface{}, bool) {
+	switch m := v.(type) {
+	case bson.M:
+		return m, true
+	case map[string]interface{}:
+		return m, true
+	case bson.D:
+		r := map[string]interface{}{}
+		for _, e := range m {
+			r[e.Key] = e.Value
+		}
+		return r, true
+	}
+	return nil, false
+}
+
+func isZero(v any) bool {
+	switch n := v.(type) {
+	case nil:
+		return true
+	case int32:
+		return n == 0
+	case int64:
+		return n == 0
+	case float64:
+		return n == 0
+	}
+	return false
+}
+
+func isEmptyList(v any) bool {
+	switch l := v.(type) {
+	case nil:
+		return true
+	case bson.A:
+		return len(l) == 0
+	case []interface{}:
+		return len(l) == 0
+	}
+	return false
+}
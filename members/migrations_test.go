@@ -0,0 +1,125 @@
+package members
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sol-armada/sol-bot/ranks"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestMigrateRankNames(t *testing.T) {
+	ctx := context.Background()
+	c := setupMemory(t)
+
+	// members saved when ranks were stored by their place on the ladder
+	store, _ := c.GetMembersStore()
+	if err := store.Upsert(ctx, "1", 0, bson.M{
+		"_id":  "1",
+		"name": "one",
+		"rank": int64(5),
+		"history": bson.A{
+			bson.M{"rank": int64(7), "cause": "role"},
+			bson.M{"rank": int64(0), "cause": "rsi"},
+			bson.M{"rank": int64(5), "cause": "officer"},
+		},
+		"version": int64(1),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := c.Migrate(ctx, false); err != nil {
+		t.Fatal(err)
+	}
+
+	cur, err := store.Get(ctx, "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := bson.M{}
+	if !cur.Next(ctx) {
+		t.Fatal("member is gone")
+	}
+	if err := cur.Decode(&doc); err != nil {
+		t.Fatal(err)
+	}
+
+	if doc["rank"] != "Technician" {
+		t.Errorf("rank is %v, want Technician", doc["rank"])
+	}
+	want := []interface{}{"Recruit", nil, "Technician"}
+	history := doc["history"].(bson.A)
+	for i, item := range history {
+		if rank := item.(bson.M)["rank"]; rank != want[i] {
+			t.Errorf("history %d rank is %v, want %v", i, rank, want[i])
+		}
+	}
+
+	member, err := Get(ctx, "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if member.Rank != ranks.Technician || member.History[0].Rank != ranks.Recruit {
+		t.Errorf("got rank %v and first history rank %v", member.Rank, member.History[0].Rank)
+	}
+}
+
+func TestEmptyListFieldsFor(t *testing.T) {
+	tests := []struct {
+		name   string
+		fields []string
+		doc    bson.M
+		want   []string
+	}{
+		{"merits and demerits missing", []string{"merits", "demerits"}, bson.M{}, []string{"merits", "demerits"}},
+		{"merits and demerits leave escalations alone", []string{"merits", "demerits"}, bson.M{"merits": bson.A{}, "demerits": nil}, []string{"demerits"}},
+		{"escalations missing", []string{"escalations"}, bson.M{"merits": nil}, []string{"escalations"}},
+		{"escalations already set", []string{"escalations"}, bson.M{"escalations": bson.A{}}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			change, err := emptyListFieldsFor(tt.fields...)(tt.doc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(change.Set) != len(tt.want) {
+				t.Fatalf("set %v, want %v", change.Set, tt.want)
+			}
+			for _, field := range tt.want {
+				if _, ok := change.Set[field].(bson.A); !ok {
+					t.Errorf("%s is %v, want an empty array", field, change.Set[field])
+				}
+			}
+		})
+	}
+}
+
+func TestGetRandomByRank(t *testing.T) {
+	ctx := context.Background()
+	setupMemory(t)
+
+	for id, rank := range map[string]ranks.Rank{
+		"admiral":    ranks.Admiral,
+		"technician": ranks.Technician,
+		"recruit":    ranks.Recruit,
+		"none":       ranks.None,
+	} {
+		if err := (&Member{Id: id, Name: id, Rank: rank}).Save(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := GetRandom(ctx, 10, ranks.Technician)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	found := map[string]bool{}
+	for _, member := range got {
+		found[member.Id] = true
+	}
+	if len(found) != 2 || !found["admiral"] || !found["technician"] {
+		t.Errorf("got %v, want the admiral and the technician", found)
+	}
+}
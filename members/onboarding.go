@@ -0,0 +1,141 @@
+package members
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// the onboarding answers that can be flagged for review
+const (
+	ReviewAge      = "age"
+	ReviewPlaytime = "playtime"
+	ReviewGameplay = "gameplay"
+)
+
+// SetOnboardingAnswers keeps the answers as they were typed and fills in the
+// typed fields from them. Any answer that can't be read is added to
+// NeedsReview so an officer can fill it in.
+func (m *Member) SetOnboardingAnswers(age string, playtime string, gameplay string) {
+	m.LegacyAge = age
+	m.LegacyPlaytime = playtime
+	m.LegacyGameplay = gameplay
+	m.NeedsReview = []string{}
+
+	if parsed, ok := ParseAge(age); ok {
+		m.Age = parsed
+	} else {
+		m.NeedsReview = append(m.NeedsReview, ReviewAge)
+	}
+
+	if parsed, ok := ParsePlaytime(playtime); ok {
+		m.Playtime = parsed
+	} else {
+		m.NeedsReview = append(m.NeedsReview, ReviewPlaytime)
+	}
+
+	parsed, unmatched := ParseGameplayAnswer(gameplay)
+	m.Gameplay = parsed
+	if len(parsed) == 0 || len(unmatched) > 0 {
+		m.NeedsReview = append(m.NeedsReview, ReviewGameplay)
+	}
+}
+
+// UnmatchedGameplay is the part of the gameplay answer that couldn't be matched
+// to a type
+func (m *Member) UnmatchedGameplay() string {
+	_, unmatched := ParseGameplayAnswer(m.LegacyGameplay)
+	return strings.Join(unmatched, ", ")
+}
+
+// FormatPlaytime describes a playtime in months for people to read
+func FormatPlaytime(months int) string {
+	switch {
+	case months == 0:
+		return "just started"
+	case months < 12:
+		return fmt.Sprintf("%d months", months)
+	case months%12 == 0:
+		return fmt.Sprintf("%d months (%d years)", months, months/12)
+	}
+	return fmt.Sprintf("%d months (%.1f years)", months, float64(months)/12)
+}
+
+// FormatGameplay lists the gameplay types for people to read
+func FormatGameplay(gameplay []GameplayType) string {
+	if len(gameplay) == 0 {
+		return "none"
+	}
+
+	names := make([]string, 0, len(gameplay))
+	for _, g := range gameplay {
+		names = append(names, g.String())
+	}
+	return strings.Join(names, ", ")
+}
+
+// ListNeedsReview returns the members with onboarding answers waiting on an
+// officer
+func ListNeedsReview(ctx context.Context, page int) ([]Member, error) {
+	return list(ctx, bson.D{
+		{Key: "archived_at", Value: nil},
+		{Key: "needs_review.0", Value: bson.D{{Key: "$exists", Value: true}}},
+	}, page, 100)
+}
+
+// RosterFilter narrows down the roster by the onboarding answers. Zero values
+// are ignored.
+type RosterFilter struct {
+	Gameplay    GameplayType
+	MinPlaytime int // months
+	MaxPlaytime int // months
+	MinAge      int
+	MaxAge      int
+	NeedsReview bool
+}
+
+func (f RosterFilter) query() bson.D {
+	filter := bson.D{
+		{Key: "is_bot", Value: bson.D{{Key: "$eq", Value: false}}},
+		{Key: "archived_at", Value: nil},
+	}
+
+	if f.Gameplay != "" {
+		filter = append(filter, bson.E{Key: "gameplay", Value: f.Gameplay})
+	}
+
+	if r := between(f.MinPlaytime, f.MaxPlaytime); r != nil {
+		filter = append(filter, bson.E{Key: "playtime", Value: r})
+	}
+
+	if r := between(f.MinAge, f.MaxAge); r != nil {
+		filter = append(filter, bson.E{Key: "age", Value: r})
+	}
+
+	if f.NeedsReview {
+		filter = append(filter, bson.E{Key: "needs_review.0", Value: bson.D{{Key: "$exists", Value: true}}})
+	}
+
+	return filter
+}
+
+func between(low int, high int) bson.D {
+	r := bson.D{}
+	if low > 0 {
+		r = append(r, bson.E{Key: "$gte", Value: low})
+	}
+	if high > 0 {
+		r = append(r, bson.E{Key: "$lte", Value: high})
+	}
+	if len(r) == 0 {
+		return nil
+	}
+	return r
+}
+
+// Roster returns a page of the members matching the filter
+func Roster(ctx context.Context, filter RosterFilter, page int, size int) ([]Member, error) {
+	return list(ctx, filter.query(), page, size)
+}
@@ -0,0 +1,320 @@
+package members
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ageReg      = regexp.MustCompile(`\d+`)
+	playtimeReg = regexp.MustCompile(`(\d+(?:\.\d+)?)\s*(years?|yrs?|y|months?|mos?|weeks?|wks?|w)\b`)
+	sinceReg    = regexp.MustCompile(`(?:since|from|started(?:\s+in)?|starting(?:\s+in)?|back\s+in|in)\s+(?:(jan|feb|mar|apr|may|jun|jul|aug|sep|oct|nov|dec)[a-z]*\.?\s+)?((?:19|20)\d\d)\b`)
+	yearReg     = regexp.MustCompile(`^\s*((?:19|20)\d\d)\s*$`)
+	gameplayReg = regexp.MustCompile(`\s*(?:,|/|&|;|\+|\n|\band\b)\s*`)
+)
+
+// now is swapped out when the current time matters, like working out how long
+// someone has played since a year
+var now = time.Now
+
+// words people write instead of digits
+var numberWords = map[string]string{
+	"a": "1", "an": "1", "one": "1", "two": "2", "three": "3", "four": "4",
+	"five": "5", "six": "6", "seven": "7", "eight": "8", "nine": "9",
+	"ten": "10", "eleven": "11", "twelve": "12", "couple": "2",
+	"a couple": "2", "a couple of": "2", "couple of": "2", "few": "3",
+	"a few": "3", "several": "4", "half a": "0.5", "a half": "0.5",
+}
+
+var numberWordsReg = regexp.MustCompile(`\b(a couple of|couple of|a couple|a few|half a|a half|a|an|one|two|three|four|five|six|seven|eight|nine|ten|eleven|twelve|couple|few|several)\s+(years?|yrs?|months?|mos?|weeks?|wks?)\b`)
+
+// answers that mean they are just starting out
+var newPlayerReg = regexp.MustCompile(`\b(new|just (?:started|bought|got|began)|brand new|never|none|not yet|first (?:day|week)|today|this week)\b`)
+
+var months = map[string]time.Month{
+	"jan": time.January, "feb": time.February, "mar": time.March, "apr": time.April,
+	"may": time.May, "jun": time.June, "jul": time.July, "aug": time.August,
+	"sep": time.September, "oct": time.October, "nov": time.November, "dec": time.December,
+}
+
+// ParseAge reads an age out of an onboarding answer like "25", "25 years old"
+// or "born in 1995"
+func ParseAge(answer string) (int, bool) {
+	match := ageReg.FindString(answer)
+	if match == "" {
+		return 0, false
+	}
+
+	age, err := strconv.Atoi(match)
+	if err != nil {
+		return 0, false
+	}
+
+	// a birth year
+	if age >= 1900 && age <= now().Year() {
+		age = now().Year() - age
+	}
+
+	if age < 1 || age > 120 {
+		return 0, false
+	}
+
+	return age, true
+}
+
+// ParsePlaytime reads how long someone has played out of an onboarding answer
+// like "2 years", "1 year 6 months", "a couple of months" or "since 2018" and
+// returns it in months
+func ParsePlaytime(answer string) (int, bool) {
+	answer = strings.ToLower(answer)
+
+	// since a year, optionally with a month
+	if match := sinceReg.FindStringSubmatch(answer); match != nil {
+		return monthsSince(match[1], match[2])
+	}
+	if match := yearReg.FindStringSubmatch(answer); match != nil {
+		return monthsSince("", match[1])
+	}
+
+	answer = numberWordsReg.ReplaceAllStringFunc(answer, func(s string) string {
+		match := numberWordsReg.FindStringSubmatch(s)
+		return numberWords[match[1]] + " " + match[2]
+	})
+
+	matches := playtimeReg.FindAllStringSubmatch(answer, -1)
+	if len(matches) == 0 {
+		if newPlayerReg.MatchString(answer) {
+			return 0, true
+		}
+		return 0, false
+	}
+
+	total := 0.0
+	for _, match := range matches {
+		amount, err := strconv.ParseFloat(match[1], 64)
+		if err != nil {
+			return 0, false
+		}
+
+		switch match[2][0] {
+		case 'y':
+			total += amount * 12
+		case 'm':
+			total += amount
+		case 'w':
+			total += amount / 4
+		}
+	}
+
+	return int(total + 0.5), true
+}
+
+// monthsSince is how many months it has been since the start of the month, or
+// the start of the year if no month was given
+func monthsSince(month string, year string) (int, bool) {
+	y, err := strconv.Atoi(year)
+	if err != nil {
+		return 0, false
+	}
+
+	m := time.January
+	if month != "" {
+		m = months[month[:3]]
+	}
+
+	current := now()
+	if y > current.Year() || (y == current.Year() && m > current.Month()) {
+		return 0, false
+	}
+
+	return (current.Year()-y)*12 + int(current.Month()-m), true
+}
+
+// gameplaySynonyms are the other ways people describe each type of gameplay
+var gameplaySynonyms = map[string]GameplayType{
+	"bounty":            BountyHunting,
+	"bounty hunting":    BountyHunting,
+	"bounty hunter":     BountyHunting,
+	"bounties":          BountyHunting,
+	"mercenary":         BountyHunting,
+	"merc":              BountyHunting,
+	"engineering":       Engineering,
+	"engineer":          Engineering,
+	"repair":            Engineering,
+	"repairs":           Engineering,
+	"exploration":       Exporation,
+	"exporation":        Exporation,
+	"exploring":         Exporation,
+	"explore":           Exporation,
+	"explorer":          Exporation,
+	"pathfinding":       Exporation,
+	"fps":               FpsCombat,
+	"fps combat":        FpsCombat,
+	"ground combat":     FpsCombat,
+	"infantry":          FpsCombat,
+	"boots on ground":   FpsCombat,
+	"hauling":           Hauling,
+	"haul":              Hauling,
+	"cargo":             Hauling,
+	"cargo hauling":     Hauling,
+	"freight":           Hauling,
+	"trucking":          Hauling,
+	"logistics":         Hauling,
+	"medical":           Medical,
+	"medic":             Medical,
+	"rescue":            Medical,
+	"search and rescue": Medical,
+	"sar":               Medical,
+	"healing":           Medical,
+	"mining":            Mining,
+	"mine":              Mining,
+	"miner":             Mining,
+	"ship mining":       Mining,
+	"hand mining":       Mining,
+	"ground mining":     Mining,
+	"reconnaissance":    Reconnaissance,
+	"recon":             Reconnaissance,
+	"scouting":          Reconnaissance,
+	"scout":             Reconnaissance,
+	"intel":             Reconnaissance,
+	"racing":            Racing,
+	"race":              Racing,
+	"races":             Racing,
+	"scrapping":         Scrapping,
+	"salvage":           Scrapping,
+	"salvaging":         Scrapping,
+	"scrap":             Scrapping,
+	"ship crew":         ShipCrew,
+	"crew":              ShipCrew,
+	"multicrew":         ShipCrew,
+	"multi crew":        ShipCrew,
+	"gunner":            ShipCrew,
+	"gunnery":           ShipCrew,
+	"turret":            ShipCrew,
+	"turrets":           ShipCrew,
+	"ship combat":       ShipCombat,
+	"combat":            ShipCombat,
+	"space combat":      ShipCombat,
+	"dogfighting":       ShipCombat,
+	"dog fighting":      ShipCombat,
+	"pvp":               ShipCombat,
+	"pve":               ShipCombat,
+	"fighter":           ShipCombat,
+	"fighting":          ShipCombat,
+	"trading":           Trading,
+	"trade":             Trading,
+	"trader":            Trading,
+	"merchant":          Trading,
+	"commerce":          Trading,
+}
+
+// phrases that would otherwise be split apart on "and" or "&"
+var gameplayPhrases = strings.NewReplacer(
+	"search and rescue", "sar",
+	"search & rescue", "sar",
+	"s&r", "sar",
+)
+
+// ParseGameplay reads the gameplay types out of an onboarding answer like
+// "Mining, Hauling and Ship Combat". Answers that don't match a known type are
+// left out.
+func ParseGameplay(answer string) []GameplayType {
+	gameplay, _ := ParseGameplayAnswer(answer)
+	return gameplay
+}
+
+// ParseGameplayAnswer reads the gameplay types out of an onboarding answer,
+// also returning the parts of the answer it couldn't match to a type. Parts are
+// matched exactly, then by synonym, then by the closest synonym to allow for
+// typos.
+func ParseGameplayAnswer(answer string) ([]GameplayType, []string) {
+	gameplay := []GameplayType{}
+	unmatched := []string{}
+
+	answer = gameplayPhrases.Replace(strings.ToLower(answer))
+	for _, part := range gameplayReg.Split(answer, -1) {
+		part = strings.Join(strings.Fields(strings.Trim(part, ".!?-_ ")), " ")
+		if part == "" {
+			continue
+		}
+
+		g := matchGameplay(part)
+		if g == Unknown {
+			unmatched = append(unmatched, part)
+			continue
+		}
+		if containsGameplay(gameplay, g) {
+			continue
+		}
+		gameplay = append(gameplay, g)
+	}
+
+	return gameplay, unmatched
+}
+
+func matchGameplay(part string) GameplayType {
+	if g := ToGameplayType(strings.ReplaceAll(part, " ", "_")); g != Unknown {
+		return g
+	}
+
+	if g, ok := gameplaySynonyms[part]; ok {
+		return g
+	}
+
+	// allow a typo or two, more for longer words
+	allowed := 1
+	if len(part) > 7 {
+		allowed = 2
+	}
+	if len(part) < 4 {
+		return Unknown
+	}
+
+	best := Unknown
+	bestDistance := allowed + 1
+	for synonym, g := range gameplaySynonyms {
+		if d := levenshtein(part, synonym); d < bestDistance || (d == bestDistance && g < best) {
+			best, bestDistance = g, d
+		}
+	}
+	if bestDistance > allowed {
+		return Unknown
+	}
+
+	return best
+}
+
+// levenshtein is the number of single character edits between a and b
+func levenshtein(a, b string) int {
+	ar, br := []rune(a), []rune(b)
+	prev := make([]int, len(br)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ar); i++ {
+		current := make([]int, len(br)+1)
+		current[0] = i
+		for j := 1; j <= len(br); j++ {
+			cost := 1
+			if ar[i-1] == br[j-1] {
+				cost = 0
+			}
+			current[j] = min(prev[j]+1, current[j-1]+1, prev[j-1]+cost)
+		}
+		prev = current
+	}
+
+	return prev[len(br)]
+}
+
+func containsGameplay(gameplay []GameplayType, g GameplayType) bool {
+	for _, existing := range gameplay {
+		if existing == g {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,116 @@
+package members
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+// fixNow pins the current time the parser uses for the test
+func fixNow(t *testing.T) {
+	t.Helper()
+
+	now = func() time.Time { return time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC) }
+	t.Cleanup(func() { now = time.Now })
+}
+
+func TestParseAge(t *testing.T) {
+	fixNow(t)
+
+	tests := []struct {
+		answer string
+		want   int
+		ok     bool
+	}{
+		{"25", 25, true},
+		{"25 years old", 25, true},
+		{"I'm 31", 31, true},
+		{"born in 1995", 29, true},
+		{"1995", 29, true},
+		{"old enough", 0, false},
+		{"", 0, false},
+		{"0", 0, false},
+		{"150", 0, false},
+		{"born in 2030", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.answer, func(t *testing.T) {
+			got, ok := ParseAge(tt.answer)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("got %d %v, want %d %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestParsePlaytime(t *testing.T) {
+	fixNow(t)
+
+	tests := []struct {
+		answer string
+		want   int
+		ok     bool
+	}{
+		{"2 years", 24, true},
+		{"1 year 6 months", 18, true},
+		{"1.5 yrs", 18, true},
+		{"3 weeks", 1, true},
+		{"a couple of months", 2, true},
+		{"a few months", 3, true},
+		{"half a year", 6, true},
+		{"two years", 24, true},
+		{"since 2018", 77, true},
+		{"Since March 2023", 15, true},
+		{"started in dec 2023", 6, true},
+		{"2020", 53, true},
+		{"just started", 0, true},
+		{"brand new", 0, true},
+		{"since 2025", 0, false},
+		{"since june 2024", 0, true},
+		{"since july 2024", 0, false},
+		{"a while", 0, false},
+		{"", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.answer, func(t *testing.T) {
+			got, ok := ParsePlaytime(tt.answer)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("got %d %v, want %d %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestParseGameplayAnswer(t *testing.T) {
+	tests := []struct {
+		answer    string
+		want      []GameplayType
+		unmatched []string
+	}{
+		{"Mining, Hauling and Ship Combat", []GameplayType{Mining, Hauling, ShipCombat}, []string{}},
+		{"search and rescue", []GameplayType{Medical}, []string{}},
+		{"Search & Rescue / bounty hunting", []GameplayType{Medical, BountyHunting}, []string{}},
+		{"pvp + salvage", []GameplayType{ShipCombat, Scrapping}, []string{}},
+		{"mining, miner & mining", []GameplayType{Mining}, []string{}},
+		{"minning", []GameplayType{Mining}, []string{}},
+		{"exploraton", []GameplayType{Exporation}, []string{}},
+		{"Trading!", []GameplayType{Trading}, []string{}},
+		{"basket weaving", []GameplayType{}, []string{"basket weaving"}},
+		{"mining, xyz", []GameplayType{Mining}, []string{"xyz"}},
+		{"", []GameplayType{}, []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.answer, func(t *testing.T) {
+			got, unmatched := ParseGameplayAnswer(tt.answer)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+			if !slices.Equal(unmatched, tt.unmatched) {
+				t.Errorf("unmatched %q, want %q", unmatched, tt.unmatched)
+			}
+		})
+	}
+}
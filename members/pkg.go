@@ -15,8 +15,10 @@ import (
 	"github.com/pkg/errors"
 	"github.com/sol-armada/sol-bot/auth"
 	"github.com/sol-armada/sol-bot/ranks"
+	"github.com/sol-armada/sol-bot/settings"
 	"github.com/sol-armada/sol-bot/stores"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type Member struct {
@@ -27,13 +29,14 @@ type Member struct {
 	PrimaryOrg     string     `json:"primary_org" bson:"primary_org"`
 	RSIMember      bool       `json:"rsi_member" bson:"rsi_member"`
 	BadAffiliation bool       `json:"bad_affiliation" bson:"bad_affiliation"`
-	Affilations    []string   `json:"affiliations" bson:"affilations"`
+	Affiliations   []string   `json:"affiliations" bson:"affiliations"`
 	Avatar         string     `json:"avatar" bson:"avatar"`
 	Updated        time.Time  `json:"updated" bson:"updated"`
 	Validated      bool       `json:"validated" bson:"validated"`
 	ValidationCode string     `json:"validation_code" bson:"validation_code"`
 	Joined         time.Time  `json:"joined" bson:"joined"`
 	Suffix         string     `json:"suffix" bson:"suffix"`
+	Version        int64      `json:"version" bson:"version"`
 
 	IsBot       bool `json:"is_bot" bson:"is_bot"`
 	IsAlly      bool `json:"is_ally" bson:"is_ally"`
@@ -42,24 +45,33 @@ type Member struct {
 
 	Merits   []*Merit   `json:"merits" bson:"merits"`
 	Demerits []*Demerit `json:"demerits" bson:"demerits"`
+	// Escalations are the demerit rules that have been applied to the member
+	Escalations []*Escalation `json:"escalations" bson:"escalations"`
+
+	// History is every change to the member's rank and status, oldest first
+	History []*StatusChange `json:"history" bson:"history"`
 
 	// onboarding info
 	OnboardedAt *time.Time     `json:"onboarded_at" bson:"onboarded_at"`
 	Age         int            `json:"age" bson:"age"`
 	Pronouns    string         `json:"pronouns" bson:"pronouns"`
 	Playtime    int            `json:"playtime" bson:"playtime"`
-	Gameplay    []GameplayType `json:"gamplay" bson:"gameplay"`
+	Gameplay    []GameplayType `json:"gameplay" bson:"gameplay"`
 	Recruiter   *Member        `json:"recruiter" bson:"recruiter"`
 	ChannelId   string         `json:"channel_id" bson:"channel_id"`
 	MessageId   string         `json:"message_id" bson:"message_id"`
 	LeftAt      *time.Time     `json:"left_at" bson:"left_at"`
+	ArchivedAt  *time.Time     `json:"archived_at" bson:"archived_at"`
 	FoundBy     string         `json:"found_by" bson:"found_by"`
 	TimeZone    string         `json:"time_zone" bson:"time_zone"`
 	Other       string         `json:"other" bson:"other"`
+	// NeedsReview lists the onboarding answers that couldn't be read and need
+	// an officer to fill in
+	NeedsReview []string `json:"needs_review" bson:"needs_review"`
 
 	LegacyAge       string `json:"legacy_age" bson:"legacy_age"`
 	LegacyPlaytime  string `json:"legacy_playtime" bson:"legacy_playtime"`
-	LegacyGamplay   string `json:"legacy_gamplay" bson:"legacy_gamplay"`
+	LegacyGameplay  string `json:"legacy_gameplay" bson:"legacy_gameplay"`
 	LegacyRecruiter string `json:"legacy_recruiter" bson:"legacy_recruiter"`
 	LegacyOther     string `json:"legacy_other" bson:"legacy_other"`
 }
@@ -69,25 +81,23 @@ type AttendedEvent struct {
 	Name string `json:"name" bson:"name"`
 }
 
-type Merit struct {
-	Giver  Member    `json:"giver" bson:"giver"`
-	Reason string    `json:"reason" bson:"reason"`
-	When   time.Time `json:"when" bson:"when"`
-}
-
-type Demerit struct {
-	Giver  Member    `json:"giver" bson:"giver"`
-	Reason string    `json:"reason" bson:"reason"`
-	When   time.Time `json:"when" bson:"when"`
-}
-
 type MemberError error
 
 var (
 	MemberNotFound MemberError = errors.New("member not found")
+	MemberConflict MemberError = errors.New("member was changed since it was read")
 )
 
-var membersStore *stores.MembersStore
+// how many times Update will reload and retry a member after a conflict
+const maxUpdateAttempts = 5
+
+// listFields are pushed onto by field level updates, which mongo refuses to do
+// when they are null, so they are always stored as arrays
+var listFields = []string{"merits", "demerits", "escalations"}
+
+var membersStore stores.MembersStore
+
+var cache *memberCache
 
 func Setup() error {
 	storesClient := stores.Get()
@@ -97,9 +107,25 @@ func Setup() error {
 	}
 	membersStore = ms
 
+	cache = newMemberCache(
+		settings.GetIntWithDefault("MEMBERS.CACHE.SIZE", 1000),
+		settings.GetDurationWithDefault("MEMBERS.CACHE.TTL", 5*time.Minute),
+	)
+
 	return nil
 }
 
+// Invalidate drops the member from the cache so the next Get reads them from
+// the store
+func Invalidate(id string) {
+	cache.invalidate(id)
+}
+
+// CacheReport returns the member cache counters
+func CacheReport() CacheStats {
+	return cache.snapshot()
+}
+
 func New(discordMember *discordgo.Member) *Member {
 	m := &Member{
 		Id:      discordMember.User.ID,
@@ -113,30 +139,78 @@ func New(discordMember *discordgo.Member) *Member {
 	return m
 }
 
-func Get(id string) (*Member, error) {
+func Get(ctx context.Context, id string) (*Member, error) {
+	if member, ok := cache.get(id); ok {
+		return member, nil
+	}
+
 	member := &Member{}
 
 	// check the store
-	cur, err := membersStore.Get(id)
+	cur, err := membersStore.Get(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 
-	if cur.Next(context.Background()) {
+	if cur.Next(ctx) {
 		if err := cur.Decode(member); err != nil {
 			return nil, err
 		}
 	}
+	if err := cur.Err(); err != nil {
+		return nil, err
+	}
 
 	if member.Id == "" {
 		return nil, MemberNotFound
 	}
 
+	cache.put(member)
+
 	return member, nil
 }
 
-func GetRandom(max int, maxRank ranks.Rank) ([]Member, error) {
-	membersMap, err := membersStore.GetRandom(max, int(maxRank))
+// Update loads the member, applies fn and saves it. If someone else saved the
+// member in between it reloads and tries again, so fn may be called more than
+// once and should only change the member it is given.
+func Update(ctx context.Context, id string, fn func(m *Member) error) (*Member, error) {
+	for attempt := 1; attempt <= maxUpdateAttempts; attempt++ {
+		member, err := Get(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := fn(member); err != nil {
+			return nil, err
+		}
+
+		if err := member.Save(ctx); err != nil {
+			if errors.Is(err, MemberConflict) {
+				log.WithFields(log.Fields{
+					"id":      id,
+					"attempt": attempt,
+				}).Debug("member changed while updating, retrying")
+				continue
+			}
+			return nil, err
+		}
+
+		return member, nil
+	}
+
+	return nil, MemberConflict
+}
+
+// GetRandom picks up to max members that have maxRank or one above it
+func GetRandom(ctx context.Context, max int, maxRank ranks.Rank) ([]Member, error) {
+	rankNames := []string{}
+	for _, rank := range ranks.All() {
+		if rank <= maxRank {
+			rankNames = append(rankNames, rank.String())
+		}
+	}
+
+	membersMap, err := membersStore.GetRandom(ctx, max, rankNames)
 	if err != nil {
 		return nil, err
 	}
@@ -144,9 +218,13 @@ func GetRandom(max int, maxRank ranks.Rank) ([]Member, error) {
 	members := []Member{}
 
 	for _, memberMap := range membersMap {
-		j, _ := json.Marshal(memberMap)
+		// the documents are as stored, so they decode like one
+		b, err := bson.Marshal(memberMap)
+		if err != nil {
+			return nil, err
+		}
 		member := &Member{}
-		if err := json.Unmarshal(j, member); err != nil {
+		if err := bson.Unmarshal(b, member); err != nil {
 			return nil, err
 		}
 		members = append(members, *member)
@@ -155,21 +233,40 @@ func GetRandom(max int, maxRank ranks.Rank) ([]Member, error) {
 	return members, nil
 }
 
-func List(page int) ([]Member, error) {
-	cur, err := membersStore.List(bson.D{{Key: "is_bot", Value: bson.D{{Key: "$eq", Value: false}}}}, page, 100)
+// List returns the members that haven't been archived
+func List(ctx context.Context, page int) ([]Member, error) {
+	return list(ctx, bson.D{
+		{Key: "is_bot", Value: bson.D{{Key: "$eq", Value: false}}},
+		{Key: "archived_at", Value: nil},
+	}, page, 100)
+}
+
+// ListArchived returns the members that have left and been archived
+func ListArchived(ctx context.Context, page int) ([]Member, error) {
+	return list(ctx, bson.D{
+		{Key: "is_bot", Value: bson.D{{Key: "$eq", Value: false}}},
+		{Key: "archived_at", Value: bson.D{{Key: "$ne", Value: nil}}},
+	}, page, 100)
+}
+
+func list(ctx context.Context, filter interface{}, page int, size int) ([]Member, error) {
+	cur, err := membersStore.List(ctx, filter, page, size)
 	if err != nil {
 		return nil, err
 	}
 
 	members := []Member{}
 
-	for cur.Next(context.Background()) {
+	for cur.Next(ctx) {
 		member := Member{}
 		if err := cur.Decode(&member); err != nil {
 			return nil, err
 		}
 		members = append(members, member)
 	}
+	if err := cur.Err(); err != nil {
+		return nil, err
+	}
 
 	return members, nil
 }
@@ -196,7 +293,11 @@ func (m *Member) GetTrueNick(discordMember *discordgo.Member) string {
 	return trueNick
 }
 
-func (m *Member) Save() error {
+// Save replaces the whole stored member. If the member was changed since it was
+// read MemberConflict is returned and nothing is written.
+func (m *Member) Save(ctx context.Context) error {
+	defer Invalidate(m.Id)
+
 	m.Updated = time.Now().UTC()
 
 	memberMap := m.ToMap()
@@ -204,24 +305,113 @@ func (m *Member) Save() error {
 	memberMap["_id"] = memberMap["id"]
 	delete(memberMap, "id")
 
+	for _, field := range listFields {
+		if memberMap[field] == nil {
+			memberMap[field] = []interface{}{}
+		}
+	}
+
+	// only the recruiter's id is stored
 	if recruiter, ok := memberMap["recruiter"].(map[string]interface{}); ok {
 		memberMap["recruiter"] = recruiter["id"]
+		if recruiter["id"] == "" {
+			memberMap["recruiter"] = nil
+		}
 	}
 
-	return membersStore.Upsert(m.Id, memberMap)
+	memberMap["version"] = m.Version + 1
+
+	if err := membersStore.Upsert(ctx, m.Id, m.Version, memberMap); err != nil {
+		if errors.Is(err, stores.ErrVersionConflict) {
+			return MemberConflict
+		}
+		return err
+	}
+
+	m.Version++
+
+	return nil
+}
+
+// update makes a field level change to the stored member, so it never
+// overwrites changes made by someone else
+func (m *Member) update(ctx context.Context, change *stores.Change) error {
+	defer Invalidate(m.Id)
+
+	m.Updated = time.Now().UTC()
+	if change.Set == nil {
+		change.Set = bson.M{}
+	}
+	change.Set["updated"] = m.Updated
+
+	version, err := membersStore.Update(ctx, m.Id, change)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return MemberNotFound
+		}
+		return err
+	}
+
+	m.Version = version
+
+	return nil
+}
+
+// SetRSIInfo saves only the fields that come from the member's RSI profile
+func (m *Member) SetRSIInfo(ctx context.Context) error {
+	return m.update(ctx, &stores.Change{Set: bson.M{
+		"rank":            m.Rank,
+		"primary_org":     m.PrimaryOrg,
+		"rsi_member":      m.RSIMember,
+		"bad_affiliation": m.BadAffiliation,
+		"affiliations":    m.Affiliations,
+		"is_ally":         m.IsAlly,
+		"is_affiliate":    m.IsAffiliate,
+		"is_guest":        m.IsGuest,
+		"history":         m.History,
+	}})
+}
+
+// SetDiscordInfo saves only the fields that come from the member's Discord
+// account
+func (m *Member) SetDiscordInfo(ctx context.Context) error {
+	return m.update(ctx, &stores.Change{Set: bson.M{
+		"name":   m.Name,
+		"avatar": m.Avatar,
+		"joined": m.Joined,
+		"suffix": m.Suffix,
+		"is_bot": m.IsBot,
+	}})
+}
+
+// SetValidationCode saves the code the member needs to put in their RSI bio
+func (m *Member) SetValidationCode(ctx context.Context, code string) error {
+	m.ValidationCode = code
+	return m.update(ctx, &stores.Change{Set: bson.M{"validation_code": code}})
+}
+
+// SetValidated saves if the member's RSI profile is validated, clearing the
+// validation code
+func (m *Member) SetValidated(ctx context.Context, validated bool) error {
+	m.Validated = validated
+	m.ValidationCode = ""
+	return m.update(ctx, &stores.Change{Set: bson.M{
+		"validated":       validated,
+		"validation_code": "",
+	}})
 }
 
 func (m *Member) IsAdmin() bool {
 	logger := log.WithField("id", m.Id)
 	logger.Debug("checking if admin")
-	if m.Rank <= ranks.Lieutenant {
+	if m.Rank.IsOfficer() {
 		return true
 	}
 	logger.Debug("is NOT admin")
 	return false
 }
 
-func (m *Member) Login(code string) error {
+func (m *Member) Login(ctx context.Context, code string) error {
 	log.WithField("access code", code).Debug("logging in")
 	access, err := auth.Authenticate(code)
 	if err != nil {
@@ -259,25 +449,59 @@ func (m *Member) Login(code string) error {
 		return err
 	}
 
-	cur, err := membersStore.Get(discordUser.User.ID)
+	cur, err := membersStore.Get(ctx, discordUser.User.ID)
 	if err != nil {
 		return errors.Wrap(err, "getting stored member")
 	}
 
+	if !cur.Next(ctx) {
+		if err := cur.Err(); err != nil {
+			return errors.Wrap(err, "getting stored member")
+		}
+		return MemberNotFound
+	}
+
 	if err := cur.Decode(m); err != nil {
 		return err
 	}
 
 	m.Avatar = discordUser.Avatar
-	_ = m.Save()
+	_ = m.update(ctx, &stores.Change{Set: bson.M{"avatar": m.Avatar}})
 
 	return nil
 }
 
-func (m *Member) Delete() error {
+// Archive keeps the member and all of their history but hides them from List.
+// Members are archived instead of deleted when they leave so their record can
+// be restored if they come back.
+func (m *Member) Archive(ctx context.Context) error {
+	now := time.Now().UTC()
+	m.ArchivedAt = &now
+	return m.update(ctx, &stores.Change{Set: bson.M{"archived_at": now}})
+}
+
+// Restore brings an archived member back, keeping everything they had before
+// they left
+func (m *Member) Restore(ctx context.Context, joined time.Time) error {
+	m.ArchivedAt = nil
+	m.LeftAt = nil
+	m.Joined = joined
+	return m.update(ctx, &stores.Change{
+		Set:   bson.M{"joined": joined},
+		Unset: []string{"archived_at", "left_at"},
+	})
+}
+
+func (m *Member) IsArchived() bool {
+	return m.ArchivedAt != nil
+}
+
+func (m *Member) Delete(ctx context.Context) error {
 	log.WithField("member", m).Debug("deleting member")
 
-	return membersStore.Delete(m.Id)
+	defer Invalidate(m.Id)
+
+	return membersStore.Delete(ctx, m.Id)
 }
 
 func (m *Member) ToMap() map[string]interface{} {
@@ -287,73 +511,37 @@ func (m *Member) ToMap() map[string]interface{} {
 	return r
 }
 
-func (m *Member) GiveMerit(reason string, who *Member) error {
-	m.Merits = append(m.Merits, &Merit{
-		Giver:  *who,
-		Reason: reason,
-		When:   time.Now().UTC(),
-	})
-
-	return m.Save()
-}
-
-func (m *Member) GiveDemerit(reason string, who *Member) error {
-	m.Demerits = append(m.Demerits, &Demerit{
-		Giver:  *who,
-		Reason: reason,
-		When:   time.Now().UTC(),
-	})
-
-	return m.Save()
-}
-
-func (m *Member) GetOnboardingMessage() *discordgo.Message {
+// GetOnboardingMessage renders the officer channel post for the member from
+// what we know about them. attendanceCount is passed in since attendance is
+// kept outside of the member.
+func (m *Member) GetOnboardingMessage(attendanceCount int) *discordgo.Message {
 	onboarded := "No"
 	if m.OnboardedAt != nil {
 		onboarded = m.OnboardedAt.Format("2006-01-02 15:04:05 -0700 MST")
 	}
 
+	validated := "No"
+	if m.Validated {
+		validated = "Yes"
+	}
+
 	fields := []*discordgo.MessageEmbedField{
-		{
-			Name:  "Onboarded",
-			Value: onboarded,
-		},
+		{Name: "Onboarded", Value: onboarded},
+		{Name: "Rank", Value: m.Rank.String(), Inline: true},
+		{Name: "Validated", Value: validated, Inline: true},
+		{Name: "Attendance", Value: fmt.Sprintf("%d", attendanceCount), Inline: true},
 	}
 
 	if m.OnboardedAt != nil {
 		fields = append(fields, []*discordgo.MessageEmbedField{
-			{
-				Name:  "Age",
-				Value: "Not Set",
-			},
-			{
-				Name:  "RSI Profile",
-				Value: "None",
-			},
-			{
-				Name:  "Primary set Org",
-				Value: "None",
-			},
-			{
-				Name:  "Affiliated Orgs",
-				Value: "None",
-			},
-			{
-				Name:  "How they found us",
-				Value: "A way",
-			},
-			{
-				Name:  "Who Recruited",
-				Value: "No one",
-			},
-			{
-				Name:  "Time Playing Star Citizen",
-				Value: "Many Years",
-			},
-			{
-				Name:  "Interested Gamplay",
-				Value: "Salvage, Bounty Hunting, Mining",
-			},
+			{Name: "Age", Value: m.onboardingAnswer(ReviewAge)},
+			{Name: "RSI Profile", Value: orNotSet(m.Name, "https://robertsspaceindustries.com/citizens/"+m.Name)},
+			{Name: "Primary set Org", Value: orNotSet(m.PrimaryOrg, "https://robertsspaceindustries.com/orgs/"+m.PrimaryOrg)},
+			{Name: "Affiliated Orgs", Value: orNotSet(strings.Join(m.Affiliations, ", "), strings.Join(m.Affiliations, ", "))},
+			{Name: "How they found us", Value: orNotSet(m.foundUs(), m.foundUs())},
+			{Name: "Who Recruited", Value: m.recruitedBy()},
+			{Name: "Time Playing Star Citizen", Value: m.onboardingAnswer(ReviewPlaytime)},
+			{Name: "Interested Gameplay", Value: m.onboardingAnswer(ReviewGameplay)},
 		}...)
 	}
 
@@ -379,6 +567,65 @@ func (m *Member) GetOnboardingMessage() *discordgo.Message {
 	return message
 }
 
+// onboardingAnswer is the typed answer, or what they wrote if it still needs
+// a review
+func (m *Member) onboardingAnswer(answer string) string {
+	reviewing := false
+	for _, review := range m.NeedsReview {
+		if review == answer {
+			reviewing = true
+		}
+	}
+
+	switch answer {
+	case ReviewAge:
+		if reviewing || m.Age == 0 {
+			return orNotSet(m.LegacyAge, m.LegacyAge)
+		}
+		return fmt.Sprintf("%d", m.Age)
+	case ReviewPlaytime:
+		if reviewing || (m.Playtime == 0 && m.LegacyPlaytime == "") {
+			return orNotSet(m.LegacyPlaytime, m.LegacyPlaytime)
+		}
+		return FormatPlaytime(m.Playtime)
+	case ReviewGameplay:
+		if reviewing || len(m.Gameplay) == 0 {
+			return orNotSet(m.LegacyGameplay, m.LegacyGameplay)
+		}
+		return FormatGameplay(m.Gameplay)
+	}
+
+	return "Not set"
+}
+
+func (m *Member) foundUs() string {
+	switch {
+	case m.FoundBy != "":
+		return m.FoundBy
+	case m.Other != "":
+		return m.Other
+	}
+	return m.LegacyOther
+}
+
+func (m *Member) recruitedBy() string {
+	switch {
+	case m.Recruiter != nil && m.Recruiter.Id != "":
+		return "<@" + m.Recruiter.Id + ">"
+	case m.LegacyRecruiter != "":
+		return m.LegacyRecruiter
+	}
+	return "No one"
+}
+
+// orNotSet is value, unless check is empty. Discord won't take an empty field.
+func orNotSet(check string, value string) string {
+	if check == "" {
+		return "Not set"
+	}
+	return value
+}
+
 func (m *Member) IsRanked() bool {
-	return m.Rank <= ranks.Member
+	return m.Rank < ranks.Lowest()
 }
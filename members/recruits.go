@@ -0,0 +1,186 @@
+package members
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sol-armada/sol-bot/ranks"
+	"github.com/sol-armada/sol-bot/stores"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
+)
+
+// a recruiter is stored as the recruiter's id and only becomes a whole member
+// when it is looked up, so anything reading members without the lookup, like
+// the attendance lookups, sees the id instead
+
+// UnmarshalBSONValue reads a member from either a document or an id
+func (m *Member) UnmarshalBSONValue(t bsontype.Type, data []byte) error {
+	switch t {
+	case bsontype.String:
+		id, _, ok := bsoncore.ReadString(data)
+		if !ok {
+			return errors.New("reading member id")
+		}
+		*m = Member{Id: id}
+		return nil
+	case bsontype.Null, bsontype.Undefined:
+		*m = Member{}
+		return nil
+	}
+
+	if t != bsontype.EmbeddedDocument {
+		return errors.Errorf("can't read a member from %s", t)
+	}
+
+	return m.UnmarshalBSON(data)
+}
+
+// UnmarshalBSON reads a member from a document, it is what decoding a member on
+// its own uses
+func (m *Member) UnmarshalBSON(data []byte) error {
+	type plain Member
+	p := plain{}
+	if err := bson.Unmarshal(data, &p); err != nil {
+		return err
+	}
+	*m = Member(p)
+
+	return nil
+}
+
+// UnmarshalJSON reads a member from either an object or an id
+func (m *Member) UnmarshalJSON(data []byte) error {
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte(`"`)) {
+		var id string
+		if err := json.Unmarshal(data, &id); err != nil {
+			return err
+		}
+		*m = Member{Id: id}
+		return nil
+	}
+
+	type plain Member
+	p := plain{}
+	if err := json.Unmarshal(data, &p); err != nil {
+		return err
+	}
+	*m = Member(p)
+
+	return nil
+}
+
+// HasRecruiter is if the member's recruiter has been worked out
+func (m *Member) HasRecruiter() bool {
+	return m.Recruiter != nil && m.Recruiter.Id != ""
+}
+
+// SetRecruiter saves who recruited the member
+func (m *Member) SetRecruiter(ctx context.Context, recruiter *Member) error {
+	m.Recruiter = recruiter
+	return m.update(ctx, &stores.Change{Set: bson.M{"recruiter": recruiter.Id}})
+}
+
+// FindByName finds the members whose name, their RSI handle, matches ignoring
+// case
+func FindByName(ctx context.Context, name string) ([]Member, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return []Member{}, nil
+	}
+
+	return list(ctx, bson.D{
+		{Key: "name", Value: bson.D{
+			{Key: "$regex", Value: "^" + regexp.QuoteMeta(name) + "$"},
+			{Key: "$options", Value: "i"},
+		}},
+	}, 0, 0)
+}
+
+// RecruitStats is how a recruiter's recruits have done
+type RecruitStats struct {
+	Recruiter Member
+	Recruits  []Member
+	RankedUp  int
+	Left      int
+}
+
+// rankedUp is if the recruit has made it past recruit
+func rankedUp(recruit Member) bool {
+	return recruit.Rank != ranks.None && recruit.Rank < ranks.Lowest()
+}
+
+func left(recruit Member) bool {
+	return recruit.LeftAt != nil || recruit.IsArchived()
+}
+
+func newRecruitStats(recruiter Member, recruits []Member) RecruitStats {
+	stats := RecruitStats{Recruiter: recruiter, Recruits: recruits}
+	for _, recruit := range recruits {
+		if rankedUp(recruit) {
+			stats.RankedUp++
+		}
+		if left(recruit) {
+			stats.Left++
+		}
+	}
+	return stats
+}
+
+// ListRecruits returns everyone the member recruited, including those who have
+// since left
+func ListRecruits(ctx context.Context, recruiter *Member) (RecruitStats, error) {
+	recruits, err := list(ctx, bson.D{{Key: "recruiter", Value: recruiter.Id}}, 0, 0)
+	if err != nil {
+		return RecruitStats{}, errors.Wrap(err, "listing recruits")
+	}
+
+	return newRecruitStats(*recruiter, recruits), nil
+}
+
+// RecruiterLeaderboard ranks the recruiters by how many recruits joined since
+// the given time, most first
+func RecruiterLeaderboard(ctx context.Context, since time.Time) ([]RecruitStats, error) {
+	recruits, err := list(ctx, bson.D{
+		{Key: "recruiter", Value: bson.D{{Key: "$exists", Value: true}, {Key: "$ne", Value: nil}}},
+	}, 0, 0)
+	if err != nil {
+		return nil, errors.Wrap(err, "listing recruits")
+	}
+
+	byRecruiter := map[string][]Member{}
+	recruiters := map[string]Member{}
+	for _, recruit := range recruits {
+		// joined is saved as text, so it is compared here rather than in the
+		// query
+		if !recruit.HasRecruiter() || recruit.Joined.Before(since) {
+			continue
+		}
+		byRecruiter[recruit.Recruiter.Id] = append(byRecruiter[recruit.Recruiter.Id], recruit)
+		recruiters[recruit.Recruiter.Id] = *recruit.Recruiter
+	}
+
+	leaderboard := []RecruitStats{}
+	for id, recruits := range byRecruiter {
+		leaderboard = append(leaderboard, newRecruitStats(recruiters[id], recruits))
+	}
+
+	sort.Slice(leaderboard, func(i, j int) bool {
+		if len(leaderboard[i].Recruits) != len(leaderboard[j].Recruits) {
+			return len(leaderboard[i].Recruits) > len(leaderboard[j].Recruits)
+		}
+		if leaderboard[i].RankedUp != leaderboard[j].RankedUp {
+			return leaderboard[i].RankedUp > leaderboard[j].RankedUp
+		}
+		return leaderboard[i].Recruiter.Name < leaderboard[j].Recruiter.Name
+	})
+
+	return leaderboard, nil
+}
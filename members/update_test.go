@@ -0,0 +1,43 @@
+package members
+
+import (
+	"context"
+	"testing"
+)
+
+func TestUpdateRetriesOnConflict(t *testing.T) {
+	ctx := context.Background()
+	setupMemory(t)
+
+	if err := (&Member{Id: "1", Name: "one"}).Save(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	calls := 0
+	member, err := Update(ctx, "1", func(m *Member) error {
+		calls++
+		if calls == 1 {
+			// someone else saves the member while we're working on it
+			other, err := Get(ctx, "1")
+			if err != nil {
+				return err
+			}
+			other.Notes = "changed meanwhile"
+			if err := other.Save(ctx); err != nil {
+				return err
+			}
+		}
+		m.PrimaryOrg = "SOLARMADA"
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if calls != 2 {
+		t.Errorf("fn was called %d times, want 2", calls)
+	}
+	if member.Notes != "changed meanwhile" || member.PrimaryOrg != "SOLARMADA" {
+		t.Errorf("got notes %q and org %q, want both changes kept", member.Notes, member.PrimaryOrg)
+	}
+}
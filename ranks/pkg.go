@@ -1,12 +1,29 @@
 package ranks
 
-import "strings"
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/apex/log"
+	"github.com/pkg/errors"
+	"github.com/sol-armada/sol-bot/settings"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+)
+
+// Rank is a position on the ladder, 1 being the top. None is no rank at all.
+// It is stored by the rank's name, so changing the ladder doesn't change what
+// rank members have.
 type Rank int
 
+const None Rank = 0
+
+// the ranks of the default ladder, for when none is configured
 const (
-	None Rank = iota
-	Admiral
+	Admiral Rank = iota + 1
 	Commander
 	Lieutenant
 	Specialist
@@ -15,87 +32,319 @@ const (
 	Recruit
 )
 
-var Prefix map[Rank]string = map[Rank]string{
-	Admiral:    "[ADM]",
-	Commander:  "[CDR]",
-	Lieutenant: "[LT]",
-	Specialist: "[SPC]",
-	Technician: "[TEC]",
+// Requirements are what it takes to be promoted into a rank from the one
+// below it
+type Requirements struct {
+	// Events is how many events the member has attended
+	Events int `mapstructure:"events"`
+	// MinDaysInRank is how long the member has held the rank below
+	MinDaysInRank int `mapstructure:"min_days_in_rank"`
+	// Merits is the net merit score the member needs
+	Merits int `mapstructure:"merits"`
+	// NoDemerits is if the member can't have any active demerits
+	NoDemerits bool `mapstructure:"no_demerits"`
+	// Validated is if the member's RSI profile has to be validated
+	Validated bool `mapstructure:"validated"`
+}
+
+// Definition is a single rank of the ladder
+type Definition struct {
+	Name         string `mapstructure:"name"`
+	Abbreviation string `mapstructure:"abbreviation"`
+	// Prefix goes in front of members' nicknames, the abbreviation in brackets
+	// if not set
+	Prefix string `mapstructure:"prefix"`
+	RoleId string `mapstructure:"role_id"`
+	// RSIRanks are the names of the org ranks on RSI that are this rank
+	RSIRanks []string `mapstructure:"rsi_ranks"`
+	// Officer is if the rank can do officer things, like look at other
+	// members' profiles
+	Officer bool `mapstructure:"officer"`
+	// PrimaryOrg is if the rank has to have the org set as their primary on
+	// RSI
+	PrimaryOrg bool `mapstructure:"primary_org"`
+	// Promotion is what it takes to be promoted into the rank, nil if it is
+	// never given through a promotion
+	Promotion *Requirements `mapstructure:"promotion"`
+}
+
+var defaultLadder = []Definition{
+	{Name: "Admiral", Abbreviation: "ADM", RSIRanks: []string{"Admiral"}, Officer: true, PrimaryOrg: true},
+	{Name: "Commander", Abbreviation: "COM", Prefix: "[CDR]", RSIRanks: []string{"Commander"}, Officer: true, PrimaryOrg: true},
+	{Name: "Lieutenant", Abbreviation: "LT", RSIRanks: []string{"Lieutenant"}, Officer: true, PrimaryOrg: true},
+	{Name: "Specialist", Abbreviation: "SPC", RSIRanks: []string{"Specialist"}, PrimaryOrg: true, Promotion: &Requirements{Events: 20}},
+	{Name: "Technician", Abbreviation: "TEC", RSIRanks: []string{"Technician"}, PrimaryOrg: true, Promotion: &Requirements{Events: 10}},
+	{Name: "Member", RSIRanks: []string{"Member"}, Promotion: &Requirements{Events: 3}},
+	{Name: "Recruit"},
+}
+
+// ladder is every rank, highest first
+var ladder = defaultLadder
+
+// Setup reads the ladder from the [[ranks]] settings, keeping the default
+// ladder when there are none
+func Setup() error {
+	configured := []Definition{}
+	if err := settings.UnmarshalKey("RANKS", &configured); err != nil {
+		return errors.Wrap(err, "reading ranks")
+	}
+
+	if len(configured) == 0 {
+		ladder = defaultLadder
+		return nil
+	}
+
+	names := map[string]bool{}
+	abbreviations := map[string]bool{}
+	for n, definition := range configured {
+		if definition.Name == "" {
+			return errors.New("every rank needs a name")
+		}
+		if names[strings.ToUpper(definition.Name)] {
+			return errors.New("rank " + definition.Name + " is on the ladder twice")
+		}
+		names[strings.ToUpper(definition.Name)] = true
+
+		if definition.Abbreviation != "" {
+			if abbreviations[strings.ToUpper(definition.Abbreviation)] {
+				return errors.New("abbreviation " + definition.Abbreviation + " is used by more than one rank")
+			}
+			abbreviations[strings.ToUpper(definition.Abbreviation)] = true
+		}
+
+		if definition.Promotion == nil {
+			continue
+		}
+		if n == len(configured)-1 {
+			return errors.New("rank " + definition.Name + " is the lowest, there is no rank to be promoted from")
+		}
+		if definition.Promotion.Events < 0 || definition.Promotion.MinDaysInRank < 0 {
+			return errors.New("rank " + definition.Name + " can't need negative events or days in rank")
+		}
+	}
+
+	ladder = configured
+	return nil
+}
+
+// All returns every rank on the ladder, highest first
+func All() []Rank {
+	all := []Rank{}
+	for n := range ladder {
+		all = append(all, Rank(n+1))
+	}
+	return all
+}
+
+// Lowest is the rank members join at
+func Lowest() Rank {
+	return Rank(len(ladder))
+}
+
+// LowestRSI is the lowest rank that comes from being in the org on RSI
+func LowestRSI() Rank {
+	for n := len(ladder) - 1; n >= 0; n-- {
+		if len(ladder[n].RSIRanks) > 0 {
+			return Rank(n + 1)
+		}
+	}
+	return Lowest()
+}
+
+// Definition returns how the rank is set up, ok is false for None or a rank
+// no longer on the ladder
+func (r Rank) Definition() (Definition, bool) {
+	if r < 1 || int(r) > len(ladder) {
+		return Definition{}, false
+	}
+	return ladder[r-1], true
+}
+
+// Above is the rank one step up the ladder, None if it is the top
+func (r Rank) Above() Rank {
+	if r <= 1 || int(r) > len(ladder) {
+		return None
+	}
+	return r - 1
 }
 
 func GetRankByName(name string) Rank {
-	switch strings.ToUpper(name) {
-	case "ADMIRAL":
-		return Admiral
-	case "COMMANDER":
-		return Commander
-	case "LIEUTENANT":
-		return Lieutenant
-	case "SPECIALIST":
-		return Specialist
-	case "TECHNICIAN":
-		return Technician
-	case "MEMBER":
-		return Member
-	case "RECRUIT":
-		return Recruit
+	for n, definition := range ladder {
+		if strings.EqualFold(definition.Name, name) {
+			return Rank(n + 1)
+		}
+	}
+	return None
+}
+
+// LegacyName is the name of the rank that was stored as its number, from
+// before ranks were stored by name. Those numbers are places on the default
+// ladder.
+func LegacyName(n int) string {
+	if n < 1 || n > len(defaultLadder) {
+		return ""
+	}
+	return defaultLadder[n-1].Name
+}
+
+// byStoredName finds the rank that was stored by name
+func byStoredName(name string) Rank {
+	r := GetRankByName(name)
+	if r == None && name != "" {
+		log.WithField("rank", name).Warn("stored rank is not on the ladder")
+	}
+	return r
+}
+
+func (r Rank) MarshalBSONValue() (bsontype.Type, []byte, error) {
+	if r.String() == "" {
+		return bsontype.Null, nil, nil
+	}
+	return bson.MarshalValue(r.String())
+}
+
+func (r *Rank) UnmarshalBSONValue(t bsontype.Type, data []byte) error {
+	raw := bson.RawValue{Type: t, Value: data}
+	switch t {
+	case bsontype.Null, bsontype.Undefined:
+		*r = None
+	case bsontype.String:
+		*r = byStoredName(raw.StringValue())
+	case bsontype.Int32, bsontype.Int64, bsontype.Double:
+		n, ok := raw.AsInt64OK()
+		if !ok {
+			return errors.Errorf("reading rank from %v", raw)
+		}
+		*r = byStoredName(LegacyName(int(n)))
 	default:
-		return None
+		return errors.Errorf("can't read a rank from %s", t)
 	}
+	return nil
 }
 
-func GetRankByRSIRankName(name string) Rank {
-	switch strings.ToUpper(name) {
-	case "ADMIRAL":
-		return Admiral
-	case "COMMANDER":
-		return Commander
-	case "LIEUTENANT":
-		return Lieutenant
-	case "SPECIALIST":
-		return Specialist
-	case "TECHNICIAN":
-		return Technician
-	case "MEMBER":
-		return Member
+func (r Rank) MarshalJSON() ([]byte, error) {
+	if r.String() == "" {
+		return []byte("null"), nil
+	}
+	return json.Marshal(r.String())
+}
+
+func (r *Rank) UnmarshalJSON(data []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	switch value := v.(type) {
+	case nil:
+		*r = None
+	case string:
+		*r = byStoredName(value)
+	case float64:
+		*r = byStoredName(LegacyName(int(value)))
 	default:
-		return None
+		return errors.Errorf("can't read a rank from %s", data)
 	}
+	return nil
+}
+
+func GetRankByRSIRankName(name string) Rank {
+	for n, definition := range ladder {
+		if slices.ContainsFunc(definition.RSIRanks, func(rsiRank string) bool { return strings.EqualFold(rsiRank, name) }) {
+			return Rank(n + 1)
+		}
+	}
+	return None
 }
 
 // String returns the string representation of the rank.
 func (r Rank) String() string {
-	switch r {
-	case Admiral:
-		return "Admiral"
-	case Commander:
-		return "Commander"
-	case Lieutenant:
-		return "Lieutenant"
-	case Specialist:
-		return "Specialist"
-	case Technician:
-		return "Technician"
-	case Member:
-		return "Member"
-	case Recruit:
-		return "Recruit"
-	}
-	return ""
+	definition, _ := r.Definition()
+	return definition.Name
 }
 
 func (r Rank) ShortString() string {
-	switch r {
-	case Admiral:
-		return "ADM"
-	case Commander:
-		return "COM"
-	case Lieutenant:
-		return "LT"
-	case Specialist:
-		return "SPC"
-	case Technician:
-		return "TEC"
-	}
-	return ""
+	definition, _ := r.Definition()
+	return definition.Abbreviation
+}
+
+// Prefix is what goes in front of a member's nickname, like "[TEC]"
+func (r Rank) Prefix() string {
+	definition, _ := r.Definition()
+	if definition.Prefix != "" {
+		return definition.Prefix
+	}
+	if definition.Abbreviation == "" {
+		return ""
+	}
+	return "[" + definition.Abbreviation + "]"
+}
+
+func (r Rank) IsOfficer() bool {
+	definition, _ := r.Definition()
+	return definition.Officer
+}
+
+// Candidate is what is known about a member when deciding if they can be
+// promoted
+type Candidate struct {
+	Rank   Rank
+	Events int
+	// TimeInRank is how long they have held their rank, nil if not known
+	TimeInRank     *time.Duration
+	MeritScore     int
+	ActiveDemerits int
+	Validated      bool
+}
+
+// Evaluation is what a candidate can be promoted to and what is holding them
+// back
+type Evaluation struct {
+	Next  Rank
+	Unmet []string
+}
+
+// Eligible is if the candidate meets everything for the next rank
+func (e Evaluation) Eligible() bool {
+	return e.Next != None && len(e.Unmet) == 0
+}
+
+// Evaluate checks the candidate against the requirements of the rank above
+// theirs. Next is None if that rank isn't given through promotion.
+// defaultMinDaysInRank is used when the rank doesn't set its own.
+func Evaluate(candidate Candidate, defaultMinDaysInRank int) Evaluation {
+	next := candidate.Rank.Above()
+	definition, ok := next.Definition()
+	if !ok || definition.Promotion == nil {
+		return Evaluation{}
+	}
+	requirements := definition.Promotion
+
+	evaluation := Evaluation{Next: next, Unmet: []string{}}
+
+	if candidate.Events < requirements.Events {
+		evaluation.Unmet = append(evaluation.Unmet, fmt.Sprintf("%d of %d events", candidate.Events, requirements.Events))
+	}
+
+	minDays := requirements.MinDaysInRank
+	if minDays == 0 {
+		minDays = defaultMinDaysInRank
+	}
+	// members from before ranks were tracked don't hold it against them
+	if candidate.TimeInRank != nil && *candidate.TimeInRank < time.Duration(minDays)*24*time.Hour {
+		evaluation.Unmet = append(evaluation.Unmet, fmt.Sprintf("%d of %d days in rank", int(candidate.TimeInRank.Hours()/24), minDays))
+	}
+
+	if candidate.MeritScore < requirements.Merits {
+		evaluation.Unmet = append(evaluation.Unmet, fmt.Sprintf("%d of %d merits", candidate.MeritScore, requirements.Merits))
+	}
+
+	if requirements.NoDemerits && candidate.ActiveDemerits > 0 {
+		evaluation.Unmet = append(evaluation.Unmet, fmt.Sprintf("%d active demerits", candidate.ActiveDemerits))
+	}
+
+	if requirements.Validated && !candidate.Validated {
+		evaluation.Unmet = append(evaluation.Unmet, "RSI not validated")
+	}
+
+	return evaluation
 }
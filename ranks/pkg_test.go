@@ -0,0 +1,205 @@
+package ranks
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/sol-armada/sol-bot/settings"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type stored struct {
+	Rank Rank `json:"rank" bson:"rank"`
+}
+
+func useLadder(t *testing.T, configured []map[string]interface{}) {
+	t.Helper()
+
+	settings.Reset()
+	t.Cleanup(func() {
+		settings.Reset()
+		ladder = defaultLadder
+	})
+	if configured != nil {
+		settings.Set("RANKS", configured)
+	}
+	if err := Setup(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRankStoredByName(t *testing.T) {
+	useLadder(t, nil)
+
+	for _, rank := range []Rank{None, Admiral, Technician, Recruit} {
+		b, err := bson.Marshal(stored{Rank: rank})
+		if err != nil {
+			t.Fatal(err)
+		}
+		raw := bson.Raw(b).Lookup("rank")
+		if rank == None && raw.Type != bson.TypeNull {
+			t.Errorf("None stored as %v, want null", raw)
+		}
+		if name, ok := raw.StringValueOK(); rank != None && (!ok || name != rank.String()) {
+			t.Errorf("%s stored as %v, want its name", rank, raw)
+		}
+
+		got := stored{}
+		if err := bson.Unmarshal(b, &got); err != nil {
+			t.Fatal(err)
+		}
+		if got.Rank != rank {
+			t.Errorf("bson: got %v back, want %v", got.Rank, rank)
+		}
+
+		j, err := json.Marshal(stored{Rank: rank})
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = stored{}
+		if err := json.Unmarshal(j, &got); err != nil {
+			t.Fatal(err)
+		}
+		if got.Rank != rank {
+			t.Errorf("json: got %v back from %s, want %v", got.Rank, j, rank)
+		}
+	}
+}
+
+func TestRankReadsLegacyNumbers(t *testing.T) {
+	useLadder(t, nil)
+
+	tests := []struct {
+		name string
+		doc  bson.M
+		want Rank
+	}{
+		{"int32", bson.M{"rank": int32(5)}, Technician},
+		{"int64", bson.M{"rank": int64(2)}, Commander},
+		{"double", bson.M{"rank": 7.0}, Recruit},
+		{"zero", bson.M{"rank": 0}, None},
+		{"off the ladder", bson.M{"rank": 42}, None},
+		{"unknown name", bson.M{"rank": "Ensign"}, None},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := bson.Marshal(tt.doc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := stored{}
+			if err := bson.Unmarshal(b, &got); err != nil {
+				t.Fatal(err)
+			}
+			if got.Rank != tt.want {
+				t.Errorf("got %v, want %v", got.Rank, tt.want)
+			}
+		})
+	}
+
+	got := stored{}
+	if err := json.Unmarshal([]byte(`{"rank": 6}`), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Rank != Member {
+		t.Errorf("json: got %v, want Member", got.Rank)
+	}
+}
+
+func TestRankSurvivesLadderChanges(t *testing.T) {
+	useLadder(t, nil)
+
+	b, err := bson.Marshal(stored{Rank: Technician})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a rank is added above and the ladder is reordered
+	useLadder(t, []map[string]interface{}{
+		{"name": "Fleet Admiral"},
+		{"name": "Technician"},
+		{"name": "Admiral"},
+		{"name": "Recruit"},
+	})
+
+	got := stored{}
+	if err := bson.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Rank.String() != "Technician" {
+		t.Errorf("got %q, want Technician", got.Rank.String())
+	}
+}
+
+func TestSetupValidates(t *testing.T) {
+	tests := []struct {
+		name   string
+		ladder []map[string]interface{}
+		ok     bool
+	}{
+		{"valid", []map[string]interface{}{
+			{"name": "Captain", "abbreviation": "CPT"},
+			{"name": "Crew", "promotion": map[string]interface{}{"events": 3}},
+			{"name": "Recruit"},
+		}, true},
+		{"missing name", []map[string]interface{}{{"abbreviation": "CPT"}}, false},
+		{"duplicate name", []map[string]interface{}{{"name": "Crew"}, {"name": "crew"}}, false},
+		{"duplicate abbreviation", []map[string]interface{}{
+			{"name": "Captain", "abbreviation": "CPT"},
+			{"name": "Copilot", "abbreviation": "cpt"},
+		}, false},
+		{"promotion into the lowest rank", []map[string]interface{}{
+			{"name": "Crew"},
+			{"name": "Recruit", "promotion": map[string]interface{}{"events": 3}},
+		}, false},
+		{"negative events", []map[string]interface{}{
+			{"name": "Crew", "promotion": map[string]interface{}{"events": -1}},
+			{"name": "Recruit"},
+		}, false},
+		{"negative days in rank", []map[string]interface{}{
+			{"name": "Crew", "promotion": map[string]interface{}{"min_days_in_rank": -1}},
+			{"name": "Recruit"},
+		}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings.Reset()
+			t.Cleanup(func() {
+				settings.Reset()
+				ladder = defaultLadder
+			})
+			settings.Set("RANKS", tt.ladder)
+
+			if err := Setup(); (err == nil) != tt.ok {
+				t.Errorf("got %v, want ok %v", err, tt.ok)
+			}
+		})
+	}
+}
+
+func TestPrefix(t *testing.T) {
+	useLadder(t, nil)
+
+	tests := []struct {
+		rank  Rank
+		short string
+		want  string
+	}{
+		{Admiral, "ADM", "[ADM]"},
+		{Commander, "COM", "[CDR]"},
+		{Technician, "TEC", "[TEC]"},
+		{Member, "", ""},
+		{None, "", ""},
+	}
+
+	for _, tt := range tests {
+		if got := tt.rank.ShortString(); got != tt.short {
+			t.Errorf("%v short string is %q, want %q", tt.rank, got, tt.short)
+		}
+		if got := tt.rank.Prefix(); got != tt.want {
+			t.Errorf("%v prefix is %q, want %q", tt.rank, got, tt.want)
+		}
+	}
+}
@@ -24,7 +24,7 @@ func UpdateRsiInfo(member *members.Member) error {
 	member.IsGuest = true
 	member.Rank = ranks.None
 	member.PrimaryOrg = ""
-	member.Affilations = []string{}
+	member.Affiliations = []string{}
 
 	var err error
 	c.OnResponse(func(r *colly.Response) {
@@ -48,10 +48,10 @@ func UpdateRsiInfo(member *members.Member) error {
 	})
 
 	c.OnXML(`//div[contains(@class, "orgs-content")]`, func(e *colly.XMLElement) {
-		member.Affilations = e.ChildTexts(`//div[contains(@class, "org affiliation")]//div[@class="info"]//span[contains(text(), "SID")]/following-sibling::strong`)
-		if utils.StringSliceContains(member.Affilations, settings.GetString("rsi_org_sid")) {
+		member.Affiliations = e.ChildTexts(`//div[contains(@class, "org affiliation")]//div[@class="info"]//span[contains(text(), "SID")]/following-sibling::strong`)
+		if utils.StringSliceContains(member.Affiliations, settings.GetString("rsi_org_sid")) {
 			member.IsAffiliate = true
-			member.Rank = ranks.Member
+			member.Rank = ranks.LowestRSI()
 			member.IsGuest = false
 			member.IsAlly = false
 		}
@@ -1,6 +1,8 @@
 package settings
 
 import (
+	"time"
+
 	"github.com/spf13/viper"
 )
 
@@ -91,3 +93,15 @@ func GetIntSlice(key string) []int {
 func GetStringSlice(key string) []string {
 	return setting.GetStringSlice(key)
 }
+
+// UnmarshalKey reads the setting into rawVal, for settings made of tables
+func UnmarshalKey(key string, rawVal interface{}) error {
+	return setting.UnmarshalKey(key, rawVal)
+}
+
+func GetDurationWithDefault(key string, val time.Duration) time.Duration {
+	if !setting.IsSet(key) {
+		return val
+	}
+	return setting.GetDuration(key)
+}
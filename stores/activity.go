@@ -4,15 +4,16 @@ import (
 	"context"
 
 	"github.com/sol-armada/sol-bot/utils"
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-type ActivityStore struct {
+type mongoActivityStore struct {
 	*store
 }
 
-func newActivityStore(ctx context.Context, client *mongo.Client, database string) *ActivityStore {
+func newActivityStore(ctx context.Context, client *mongo.Client, database string) *mongoActivityStore {
 	_ = client.Database(database).CreateCollection(ctx, string(ACTIVITY), &options.CreateCollectionOptions{
 		TimeSeriesOptions: &options.TimeSeriesOptions{
 			TimeField: "when",
@@ -21,12 +22,34 @@ func newActivityStore(ctx context.Context, client *mongo.Client, database string
 	})
 	s := &store{
 		Collection: client.Database(database).Collection(string(ACTIVITY)),
-		ctx:        ctx,
 	}
-	return &ActivityStore{s}
+	return &mongoActivityStore{s}
 }
 
-func (s *ActivityStore) Create(activity any) error {
-	_, err := s.InsertOne(s.ctx, activity)
-	return err
+func (s *mongoActivityStore) reconcileIndexes(ctx context.Context) []IndexStatus {
+	return s.store.reconcileIndexes(ctx, ACTIVITY, []Index{
+		{Name: "meta_what_when", Keys: bson.D{
+			{Key: "meta.what", Value: 1},
+			{Key: "when", Value: 1},
+		}},
+	})
+}
+
+func (s *mongoActivityStore) Create(ctx context.Context, activity any) error {
+	ctx, cancel := callContext(ctx)
+	defer cancel()
+
+	_, err := s.InsertOne(ctx, activity)
+	return timeout(err)
+}
+
+func (s *mongoActivityStore) List(ctx context.Context, filter interface{}) (Cursor, error) {
+	ctx, cancel := callContext(ctx)
+	defer cancel()
+
+	if filter == nil {
+		filter = bson.D{}
+	}
+
+	return cursor(s.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "when", Value: 1}})))
 }
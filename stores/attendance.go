@@ -9,25 +9,47 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-type AttendanceStore struct {
+type mongoAttendanceStore struct {
 	*store
 }
 
-func newAttendanceStore(ctx context.Context, client *mongo.Client, database string) *AttendanceStore {
+func newAttendanceStore(ctx context.Context, client *mongo.Client, database string) *mongoAttendanceStore {
 	_ = client.Database(database).CreateCollection(ctx, string(ATTENDANCE))
 	s := &store{
 		Collection: client.Database(database).Collection(string(ATTENDANCE)),
-		ctx:        ctx,
 	}
-	return &AttendanceStore{s}
+	return &mongoAttendanceStore{s}
 }
 
-func (s *AttendanceStore) Create(attendance any) error {
-	_, err := s.InsertOne(s.ctx, attendance)
-	return err
+func (s *mongoAttendanceStore) reconcileIndexes(ctx context.Context) []IndexStatus {
+	return s.store.reconcileIndexes(ctx, ATTENDANCE, []Index{
+		// GetCount and member history
+		{Name: "members_recorded_date_created", Keys: bson.D{
+			{Key: "members", Value: 1},
+			{Key: "recorded", Value: 1},
+			{Key: "date_created", Value: 1},
+		}},
+		// ListActive
+		{Name: "recorded_date_created", Keys: bson.D{
+			{Key: "recorded", Value: 1},
+			{Key: "date_created", Value: -1},
+		}},
+		{Name: "name", Keys: bson.D{{Key: "name", Value: 1}}},
+	})
 }
 
-func (s *AttendanceStore) Get(id string) (*mongo.Cursor, error) {
+func (s *mongoAttendanceStore) Create(ctx context.Context, attendance any) error {
+	ctx, cancel := callContext(ctx)
+	defer cancel()
+
+	_, err := s.InsertOne(ctx, attendance)
+	return timeout(err)
+}
+
+func (s *mongoAttendanceStore) Get(ctx context.Context, id string) (Cursor, error) {
+	ctx, cancel := callContext(ctx)
+	defer cancel()
+
 	pipeline := bson.A{
 		bson.D{{Key: "$match", Value: bson.D{{Key: "_id", Value: id}}}},
 		bson.D{
@@ -69,12 +91,7 @@ func (s *AttendanceStore) Get(id string) (*mongo.Cursor, error) {
 		},
 	}
 
-	cur, err := s.Aggregate(s.ctx, pipeline)
-	if err != nil {
-		return nil, err
-	}
-
-	return cur, nil
+	return cursor(s.Aggregate(ctx, pipeline))
 }
 
 // List retrieves a list of attendance records from the database, optionally filtered by the provided filter and limited to the specified number of records.
@@ -84,10 +101,49 @@ func (s *AttendanceStore) Get(id string) (*mongo.Cursor, error) {
 // - limit: An int64 representing the maximum number of records to retrieve. If limit is 0, all records will be retrieved.
 //
 // Returns:
-// - *mongo.Cursor: A cursor to iterate over the retrieved attendance records.
+// - Cursor: A cursor to iterate over the retrieved attendance records.
 // - error: An error if the query operation fails.
-func (s *AttendanceStore) List(filter interface{}, limit int, page int) (*mongo.Cursor, error) {
+func (s *mongoAttendanceStore) List(ctx context.Context, filter interface{}, limit int, page int) (Cursor, error) {
+	ctx, cancel := callContext(ctx)
+	defer cancel()
+
+	pipeline := append(attendanceLookups(),
+		bson.D{{Key: "$match", Value: filter}},
+		bson.D{{Key: "$sort", Value: bson.D{{Key: "date_created", Value: -1}}}},
+	)
+
+	if limit > 0 {
+		if page == 0 {
+			page = 1
+		}
+
+		page = (page - 1) * limit
+		pipeline = append(pipeline, bson.D{{Key: "$skip", Value: page}}, bson.D{{Key: "$limit", Value: limit}})
+	}
+
+	return cursor(s.Aggregate(ctx, pipeline))
+}
+
+// ListRecorded returns the recorded attendance the member is in, oldest first.
+// The member is matched before anything is looked up, so only their records
+// are joined.
+func (s *mongoAttendanceStore) ListRecorded(ctx context.Context, memberId string) (Cursor, error) {
+	ctx, cancel := callContext(ctx)
+	defer cancel()
+
 	pipeline := bson.A{
+		bson.D{{Key: "$match", Value: bson.D{{Key: "recorded", Value: true}, {Key: "members", Value: memberId}}}},
+	}
+	pipeline = append(pipeline, attendanceLookups()...)
+	pipeline = append(pipeline, bson.D{{Key: "$sort", Value: bson.D{{Key: "date_created", Value: 1}}}})
+
+	return cursor(s.Aggregate(ctx, pipeline))
+}
+
+// attendanceLookups are the stages that swap the stored member ids for the
+// members
+func attendanceLookups() bson.A {
+	return bson.A{
 		bson.D{
 			{Key: "$lookup",
 				Value: bson.D{
@@ -126,28 +182,13 @@ func (s *AttendanceStore) List(filter interface{}, limit int, page int) (*mongo.
 				},
 			},
 		},
-		bson.D{{Key: "$match", Value: filter}},
-		bson.D{{Key: "$sort", Value: bson.D{{Key: "date_created", Value: -1}}}},
-	}
-
-	if limit > 0 {
-		if page == 0 {
-			page = 1
-		}
-
-		page = (page - 1) * limit
-		pipeline = append(pipeline, bson.D{{Key: "$skip", Value: page}}, bson.D{{Key: "$limit", Value: limit}})
-	}
-
-	cur, err := s.Aggregate(s.ctx, pipeline)
-	if err != nil {
-		return nil, err
 	}
-
-	return cur, nil
 }
 
-func (s *AttendanceStore) GetCount(memberId string) (int, error) {
+func (s *mongoAttendanceStore) GetCount(ctx context.Context, memberId string) (int, error) {
+	ctx, cancel := callContext(ctx)
+	defer cancel()
+
 	pipeline := bson.A{
 		bson.D{{Key: "$match", Value: bson.D{
 			{Key: "$and",
@@ -363,32 +404,36 @@ func (s *AttendanceStore) GetCount(memberId string) (int, error) {
 		bson.D{{Key: "$count", Value: "count"}},
 	}
 
-	cur, err := s.Aggregate(s.ctx, pipeline)
+	cur, err := s.Aggregate(ctx, pipeline)
 	if err != nil {
-		return 0, err
+		return 0, timeout(err)
 	}
 
 	// get the count
 	var result bson.M
-	cur.Next(s.ctx)
+	if !cur.Next(ctx) {
+		return 0, timeout(cur.Err())
+	}
 
 	if err := cur.Decode(&result); err != nil {
-		if err.Error() == "EOF" {
-			return 0, nil
-		}
-
 		return 0, err
 	}
 
 	return int(result["count"].(int32)), nil
 }
 
-func (s *AttendanceStore) Upsert(id string, attendance any) error {
-	_, err := s.UpdateOne(s.ctx, bson.M{"_id": id}, bson.M{"$set": attendance}, options.Update().SetUpsert(true))
-	return err
+func (s *mongoAttendanceStore) Upsert(ctx context.Context, id string, attendance any) error {
+	ctx, cancel := callContext(ctx)
+	defer cancel()
+
+	_, err := s.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": attendance}, options.Update().SetUpsert(true))
+	return timeout(err)
 }
 
-func (s *AttendanceStore) Delete(id string) error {
-	_, err := s.DeleteOne(s.ctx, bson.M{"_id": id})
-	return err
+func (s *mongoAttendanceStore) Delete(ctx context.Context, id string) error {
+	ctx, cancel := callContext(ctx)
+	defer cancel()
+
+	_, err := s.DeleteOne(ctx, bson.M{"_id": id})
+	return timeout(err)
 }
@@ -0,0 +1,61 @@
+package stores
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type mongoAuditStore struct {
+	*store
+}
+
+func newAuditStore(ctx context.Context, client *mongo.Client, database string) *mongoAuditStore {
+	_ = client.Database(database).CreateCollection(ctx, string(AUDIT))
+	s := &store{
+		Collection: client.Database(database).Collection(string(AUDIT)),
+	}
+	return &mongoAuditStore{s}
+}
+
+func (s *mongoAuditStore) reconcileIndexes(ctx context.Context) []IndexStatus {
+	return s.store.reconcileIndexes(ctx, AUDIT, []Index{
+		{Name: "target_id_when", Keys: bson.D{
+			{Key: "target_id", Value: 1},
+			{Key: "when", Value: -1},
+		}},
+		{Name: "actor_id_when", Keys: bson.D{
+			{Key: "actor_id", Value: 1},
+			{Key: "when", Value: -1},
+		}},
+	})
+}
+
+func (s *mongoAuditStore) Create(ctx context.Context, entry any) error {
+	ctx, cancel := callContext(ctx)
+	defer cancel()
+
+	_, err := s.InsertOne(ctx, entry)
+	return timeout(err)
+}
+
+func (s *mongoAuditStore) List(ctx context.Context, filter interface{}, limit int, page int) (Cursor, error) {
+	ctx, cancel := callContext(ctx)
+	defer cancel()
+
+	if filter == nil {
+		filter = bson.D{}
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "when", Value: -1}})
+	if limit > 0 {
+		if page == 0 {
+			page = 1
+		}
+		opts.SetSkip(int64((page - 1) * limit)).SetLimit(int64(limit))
+	}
+
+	return cursor(s.Find(ctx, filter, opts))
+}
@@ -0,0 +1,310 @@
+package stores
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// BackupFormat identifies a solbot backup archive
+const BackupFormat = "solbot-backup"
+
+// BackupVersion is the version of the archive layout written by Backup.
+// Restore refuses archives from a newer version.
+const BackupVersion = 1
+
+// maximum size of a single line in an archive, documents larger than this
+// can't be stored in mongo anyway
+const maxBackupLine = 17 * 1024 * 1024
+
+var (
+	ErrInvalidBackup = errors.New("invalid backup archive")
+)
+
+// BackupCollections are the collections written to a backup, in order
+var BackupCollections = []Collection{CONFIGS, MEMBERS, ATTENDANCE, ACTIVITY, AUDIT, LEDGER, EVENTS}
+
+// timeFields are the fields a point in time restore compares against for the
+// collections that record when something happened
+var timeFields = map[Collection]string{
+	ATTENDANCE: "date_created",
+	ACTIVITY:   "when",
+	AUDIT:      "when",
+	LEDGER:     "when",
+	EVENTS:     "date_created",
+}
+
+// BackupHeader is the first line of an archive
+type BackupHeader struct {
+	Format           string       `json:"format"`
+	Version          int          `json:"version"`
+	CreatedAt        time.Time    `json:"created_at"`
+	MigrationVersion int          `json:"migration_version"`
+	Collections      []Collection `json:"collections"`
+}
+
+// BackupFooter is the last line of an archive. Its counts let a restore tell a
+// complete archive from a truncated one.
+type BackupFooter struct {
+	Counts map[Collection]int `json:"counts"`
+}
+
+// backupLine is a single line of an archive. Exactly one of the fields is set.
+type backupLine struct {
+	Header     *BackupHeader   `json:"header,omitempty"`
+	Collection Collection      `json:"collection,omitempty"`
+	Document   json.RawMessage `json:"document,omitempty"`
+	Footer     *BackupFooter   `json:"footer,omitempty"`
+}
+
+// Backup streams every collection into w as gzip compressed JSON lines. Each
+// document is written as canonical extended JSON so dates and number types
+// survive the round trip.
+func (c *Client) Backup(ctx context.Context, w io.Writer) (*BackupFooter, error) {
+	logger := log.WithField("func", "stores.Backup")
+
+	header := &BackupHeader{
+		Format:      BackupFormat,
+		Version:     BackupVersion,
+		CreatedAt:   time.Now().UTC(),
+		Collections: BackupCollections,
+	}
+
+	applied, err := c.AppliedMigrations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range applied {
+		header.MigrationVersion = max(header.MigrationVersion, a.Version)
+	}
+
+	gz := gzip.NewWriter(w)
+	enc := json.NewEncoder(gz)
+
+	if err := enc.Encode(backupLine{Header: header}); err != nil {
+		return nil, errors.Wrap(err, "writing header")
+	}
+
+	footer := &BackupFooter{Counts: map[Collection]int{}}
+	for _, collection := range BackupCollections {
+		if err := c.documents(collection).each(ctx, func(doc bson.M) error {
+			raw, err := bson.MarshalExtJSON(doc, true, false)
+			if err != nil {
+				return errors.Wrapf(err, "encoding document %v", doc["_id"])
+			}
+
+			footer.Counts[collection]++
+			return enc.Encode(backupLine{Collection: collection, Document: raw})
+		}); err != nil {
+			return nil, errors.Wrapf(err, "backing up %s", collection)
+		}
+
+		logger.WithFields(log.Fields{
+			"collection": collection,
+			"count":      footer.Counts[collection],
+		}).Debug("backed up collection")
+	}
+
+	if err := enc.Encode(backupLine{Footer: footer}); err != nil {
+		return nil, errors.Wrap(err, "writing footer")
+	}
+
+	if err := gz.Close(); err != nil {
+		return nil, errors.Wrap(err, "finishing archive")
+	}
+
+	return footer, nil
+}
+
+// RestoreOptions narrow down what Restore writes
+type RestoreOptions struct {
+	// Collection restores only this collection when set
+	Collection Collection
+	// Until skips attendance, activity, audit, ledger and event documents
+	// from after this time when set
+	Until time.Time
+	// DryRun reads and validates everything without writing
+	DryRun bool
+
+	// assignIds gives documents without an _id one instead of failing, for
+	// store files saved before every document was given one
+	assignIds bool
+}
+
+// RestoreReport is what a restore did, or would do on a dry run
+type RestoreReport struct {
+	Header   BackupHeader
+	Restored map[Collection]int
+	Skipped  map[Collection]int
+}
+
+func (r *RestoreReport) String() string {
+	s := fmt.Sprintf("%s v%d created %s\n", r.Header.Format, r.Header.Version, r.Header.CreatedAt.Format(time.RFC3339))
+	for _, collection := range r.Header.Collections {
+		s += fmt.Sprintf("%s: %d restored, %d skipped\n", collection, r.Restored[collection], r.Skipped[collection])
+	}
+	return s
+}
+
+// ValidateBackup reads the whole archive and checks it is complete and that
+// every document can be decoded, without writing anything
+func ValidateBackup(r io.Reader) (*RestoreReport, error) {
+	return readBackup(r, RestoreOptions{}, func(Collection, bson.M) error { return nil })
+}
+
+// Restore validates the archive and then writes its documents back, replacing
+// any stored document with the same id. Documents that aren't in the archive
+// are left alone. The archive is read twice, once to validate and once to
+// restore, so nothing is written from an archive that turns out to be broken.
+func (c *Client) Restore(ctx context.Context, r io.ReadSeeker, opts RestoreOptions) (*RestoreReport, error) {
+	if opts.Collection != "" && !slices.Contains(BackupCollections, opts.Collection) {
+		return nil, errors.Errorf("unknown collection %q", opts.Collection)
+	}
+
+	if _, err := ValidateBackup(r); err != nil {
+		return nil, err
+	}
+
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, errors.Wrap(err, "rewinding archive")
+	}
+
+	docs := map[Collection]documents{}
+	return readBackup(r, opts, func(collection Collection, doc bson.M) error {
+		if opts.DryRun {
+			return nil
+		}
+
+		if _, ok := docs[collection]; !ok {
+			docs[collection] = c.documents(collection)
+		}
+
+		return docs[collection].restore(ctx, doc)
+	})
+}
+
+// readBackup walks the archive calling fn for every document the options
+// select. It fails on anything that doesn't look like a complete archive.
+func readBackup(r io.Reader, opts RestoreOptions, fn func(collection Collection, doc bson.M) error) (*RestoreReport, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, errors.Wrap(ErrInvalidBackup, err.Error())
+	}
+	defer gz.Close()
+
+	scanner := bufio.NewScanner(gz)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxBackupLine)
+
+	report := &RestoreReport{
+		Restored: map[Collection]int{},
+		Skipped:  map[Collection]int{},
+	}
+	counts := map[Collection]int{}
+
+	var header *BackupHeader
+	var footer *BackupFooter
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+
+		if footer != nil {
+			return nil, errors.Wrapf(ErrInvalidBackup, "line %d: data after the footer", lineNumber)
+		}
+
+		line := backupLine{}
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			return nil, errors.Wrapf(ErrInvalidBackup, "line %d: %s", lineNumber, err.Error())
+		}
+
+		switch {
+		case lineNumber == 1:
+			if line.Header == nil {
+				return nil, errors.Wrap(ErrInvalidBackup, "missing header")
+			}
+			header = line.Header
+			if header.Format != BackupFormat {
+				return nil, errors.Wrapf(ErrInvalidBackup, "unknown format %q", header.Format)
+			}
+			if header.Version < 1 || header.Version > BackupVersion {
+				return nil, errors.Wrapf(ErrInvalidBackup, "unsupported version %d", header.Version)
+			}
+			report.Header = *header
+		case line.Footer != nil:
+			footer = line.Footer
+		case line.Collection != "":
+			if !slices.Contains(header.Collections, line.Collection) {
+				return nil, errors.Wrapf(ErrInvalidBackup, "line %d: unexpected collection %q", lineNumber, line.Collection)
+			}
+
+			doc := bson.M{}
+			if err := bson.UnmarshalExtJSON(line.Document, true, &doc); err != nil {
+				return nil, errors.Wrapf(ErrInvalidBackup, "line %d: %s", lineNumber, err.Error())
+			}
+			if _, ok := doc["_id"]; !ok {
+				if !opts.assignIds {
+					return nil, errors.Wrapf(ErrInvalidBackup, "line %d: document has no _id", lineNumber)
+				}
+				doc["_id"] = primitive.NewObjectID()
+			}
+			counts[line.Collection]++
+
+			if !selected(line.Collection, doc, opts) {
+				report.Skipped[line.Collection]++
+				continue
+			}
+
+			if err := fn(line.Collection, doc); err != nil {
+				return nil, errors.Wrapf(err, "restoring %s document %v", line.Collection, doc["_id"])
+			}
+			report.Restored[line.Collection]++
+		default:
+			return nil, errors.Wrapf(ErrInvalidBackup, "line %d: unknown line", lineNumber)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(ErrInvalidBackup, err.Error())
+	}
+
+	if header == nil {
+		return nil, errors.Wrap(ErrInvalidBackup, "empty archive")
+	}
+	if footer == nil {
+		return nil, errors.Wrap(ErrInvalidBackup, "missing footer, the archive may be truncated")
+	}
+	for _, collection := range header.Collections {
+		if counts[collection] != footer.Counts[collection] {
+			return nil, errors.Wrapf(ErrInvalidBackup, "%s has %d documents but the footer expects %d", collection, counts[collection], footer.Counts[collection])
+		}
+	}
+
+	return report, nil
+}
+
+// selected reports if the restore options include the document
+func selected(collection Collection, doc bson.M, opts RestoreOptions) bool {
+	if opts.Collection != "" && opts.Collection != collection {
+		return false
+	}
+
+	field, ok := timeFields[collection]
+	if !ok || opts.Until.IsZero() {
+		return true
+	}
+
+	when, ok := toTime(doc[field])
+	if !ok { // keep anything we can't place in time
+		return true
+	}
+
+	return !when.After(opts.Until)
+}
@@ -0,0 +1,137 @@
+package stores
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func seededClient(t *testing.T) *Client {
+	t.Helper()
+
+	ctx := context.Background()
+	c := newMemoryClient(ctx)
+	when := time.Date(2024, 5, 1, 19, 0, 0, 0, time.UTC)
+
+	c.memory.collection(MEMBERS).insert(bson.M{"_id": "1", "name": "one", "rank": int64(3), "merits": bson.A{}})
+	c.memory.collection(CONFIGS).insert(bson.M{"_id": "migrations", "name": "migrations"})
+	c.memory.collection(ATTENDANCE).insert(bson.M{"_id": "a", "members": bson.A{"1"}, "date_created": when})
+	// mongo gives activity object ids
+	c.memory.collection(ACTIVITY).insert(bson.M{"_id": primitive.NewObjectID(), "member_id": "1", "when": when})
+	c.memory.collection(ACTIVITY).insert(bson.M{"_id": primitive.NewObjectID(), "member_id": "1", "when": when.Add(48 * time.Hour)})
+
+	return c
+}
+
+func backup(t *testing.T, c *Client) *bytes.Reader {
+	t.Helper()
+
+	buf := &bytes.Buffer{}
+	if _, err := c.Backup(context.Background(), buf); err != nil {
+		t.Fatal(err)
+	}
+	return bytes.NewReader(buf.Bytes())
+}
+
+func TestBackupRestoreRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	source := seededClient(t)
+	archive := backup(t, source)
+
+	dest := newMemoryClient(ctx)
+	// restoring twice must replace, not duplicate
+	for n := 0; n < 2; n++ {
+		if _, err := archive.Seek(0, 0); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := dest.Restore(ctx, archive, RestoreOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, collection := range BackupCollections {
+		want := source.memory.collection(collection).all()
+		got := dest.memory.collection(collection).all()
+		if len(got) != len(want) {
+			t.Errorf("%s: got %d documents, want %d", collection, len(got), len(want))
+			continue
+		}
+		for i := range want {
+			if !equalValues(got[i], want[i]) {
+				t.Errorf("%s: got %v, want %v", collection, got[i], want[i])
+			}
+		}
+	}
+}
+
+func TestCopyToTwiceDoesNotDuplicate(t *testing.T) {
+	ctx := context.Background()
+	source := seededClient(t)
+	dest := newMemoryClient(ctx)
+
+	for n := 0; n < 2; n++ {
+		if _, err := source.CopyTo(ctx, dest); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := len(dest.memory.collection(ACTIVITY).all()); got != 2 {
+		t.Errorf("got %d activity documents, want 2", got)
+	}
+}
+
+func TestRestoreUntil(t *testing.T) {
+	ctx := context.Background()
+	archive := backup(t, seededClient(t))
+
+	dest := newMemoryClient(ctx)
+	report, err := dest.Restore(ctx, archive, RestoreOptions{Until: time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.Restored[ACTIVITY] != 1 || report.Skipped[ACTIVITY] != 1 {
+		t.Errorf("activity restored %d skipped %d, want 1 and 1", report.Restored[ACTIVITY], report.Skipped[ACTIVITY])
+	}
+	if report.Restored[MEMBERS] != 1 {
+		t.Errorf("members restored %d, want 1", report.Restored[MEMBERS])
+	}
+}
+
+func TestRestoreDryRunWritesNothing(t *testing.T) {
+	ctx := context.Background()
+	archive := backup(t, seededClient(t))
+
+	dest := newMemoryClient(ctx)
+	if _, err := dest.Restore(ctx, archive, RestoreOptions{DryRun: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, collection := range BackupCollections {
+		if got := len(dest.memory.collection(collection).all()); got != 0 {
+			t.Errorf("%s: dry run wrote %d documents", collection, got)
+		}
+	}
+}
+
+func TestValidateBackupRejectsTruncated(t *testing.T) {
+	archive := backup(t, seededClient(t))
+
+	full := make([]byte, archive.Len())
+	if _, err := archive.Read(full); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ValidateBackup(bytes.NewReader(full)); err != nil {
+		t.Fatalf("full archive: %v", err)
+	}
+
+	if _, err := ValidateBackup(bytes.NewReader(full[:len(full)/2])); !errors.Is(err, ErrInvalidBackup) {
+		t.Errorf("truncated archive: got %v, want ErrInvalidBackup", err)
+	}
+}
@@ -0,0 +1,273 @@
+package stores
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+// Config is how to reach the mongo server. Either set URI to a full connection
+// string, or describe the connection with the other fields. The options that
+// don't say where the server is, like TLS, read preference and pool sizes, can
+// be combined with a URI and override what it says.
+type Config struct {
+	URI string
+
+	Host     string
+	Port     int
+	SRV      bool // look the hosts up with a mongodb+srv DNS record
+	Username string
+	Password string
+
+	Database       string
+	AuthSource     string
+	AuthMechanism  string
+	ReplicaSet     string
+	ReadPreference string
+
+	TLS TLSConfig
+
+	MinPoolSize    uint64
+	MaxPoolSize    uint64
+	ConnectTimeout time.Duration
+}
+
+// TLSConfig are the TLS settings for the connection. Setting any of the files
+// turns TLS on.
+type TLSConfig struct {
+	Enabled bool
+	// CAFile is the certificate authority to trust the server with
+	CAFile string
+	// CertificateKeyFile holds both the client certificate and its key
+	CertificateKeyFile string
+	// CertificateFile and PrivateKeyFile are the client certificate and key
+	// when they are kept in separate files
+	CertificateFile    string
+	PrivateKeyFile     string
+	CertificateKeyPass string
+	// Insecure skips checking the server certificate and host name
+	Insecure bool
+}
+
+const (
+	defaultMongoHost      = "localhost"
+	defaultMongoPort      = 27017
+	defaultConnectTimeout = 5 * time.Second
+)
+
+// on is if any TLS option asks for TLS
+func (t TLSConfig) on() bool {
+	return t.Enabled || t.CAFile != "" || t.CertificateKeyFile != "" || t.CertificateFile != "" || t.Insecure
+}
+
+// Validate checks the config makes sense before trying to connect with it
+func (c Config) Validate() error {
+	if c.URI != "" {
+		if c.Host != "" || c.Port != 0 || c.Username != "" || c.Password != "" || c.SRV {
+			return errors.New("mongo uri can't be combined with host, port, srv, username or password")
+		}
+		if !strings.HasPrefix(c.URI, "mongodb://") && !strings.HasPrefix(c.URI, "mongodb+srv://") {
+			return errors.New("mongo uri must start with mongodb:// or mongodb+srv://")
+		}
+	}
+
+	if c.SRV && c.Port != 0 {
+		return errors.New("mongo srv connections can't have a port")
+	}
+
+	if c.Port < 0 || c.Port > 65535 {
+		return errors.Errorf("mongo port %d is out of range", c.Port)
+	}
+
+	if c.Password != "" && c.Username == "" {
+		return errors.New("mongo password is set without a username")
+	}
+
+	if c.ReadPreference != "" {
+		if _, err := readpref.ModeFromString(c.ReadPreference); err != nil {
+			return errors.Errorf("unknown mongo read preference %q", c.ReadPreference)
+		}
+	}
+
+	if c.MaxPoolSize != 0 && c.MinPoolSize > c.MaxPoolSize {
+		return errors.Errorf("mongo min pool size %d is bigger than the max pool size %d", c.MinPoolSize, c.MaxPoolSize)
+	}
+
+	if c.TLS.CertificateKeyFile != "" && (c.TLS.CertificateFile != "" || c.TLS.PrivateKeyFile != "") {
+		return errors.New("mongo tls certificate key file can't be combined with a separate certificate and private key")
+	}
+	if (c.TLS.CertificateFile == "") != (c.TLS.PrivateKeyFile == "") {
+		return errors.New("mongo tls certificate and private key files need to be set together")
+	}
+	for name, file := range map[string]string{
+		"ca":              c.TLS.CAFile,
+		"certificate key": c.TLS.CertificateKeyFile,
+		"certificate":     c.TLS.CertificateFile,
+		"private key":     c.TLS.PrivateKeyFile,
+	} {
+		if file == "" {
+			continue
+		}
+		if _, err := os.Stat(file); err != nil {
+			return errors.Wrapf(err, "mongo tls %s file", name)
+		}
+	}
+
+	return nil
+}
+
+// ConnectionURI builds the connection string for the config. Credentials and
+// options are escaped, so they can hold any character.
+func (c Config) ConnectionURI() (string, error) {
+	var u *url.URL
+	if c.URI != "" {
+		parsed, err := url.Parse(c.URI)
+		if err != nil {
+			return "", errors.Wrap(err, "parsing mongo uri")
+		}
+		u = parsed
+	} else {
+		u = &url.URL{Scheme: "mongodb", Path: "/"}
+
+		host := c.Host
+		if host == "" {
+			host = defaultMongoHost
+		}
+		if c.SRV {
+			u.Scheme = "mongodb+srv"
+			u.Host = host
+		} else {
+			port := c.Port
+			if port == 0 {
+				port = defaultMongoPort
+			}
+			u.Host = net.JoinHostPort(host, strconv.Itoa(port))
+		}
+
+		if c.Username != "" {
+			u.User = url.UserPassword(c.Username, c.Password)
+		}
+	}
+
+	query := u.Query()
+	set := func(key, value string) {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+	set("authSource", c.AuthSource)
+	set("authMechanism", c.AuthMechanism)
+	set("replicaSet", c.ReplicaSet)
+	set("readPreference", c.ReadPreference)
+	if c.TLS.on() {
+		query.Set("tls", "true")
+	}
+	set("tlsCAFile", c.TLS.CAFile)
+	set("tlsCertificateKeyFile", c.TLS.CertificateKeyFile)
+	set("tlsCertificateFile", c.TLS.CertificateFile)
+	set("tlsPrivateKeyFile", c.TLS.PrivateKeyFile)
+	set("tlsCertificateKeyFilePassword", c.TLS.CertificateKeyPass)
+	if c.TLS.Insecure {
+		query.Set("tlsInsecure", "true")
+	}
+	if c.MinPoolSize != 0 {
+		query.Set("minPoolSize", strconv.FormatUint(c.MinPoolSize, 10))
+	}
+	if c.MaxPoolSize != 0 {
+		query.Set("maxPoolSize", strconv.FormatUint(c.MaxPoolSize, 10))
+	}
+	u.RawQuery = query.Encode()
+
+	return u.String(), nil
+}
+
+// Redacted is the connection string with the secrets hidden, safe to log
+func (c Config) Redacted() string {
+	uri, err := c.ConnectionURI()
+	if err != nil {
+		return "invalid mongo uri"
+	}
+	return redactURI(uri)
+}
+
+func redactURI(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "invalid mongo uri"
+	}
+
+	query := u.Query()
+	for key := range query {
+		if strings.EqualFold(key, "tlsCertificateKeyFilePassword") || strings.EqualFold(key, "sslClientCertificateKeyPassword") {
+			query.Set(key, "xxxxx")
+		}
+	}
+	u.RawQuery = query.Encode()
+
+	return u.Redacted()
+}
+
+// clientOptions turns the config into validated driver options
+func (c Config) clientOptions() (*options.ClientOptions, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	uri, err := c.ConnectionURI()
+	if err != nil {
+		return nil, err
+	}
+
+	connectTimeout := c.ConnectTimeout
+	if connectTimeout == 0 {
+		connectTimeout = defaultConnectTimeout
+	}
+
+	opts := options.Client().ApplyURI(uri).SetConnectTimeout(connectTimeout)
+	if err := opts.Validate(); err != nil {
+		// the driver's errors can quote the uri, so don't pass them on as is
+		return nil, errors.Errorf("invalid mongo options for %s: %s", redactURI(uri), strings.ReplaceAll(err.Error(), uri, redactURI(uri)))
+	}
+
+	return opts, nil
+}
+
+// logFields are the effective connection options, without any secrets
+func logFields(opts *options.ClientOptions, database string) log.Fields {
+	fields := log.Fields{
+		"uri":      redactURI(opts.GetURI()),
+		"hosts":    strings.Join(opts.Hosts, ","),
+		"database": database,
+		"tls":      opts.TLSConfig != nil,
+	}
+	if opts.Auth != nil {
+		fields["username"] = opts.Auth.Username
+		fields["auth_source"] = opts.Auth.AuthSource
+		fields["auth_mechanism"] = opts.Auth.AuthMechanism
+	}
+	if opts.ReplicaSet != nil {
+		fields["replica_set"] = *opts.ReplicaSet
+	}
+	if opts.ReadPreference != nil {
+		fields["read_preference"] = opts.ReadPreference.Mode().String()
+	}
+	if opts.MinPoolSize != nil {
+		fields["min_pool_size"] = *opts.MinPoolSize
+	}
+	if opts.MaxPoolSize != nil {
+		fields["max_pool_size"] = *opts.MaxPoolSize
+	}
+	if opts.ConnectTimeout != nil {
+		fields["connect_timeout"] = fmt.Sprint(*opts.ConnectTimeout)
+	}
+	return fields
+}
@@ -2,39 +2,52 @@ package stores
 
 import (
 	"context"
+	"errors"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-type ConfigsStore struct {
+type mongoConfigsStore struct {
 	*store
 }
 
-func newConfigsStore(ctx context.Context, client *mongo.Client, database string) *ConfigsStore {
+func newConfigsStore(ctx context.Context, client *mongo.Client, database string) *mongoConfigsStore {
 	_ = client.Database(database).CreateCollection(ctx, string(CONFIGS))
 	s := &store{
 		Collection: client.Database(database).Collection(string(CONFIGS)),
-		ctx:        ctx,
 	}
-	return &ConfigsStore{s}
+	return &mongoConfigsStore{s}
 }
 
-func (s *ConfigsStore) Create(config any) error {
-	_, err := s.InsertOne(s.ctx, config)
-	return err
+func (s *mongoConfigsStore) Create(ctx context.Context, config any) error {
+	ctx, cancel := callContext(ctx)
+	defer cancel()
+
+	_, err := s.InsertOne(ctx, config)
+	return timeout(err)
 }
 
-func (s *ConfigsStore) Get(name string) *mongo.SingleResult {
+func (s *mongoConfigsStore) Get(ctx context.Context, name string) SingleResult {
+	ctx, cancel := callContext(ctx)
+	defer cancel()
+
 	filter := bson.D{{Key: "name", Value: name}}
-	return s.FindOne(s.ctx, filter)
+	return &mongoSingleResult{s.FindOne(ctx, filter)}
 }
 
-func (s *ConfigsStore) Upsert(name string, config any) error {
+func (s *mongoConfigsStore) Upsert(ctx context.Context, name string, config any) error {
+	ctx, cancel := callContext(ctx)
+	defer cancel()
+
 	opts := options.FindOneAndReplace().SetUpsert(true)
-	if err := s.FindOneAndReplace(s.ctx, bson.D{{Key: "name", Value: name}}, config, opts).Err(); err != nil {
-		return err
+	if err := s.FindOneAndReplace(ctx, bson.D{{Key: "name", Value: name}}, config, opts).Err(); err != nil {
+		// there is no previous document to return when the upsert inserts
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil
+		}
+		return timeout(err)
 	}
 	return nil
 }
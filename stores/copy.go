@@ -0,0 +1,37 @@
+package stores
+
+import (
+	"context"
+
+	"github.com/apex/log"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// CopyTo writes every document in c into another client, like from mongo to
+// a store file or back. Documents with the same id are replaced and anything
+// only in the destination is left alone, the same as a restore.
+func (c *Client) CopyTo(ctx context.Context, dest *Client) (map[Collection]int, error) {
+	logger := log.WithField("func", "stores.CopyTo")
+
+	counts := map[Collection]int{}
+	for _, collection := range BackupCollections {
+		to := dest.documents(collection)
+		if err := c.documents(collection).each(ctx, func(doc bson.M) error {
+			if err := to.restore(ctx, doc); err != nil {
+				return errors.Wrapf(err, "copying document %v", doc["_id"])
+			}
+			counts[collection]++
+			return nil
+		}); err != nil {
+			return counts, errors.Wrapf(err, "copying %s", collection)
+		}
+
+		logger.WithFields(log.Fields{
+			"collection": collection,
+			"count":      counts[collection],
+		}).Info("copied collection")
+	}
+
+	return counts, nil
+}
@@ -0,0 +1,200 @@
+package stores
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Change describes an update to a single document
+type Change struct {
+	Set   bson.M
+	Unset []string
+	// Push appends the value to the array field
+	Push bson.M
+}
+
+func (c *Change) IsEmpty() bool {
+	return c == nil || (len(c.Set) == 0 && len(c.Unset) == 0 && len(c.Push) == 0)
+}
+
+// update builds the mongo update document for the change
+func (c *Change) update() bson.D {
+	update := bson.D{}
+	if len(c.Set) > 0 {
+		update = append(update, bson.E{Key: "$set", Value: c.Set})
+	}
+	if len(c.Unset) > 0 {
+		unset := bson.D{}
+		for _, field := range c.Unset {
+			unset = append(unset, bson.E{Key: field, Value: ""})
+		}
+		update = append(update, bson.E{Key: "$unset", Value: unset})
+	}
+	if len(c.Push) > 0 {
+		update = append(update, bson.E{Key: "$push", Value: c.Push})
+	}
+	return update
+}
+
+// apply makes the change to an in-memory document. Like mongo, pushing onto a
+// field that is there but isn't an array, null included, fails without
+// changing anything.
+func (c *Change) apply(doc bson.M) error {
+	for k := range c.Push {
+		if existing, ok := doc[k]; ok {
+			if _, ok := asArray(existing); !ok {
+				return errors.Errorf("the field %q must be an array to push onto it", k)
+			}
+		}
+	}
+
+	if len(c.Set) > 0 {
+		set, err := toDocument(c.Set)
+		if err != nil {
+			return err
+		}
+		for k, v := range set {
+			doc[k] = v
+		}
+	}
+
+	for _, field := range c.Unset {
+		delete(doc, field)
+	}
+
+	if len(c.Push) > 0 {
+		push, err := toDocument(c.Push)
+		if err != nil {
+			return err
+		}
+		for k, v := range push {
+			arr, _ := asArray(doc[k])
+			doc[k] = append(append(bson.A{}, arr...), v)
+		}
+	}
+
+	return nil
+}
+
+// documents gives maintenance tasks, like migrations, raw access to the
+// documents of a collection no matter which backend is in use
+type documents interface {
+	each(ctx context.Context, fn func(doc bson.M) error) error
+	update(ctx context.Context, id any, change *Change) error
+	// restore puts the document back as it was, replacing any document with
+	// the same id
+	restore(ctx context.Context, doc bson.M) error
+}
+
+func (c *Client) documents(collection Collection) documents {
+	if c.memory != nil {
+		return &memoryDocuments{c.memory.collection(collection)}
+	}
+
+	return &mongoDocuments{
+		Collection: c.Client.Database(c.database).Collection(string(collection)),
+		timeSeries: collection == ACTIVITY,
+	}
+}
+
+type mongoDocuments struct {
+	*mongo.Collection
+
+	// time series collections can't have documents replaced, only inserted
+	timeSeries bool
+}
+
+func (d *mongoDocuments) each(ctx context.Context, fn func(doc bson.M) error) error {
+	cur, err := d.Find(ctx, bson.D{})
+	if err != nil {
+		return errors.Wrap(err, "finding documents")
+	}
+	defer cur.Close(ctx)
+
+	for cur.Next(ctx) {
+		doc := bson.M{}
+		if err := cur.Decode(&doc); err != nil {
+			return errors.Wrap(err, "decoding document")
+		}
+
+		if err := fn(doc); err != nil {
+			return err
+		}
+	}
+
+	return cur.Err()
+}
+
+func (d *mongoDocuments) update(ctx context.Context, id any, change *Change) error {
+	_, err := d.UpdateOne(ctx, bson.D{{Key: "_id", Value: id}}, change.update())
+	return err
+}
+
+func (d *mongoDocuments) restore(ctx context.Context, doc bson.M) error {
+	filter := bson.D{{Key: "_id", Value: doc["_id"]}}
+
+	if d.timeSeries {
+		err := d.FindOne(ctx, filter).Err()
+		if err == nil { // already there
+			return nil
+		}
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			return err
+		}
+
+		_, err = d.InsertOne(ctx, doc)
+		return err
+	}
+
+	_, err := d.ReplaceOne(ctx, filter, doc, options.Replace().SetUpsert(true))
+	return err
+}
+
+type memoryDocuments struct {
+	*memoryCollection
+}
+
+func (d *memoryDocuments) each(ctx context.Context, fn func(doc bson.M) error) error {
+	for _, doc := range d.all() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if err := fn(doc); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (d *memoryDocuments) update(_ context.Context, id any, change *Change) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, doc := range d.docs {
+		if equalValues(doc["_id"], id) {
+			if err := change.apply(doc); err != nil {
+				return err
+			}
+			d.touched()
+			return nil
+		}
+	}
+
+	return nil
+}
+
+func (d *memoryDocuments) restore(_ context.Context, doc bson.M) error {
+	doc, err := toDocument(doc)
+	if err != nil {
+		return err
+	}
+
+	d.replace("_id", doc["_id"], doc)
+	return nil
+}
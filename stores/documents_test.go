@@ -0,0 +1,43 @@
+package stores
+
+import (
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestChangePushNeedsAnArray(t *testing.T) {
+	tests := []struct {
+		name    string
+		doc     bson.M
+		wantErr bool
+		want    int
+	}{
+		{"missing field starts an array", bson.M{}, false, 1},
+		{"appends to an array", bson.M{"merits": bson.A{"a"}}, false, 2},
+		{"null fails like mongo", bson.M{"merits": nil}, true, 0},
+		{"not an array fails", bson.M{"merits": "a"}, true, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			change := &Change{Set: bson.M{"name": "changed"}, Push: bson.M{"merits": "b"}}
+
+			err := change.apply(tt.doc)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if _, ok := tt.doc["name"]; ok {
+					t.Error("a failed change was partly applied")
+				}
+				return
+			}
+
+			arr, _ := asArray(tt.doc["merits"])
+			if len(arr) != tt.want {
+				t.Errorf("got %d merits, want %d", len(arr), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package stores
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// QueryTimeout bounds every single store call. A call also stops early if the
+// context it was given runs out first.
+var QueryTimeout = 5 * time.Second
+
+// TimeoutError is returned when a store call runs out of time or is cancelled
+// before the database answers
+type TimeoutError struct {
+	Err error
+}
+
+func (e *TimeoutError) Error() string {
+	return "store timed out: " + e.Err.Error()
+}
+
+func (e *TimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// IsTimeout reports if the error is, or wraps, a TimeoutError
+func IsTimeout(err error) bool {
+	var t *TimeoutError
+	return errors.As(err, &t)
+}
+
+// callContext derives the context for a single store call
+func callContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, QueryTimeout)
+}
+
+// timeout turns deadline and cancellation errors into a TimeoutError so
+// callers don't need to know which backend or driver produced them
+func timeout(err error) error {
+	if err == nil || IsTimeout(err) {
+		return err
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) || mongo.IsTimeout(err) {
+		return &TimeoutError{Err: err}
+	}
+
+	return err
+}
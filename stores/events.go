@@ -0,0 +1,68 @@
+package stores
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type mongoEventsStore struct {
+	*store
+}
+
+func newEventsStore(ctx context.Context, client *mongo.Client, database string) *mongoEventsStore {
+	_ = client.Database(database).CreateCollection(ctx, string(EVENTS))
+	s := &store{
+		Collection: client.Database(database).Collection(string(EVENTS)),
+	}
+	return &mongoEventsStore{s}
+}
+
+func (s *mongoEventsStore) reconcileIndexes(ctx context.Context) []IndexStatus {
+	return s.store.reconcileIndexes(ctx, EVENTS, []Index{
+		// upcoming events and the event monitor
+		{Name: "status_start", Keys: bson.D{
+			{Key: "status", Value: 1},
+			{Key: "start", Value: 1},
+		}},
+		{Name: "scheduled_event_id", Keys: bson.D{
+			{Key: "scheduled_event_id", Value: 1},
+		}},
+	})
+}
+
+func (s *mongoEventsStore) Get(ctx context.Context, id string) SingleResult {
+	ctx, cancel := callContext(ctx)
+	defer cancel()
+
+	return &mongoSingleResult{s.FindOne(ctx, bson.D{{Key: "_id", Value: id}})}
+}
+
+func (s *mongoEventsStore) List(ctx context.Context, filter interface{}, limit int, page int) (Cursor, error) {
+	ctx, cancel := callContext(ctx)
+	defer cancel()
+
+	if filter == nil {
+		filter = bson.D{}
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "start", Value: 1}})
+	if limit > 0 {
+		if page == 0 {
+			page = 1
+		}
+		opts.SetSkip(int64((page - 1) * limit)).SetLimit(int64(limit))
+	}
+
+	return cursor(s.Find(ctx, filter, opts))
+}
+
+func (s *mongoEventsStore) Upsert(ctx context.Context, id string, event any) error {
+	ctx, cancel := callContext(ctx)
+	defer cancel()
+
+	_, err := s.ReplaceOne(ctx, bson.D{{Key: "_id", Value: id}}, event, options.Replace().SetUpsert(true))
+	return timeout(err)
+}
@@ -0,0 +1,169 @@
+package stores
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// FileSaveInterval is how long the file backend waits after a write before
+// saving, so a burst of writes only rewrites the file once
+var FileSaveInterval = time.Second
+
+// fileDatabase keeps the in-memory stores saved to a single file. The file is
+// a backup archive, so it can be validated and restored into mongo with the
+// backup and restore commands.
+type fileDatabase struct {
+	path   string
+	client *Client
+
+	saveMu sync.Mutex
+	dirty  chan struct{}
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+// NewFile creates a client backed by the in-memory stores that are loaded
+// from and saved to the file at path, so no database server is needed
+func NewFile(ctx context.Context, path string) (*Client, error) {
+	c, err := OpenFile(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	client = c
+	return client, nil
+}
+
+// OpenFile is NewFile without making the client the one Get returns
+func OpenFile(ctx context.Context, path string) (*Client, error) {
+	c := newMemoryClient(ctx)
+
+	if err := loadFile(ctx, c, path); err != nil {
+		return nil, err
+	}
+
+	f := &fileDatabase{
+		path:   path,
+		client: c,
+		dirty:  make(chan struct{}, 1),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	c.file = f
+	c.memory.onChange(f.changed)
+
+	go f.run()
+
+	return c, nil
+}
+
+// loadFile reads a saved file into the client, a missing file is an empty
+// store
+func loadFile(ctx context.Context, c *Client, path string) error {
+	logger := log.WithFields(log.Fields{
+		"func": "stores.loadFile",
+		"path": path,
+	})
+
+	fh, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			logger.Info("no store file yet, starting empty")
+			return nil
+		}
+		return errors.Wrap(err, "opening store file")
+	}
+	defer fh.Close()
+
+	report, err := readBackup(fh, RestoreOptions{assignIds: true}, func(collection Collection, doc bson.M) error {
+		return c.documents(collection).restore(ctx, doc)
+	})
+	if err != nil {
+		return errors.Wrapf(err, "loading store file %s", path)
+	}
+
+	logger.WithField("counts", report.Restored).Info("loaded store file")
+
+	return nil
+}
+
+// changed is called with a collection lock held, so it only flags the save
+func (f *fileDatabase) changed() {
+	select {
+	case f.dirty <- struct{}{}:
+	default:
+	}
+}
+
+func (f *fileDatabase) run() {
+	defer close(f.done)
+
+	for {
+		select {
+		case <-f.stop:
+			return
+		case <-f.dirty:
+		}
+
+		// let any other writes in the same burst land first
+		select {
+		case <-f.stop:
+			return
+		case <-time.After(FileSaveInterval):
+		}
+
+		if err := f.save(); err != nil {
+			log.WithError(err).WithField("path", f.path).Error("saving store file")
+		}
+	}
+}
+
+// save writes everything to a temporary file next to the store file and swaps
+// it in, so a crash part way through never leaves a broken file behind
+func (f *fileDatabase) save() error {
+	f.saveMu.Lock()
+	defer f.saveMu.Unlock()
+
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), filepath.Base(f.path)+".*.tmp")
+	if err != nil {
+		return errors.Wrap(err, "creating temporary store file")
+	}
+	defer os.Remove(tmp.Name()) // nothing to remove once it has been renamed
+
+	if _, err := f.client.Backup(context.Background(), tmp); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return errors.Wrap(err, "syncing store file")
+	}
+
+	if err := tmp.Close(); err != nil {
+		return errors.Wrap(err, "closing store file")
+	}
+
+	return errors.Wrap(os.Rename(tmp.Name(), f.path), "replacing store file")
+}
+
+// close stops saving in the background and saves one last time
+func (f *fileDatabase) close() error {
+	select {
+	case <-f.stop:
+		return nil
+	default:
+	}
+
+	close(f.stop)
+	<-f.done
+
+	return f.save()
+}
@@ -0,0 +1,119 @@
+package stores
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestFileReopensAfterWrites(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "store.db")
+
+	c, err := OpenFile(ctx, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	configs, _ := c.GetConfigsStore()
+	if err := configs.Upsert(ctx, migrationsConfigName, &migrationsConfig{
+		Name:    migrationsConfigName,
+		Applied: []AppliedMigration{{Version: 1, Description: "test", AppliedAt: time.Now().UTC()}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	activity, _ := c.GetActivityStore()
+	if err := activity.Create(ctx, bson.M{"member_id": "1", "when": time.Now().UTC(), "action": "join"}); err != nil {
+		t.Fatal(err)
+	}
+
+	c.Disconnect()
+
+	reopened, err := OpenFile(ctx, path)
+	if err != nil {
+		t.Fatalf("reopening the store file: %v", err)
+	}
+	defer reopened.Disconnect()
+
+	applied, err := reopened.AppliedMigrations(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != 1 {
+		t.Errorf("got %d applied migrations, want 1", len(applied))
+	}
+
+	if got := len(reopened.memory.collection(ACTIVITY).all()); got != 1 {
+		t.Errorf("got %d activity documents, want 1", got)
+	}
+}
+
+func TestFileLoadsDocumentsWithoutIds(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "store.db")
+
+	// files saved before every document was given an _id
+	old := newMemoryClient(ctx)
+	old.memory.collection(ACTIVITY).docs = []bson.M{{"member_id": "1", "action": "join"}}
+
+	fh, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := old.Backup(ctx, fh); err != nil {
+		t.Fatal(err)
+	}
+	if err := fh.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := OpenFile(ctx, path)
+	if err != nil {
+		t.Fatalf("opening the store file: %v", err)
+	}
+	defer c.Disconnect()
+
+	docs := c.memory.collection(ACTIVITY).all()
+	if len(docs) != 1 {
+		t.Fatalf("got %d activity documents, want 1", len(docs))
+	}
+	if _, ok := docs[0]["_id"]; !ok {
+		t.Error("loaded document was not given an _id")
+	}
+}
+
+func TestMemoryCreateGivesIds(t *testing.T) {
+	ctx := context.Background()
+	c := newMemoryClient(ctx)
+
+	activity, _ := c.GetActivityStore()
+	if err := activity.Create(ctx, bson.M{"member_id": "1"}); err != nil {
+		t.Fatal(err)
+	}
+	configs, _ := c.GetConfigsStore()
+	if err := configs.Upsert(ctx, "something", bson.M{"value": 1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := configs.Upsert(ctx, "something", bson.M{"value": 2}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, collection := range []Collection{ACTIVITY, CONFIGS} {
+		docs := c.memory.collection(collection).all()
+		if len(docs) != 1 {
+			t.Fatalf("%s: got %d documents, want 1", collection, len(docs))
+		}
+		if _, ok := docs[0]["_id"]; !ok {
+			t.Errorf("%s: document has no _id", collection)
+		}
+	}
+
+	if id := c.memory.collection(CONFIGS).all()[0]["_id"]; id != "something" {
+		t.Errorf("config _id is %v, want its name", id)
+	}
+}
@@ -0,0 +1,244 @@
+package stores
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/apex/log"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Index is an index a store needs to query efficiently
+type Index struct {
+	Name   string
+	Keys   bson.D
+	Unique bool
+}
+
+type IndexState string
+
+const (
+	// the index exists as declared
+	IndexOK IndexState = "ok"
+	// the index was missing and has been created
+	IndexCreated IndexState = "created"
+	// an index with the same name or keys exists with different options
+	IndexConflict IndexState = "conflict"
+	// the index was missing and creating it failed
+	IndexFailed IndexState = "failed"
+	// the index exists and is used but no store declares it
+	IndexUndeclared IndexState = "undeclared"
+	// the index exists, no store declares it and it has never been used
+	IndexUnused IndexState = "unused"
+)
+
+// IndexStatus is the state of a single index after reconciling
+type IndexStatus struct {
+	Collection Collection `json:"collection"`
+	Name       string     `json:"name"`
+	Keys       string     `json:"keys"`
+	State      IndexState `json:"state"`
+	Message    string     `json:"message,omitempty"`
+}
+
+// Healthy reports if the index needs no attention
+func (s IndexStatus) Healthy() bool {
+	return s.State == IndexOK || s.State == IndexCreated
+}
+
+// indexedStore is implemented by stores that declare indexes
+type indexedStore interface {
+	reconcileIndexes(ctx context.Context) []IndexStatus
+}
+
+type existingIndex struct {
+	Name   string `bson:"name"`
+	Key    bson.D `bson:"key"`
+	Unique bool   `bson:"unique"`
+}
+
+type indexStats struct {
+	Name     string `bson:"name"`
+	Accesses struct {
+		Ops int64 `bson:"ops"`
+	} `bson:"accesses"`
+}
+
+func keysString(keys bson.D) string {
+	parts := []string{}
+	for _, k := range keys {
+		parts = append(parts, fmt.Sprintf("%s_%v", k.Key, k.Value))
+	}
+	return strings.Join(parts, "_")
+}
+
+func sameKeys(a, b bson.D) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Key != b[i].Key || !equalValues(a[i].Value, b[i].Value) {
+			return false
+		}
+	}
+	return true
+}
+
+// reconcileIndexes creates any of the required indexes that are missing and
+// reports on the ones that conflict or that nothing declares. It never drops
+// an index, that is left to a person reading the report.
+func (s *store) reconcileIndexes(ctx context.Context, collection Collection, required []Index) []IndexStatus {
+	logger := log.WithFields(log.Fields{
+		"func":       "stores.reconcileIndexes",
+		"collection": collection,
+	})
+
+	statuses := []IndexStatus{}
+
+	existing := []existingIndex{}
+	cur, err := s.Indexes().List(ctx)
+	if err == nil {
+		err = cur.All(ctx, &existing)
+	}
+	if err != nil {
+		logger.WithError(err).Error("listing indexes")
+		for _, idx := range required {
+			statuses = append(statuses, IndexStatus{
+				Collection: collection,
+				Name:       idx.Name,
+				Keys:       keysString(idx.Keys),
+				State:      IndexFailed,
+				Message:    "listing indexes: " + err.Error(),
+			})
+		}
+		return statuses
+	}
+
+	declared := map[string]bool{"_id_": true}
+	for _, idx := range required {
+		status := IndexStatus{
+			Collection: collection,
+			Name:       idx.Name,
+			Keys:       keysString(idx.Keys),
+			State:      IndexOK,
+		}
+
+		var match, named *existingIndex
+		for i, e := range existing {
+			if sameKeys(e.Key, idx.Keys) {
+				match = &existing[i]
+				break
+			}
+			if e.Name == idx.Name {
+				named = &existing[i]
+			}
+		}
+
+		switch {
+		case match != nil && match.Unique != idx.Unique:
+			declared[match.Name] = true
+			status.State = IndexConflict
+			status.Message = fmt.Sprintf("index %s has the same keys but unique is %t", match.Name, match.Unique)
+		case match != nil:
+			declared[match.Name] = true
+		case named != nil:
+			declared[named.Name] = true
+			status.State = IndexConflict
+			status.Message = fmt.Sprintf("index named %s exists with keys %s", named.Name, keysString(named.Key))
+		default:
+			opts := options.Index().SetName(idx.Name)
+			if idx.Unique {
+				opts.SetUnique(true)
+			}
+			if _, err := s.Indexes().CreateOne(ctx, mongo.IndexModel{Keys: idx.Keys, Options: opts}); err != nil {
+				status.State = IndexFailed
+				status.Message = err.Error()
+			} else {
+				status.State = IndexCreated
+			}
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	// $indexStats is best effort, without it undeclared indexes can't be
+	// told apart from unused ones
+	usage := map[string]int64{}
+	if cur, err := s.Aggregate(ctx, bson.A{bson.D{{Key: "$indexStats", Value: bson.D{}}}}); err == nil {
+		stats := []indexStats{}
+		if err := cur.All(ctx, &stats); err == nil {
+			for _, stat := range stats {
+				usage[stat.Name] = stat.Accesses.Ops
+			}
+		}
+	}
+
+	for _, e := range existing {
+		if declared[e.Name] {
+			continue
+		}
+
+		status := IndexStatus{
+			Collection: collection,
+			Name:       e.Name,
+			Keys:       keysString(e.Key),
+			State:      IndexUndeclared,
+			Message:    "not declared by the store",
+		}
+		if ops, ok := usage[e.Name]; ok && ops == 0 {
+			status.State = IndexUnused
+			status.Message = "not declared by the store and never used"
+		}
+		statuses = append(statuses, status)
+	}
+
+	for _, status := range statuses {
+		slogger := logger.WithFields(log.Fields{
+			"index": status.Name,
+			"keys":  status.Keys,
+			"state": status.State,
+		})
+		switch status.State {
+		case IndexCreated:
+			slogger.Info("created index")
+		case IndexOK:
+			slogger.Debug("index ok")
+		case IndexFailed:
+			slogger.WithField("message", status.Message).Error("index needs attention")
+		default:
+			slogger.WithField("message", status.Message).Warn("index needs attention")
+		}
+	}
+
+	return statuses
+}
+
+// EnsureIndexes reconciles the indexes every store declares. The in-memory
+// backend has no indexes so it reports nothing.
+func (c *Client) EnsureIndexes(ctx context.Context) []IndexStatus {
+	statuses := []IndexStatus{}
+	for _, collection := range []Collection{MEMBERS, CONFIGS, ATTENDANCE, ACTIVITY, AUDIT, LEDGER, EVENTS} {
+		if st, ok := c.databases[collection].(indexedStore); ok {
+			statuses = append(statuses, st.reconcileIndexes(ctx)...)
+		}
+	}
+
+	c.indexesMu.Lock()
+	c.indexStatus = statuses
+	c.indexesMu.Unlock()
+
+	return statuses
+}
+
+// IndexStatus returns the report from the last time the indexes were reconciled
+func (c *Client) IndexStatus() []IndexStatus {
+	c.indexesMu.RLock()
+	defer c.indexesMu.RUnlock()
+
+	statuses := make([]IndexStatus, len(c.indexStatus))
+	copy(statuses, c.indexStatus)
+	return statuses
+}
@@ -0,0 +1,61 @@
+package stores
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type mongoLedgerStore struct {
+	*store
+}
+
+func newLedgerStore(ctx context.Context, client *mongo.Client, database string) *mongoLedgerStore {
+	_ = client.Database(database).CreateCollection(ctx, string(LEDGER))
+	s := &store{
+		Collection: client.Database(database).Collection(string(LEDGER)),
+	}
+	return &mongoLedgerStore{s}
+}
+
+func (s *mongoLedgerStore) reconcileIndexes(ctx context.Context) []IndexStatus {
+	return s.store.reconcileIndexes(ctx, LEDGER, []Index{
+		{Name: "member_id_account_when", Keys: bson.D{
+			{Key: "member_id", Value: 1},
+			{Key: "account", Value: 1},
+			{Key: "when", Value: -1},
+		}},
+		{Name: "related_id", Keys: bson.D{
+			{Key: "related_id", Value: 1},
+		}},
+	})
+}
+
+func (s *mongoLedgerStore) Create(ctx context.Context, entry any) error {
+	ctx, cancel := callContext(ctx)
+	defer cancel()
+
+	_, err := s.InsertOne(ctx, entry)
+	return timeout(err)
+}
+
+func (s *mongoLedgerStore) List(ctx context.Context, filter interface{}, limit int, page int) (Cursor, error) {
+	ctx, cancel := callContext(ctx)
+	defer cancel()
+
+	if filter == nil {
+		filter = bson.D{}
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "when", Value: -1}})
+	if limit > 0 {
+		if page == 0 {
+			page = 1
+		}
+		opts.SetSkip(int64((page - 1) * limit)).SetLimit(int64(limit))
+	}
+
+	return cursor(s.Find(ctx, filter, opts))
+}
@@ -8,21 +8,29 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-type MembersStore struct {
+type mongoMembersStore struct {
 	*store
 }
 
-func newMembersStore(ctx context.Context, client *mongo.Client, database string) *MembersStore {
+func newMembersStore(ctx context.Context, client *mongo.Client, database string) *mongoMembersStore {
 	_ = client.Database(database).CreateCollection(ctx, string(MEMBERS))
 	s := &store{
 		Collection: client.Database(database).Collection(string(MEMBERS)),
-		ctx:        ctx,
 	}
-	return &MembersStore{s}
+	return &mongoMembersStore{s}
 }
 
-func (s *MembersStore) Get(id string) (*mongo.Cursor, error) {
-	return s.Aggregate(s.ctx, bson.A{
+func (s *mongoMembersStore) reconcileIndexes(ctx context.Context) []IndexStatus {
+	return s.store.reconcileIndexes(ctx, MEMBERS, []Index{
+		{Name: "name", Keys: bson.D{{Key: "name", Value: 1}}},
+	})
+}
+
+func (s *mongoMembersStore) Get(ctx context.Context, id string) (Cursor, error) {
+	ctx, cancel := callContext(ctx)
+	defer cancel()
+
+	return cursor(s.Aggregate(ctx, bson.A{
 		bson.D{{Key: "$match", Value: bson.D{{Key: "_id", Value: id}}}},
 		bson.D{{Key: "$lookup", Value: bson.D{
 			{Key: "from", Value: "members"},
@@ -31,42 +39,40 @@ func (s *MembersStore) Get(id string) (*mongo.Cursor, error) {
 			{Key: "as", Value: "recruiter"},
 		}}},
 		bson.D{{Key: "$unwind", Value: bson.D{{Key: "path", Value: "$recruiter"}, {Key: "preserveNullAndEmptyArrays", Value: true}}}},
-	})
+	}))
 }
 
-func (s *MembersStore) GetRandom(max int, maxRank int) ([]map[string]interface{}, error) {
-	cur, err := s.Aggregate(s.ctx, bson.A{
+func (s *mongoMembersStore) GetRandom(ctx context.Context, max int, rankNames []string) ([]map[string]interface{}, error) {
+	ctx, cancel := callContext(ctx)
+	defer cancel()
+
+	cur, err := s.Aggregate(ctx, bson.A{
 		bson.D{
 			{Key: "$match",
 				Value: bson.D{
-					{Key: "rank",
-						Value: bson.D{
-							{Key: "$lte", Value: maxRank},
-							{Key: "$ne", Value: 0},
-						},
-					},
+					{Key: "rank", Value: bson.D{{Key: "$in", Value: rankNames}}},
+					{Key: "archived_at", Value: nil},
 				},
 			},
 		},
 		bson.D{{Key: "$sample", Value: bson.D{{Key: "size", Value: max}}}},
 	})
 	if err != nil {
-		return nil, err
+		return nil, timeout(err)
 	}
 
 	members := []map[string]interface{}{}
-	for cur.Next(s.ctx) {
-		member := map[string]interface{}{}
-		if err := cur.Decode(&member); err != nil {
-			return nil, err
-		}
-		members = append(members, member)
+	if err := cur.All(ctx, &members); err != nil {
+		return nil, timeout(err)
 	}
 
 	return members, nil
 }
 
-func (s *MembersStore) List(filter interface{}, page, max int) (*mongo.Cursor, error) {
+func (s *mongoMembersStore) List(ctx context.Context, filter interface{}, page, max int) (Cursor, error) {
+	ctx, cancel := callContext(ctx)
+	defer cancel()
+
 	pipeline := bson.A{
 		bson.D{{Key: "$match", Value: filter}},
 		bson.D{{Key: "$lookup", Value: bson.D{
@@ -85,17 +91,58 @@ func (s *MembersStore) List(filter interface{}, page, max int) (*mongo.Cursor, e
 		)
 	}
 
-	return s.Aggregate(s.ctx, pipeline)
+	return cursor(s.Aggregate(ctx, pipeline))
 }
 
-func (s *MembersStore) Upsert(id string, member any) error {
+func (s *mongoMembersStore) Upsert(ctx context.Context, id string, version int64, member any) error {
+	ctx, cancel := callContext(ctx)
+	defer cancel()
+
+	filter := bson.D{{Key: "_id", Value: id}}
+	if version == 0 { // members saved before versioning have no version
+		filter = append(filter, bson.E{Key: "$or", Value: bson.A{
+			bson.D{{Key: "version", Value: bson.D{{Key: "$exists", Value: false}}}},
+			bson.D{{Key: "version", Value: 0}},
+		}})
+	} else {
+		filter = append(filter, bson.E{Key: "version", Value: version})
+	}
+
 	opts := options.Replace().SetUpsert(true)
-	if _, err := s.ReplaceOne(s.ctx, bson.D{{Key: "_id", Value: id}}, member, opts); err != nil {
-		return err
+	if _, err := s.ReplaceOne(ctx, filter, member, opts); err != nil {
+		// the version didn't match so the upsert tried to insert a second
+		// document with the same id
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrVersionConflict
+		}
+		return timeout(err)
 	}
 	return nil
 }
 
-func (s *MembersStore) Delete(id string) error {
-	return s.FindOneAndDelete(s.ctx, bson.D{{Key: "_id", Value: id}}).Err()
+func (s *mongoMembersStore) Update(ctx context.Context, id string, change *Change) (int64, error) {
+	ctx, cancel := callContext(ctx)
+	defer cancel()
+
+	update := append(change.update(), bson.E{Key: "$inc", Value: bson.D{{Key: "version", Value: 1}}})
+
+	opts := options.FindOneAndUpdate().
+		SetReturnDocument(options.After).
+		SetProjection(bson.D{{Key: "version", Value: 1}})
+
+	result := struct {
+		Version int64 `bson:"version"`
+	}{}
+	if err := s.FindOneAndUpdate(ctx, bson.D{{Key: "_id", Value: id}}, update, opts).Decode(&result); err != nil {
+		return 0, timeout(err)
+	}
+
+	return result.Version, nil
+}
+
+func (s *mongoMembersStore) Delete(ctx context.Context, id string) error {
+	ctx, cancel := callContext(ctx)
+	defer cancel()
+
+	return timeout(s.FindOneAndDelete(ctx, bson.D{{Key: "_id", Value: id}}).Err())
 }
@@ -0,0 +1,348 @@
+package stores
+
+import (
+	"context"
+	"io"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var ErrDuplicateKey = errors.New("duplicate key")
+
+// memoryDatabase holds every in-memory collection so stores can resolve the
+// same joins the mongo pipelines do with $lookup
+type memoryDatabase struct {
+	collections map[Collection]*memoryCollection
+}
+
+type memoryCollection struct {
+	mu   sync.RWMutex
+	docs []bson.M
+
+	// changed is called after every write, the file backend uses it to know
+	// when to save
+	changed func()
+}
+
+func newMemoryDatabase() *memoryDatabase {
+	return &memoryDatabase{
+		collections: map[Collection]*memoryCollection{
+			MEMBERS:    {},
+			CONFIGS:    {},
+			ATTENDANCE: {},
+			ACTIVITY:   {},
+			AUDIT:      {},
+			LEDGER:     {},
+			EVENTS:     {},
+		},
+	}
+}
+
+func (db *memoryDatabase) collection(c Collection) *memoryCollection {
+	return db.collections[c]
+}
+
+// onChange sets the function called after any collection is written to
+func (db *memoryDatabase) onChange(fn func()) {
+	for _, c := range db.collections {
+		c.mu.Lock()
+		c.changed = fn
+		c.mu.Unlock()
+	}
+}
+
+// touched lets the database know the collection was written to. It is called
+// with the lock held so changed must not block.
+func (c *memoryCollection) touched() {
+	if c.changed != nil {
+		c.changed()
+	}
+}
+
+// toDocument normalizes anything bson can marshal into a bson.M so stored
+// documents hold the same types mongo would hand back
+func toDocument(v any) (bson.M, error) {
+	b, err := bson.Marshal(v)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshaling document")
+	}
+
+	doc := bson.M{}
+	if err := bson.Unmarshal(b, &doc); err != nil {
+		return nil, errors.Wrap(err, "unmarshaling document")
+	}
+
+	return doc, nil
+}
+
+// copyDocument returns a deep copy of the document so callers can never
+// mutate what is stored
+func copyDocument(doc bson.M) bson.M {
+	c, err := toDocument(doc)
+	if err != nil {
+		return bson.M{}
+	}
+	return c
+}
+
+// all returns a copy of every document in the collection
+func (c *memoryCollection) all() []bson.M {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	docs := make([]bson.M, 0, len(c.docs))
+	for _, doc := range c.docs {
+		docs = append(docs, copyDocument(doc))
+	}
+	return docs
+}
+
+// find returns a copy of the first document where key equals value
+func (c *memoryCollection) find(key string, value any) (bson.M, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, doc := range c.docs {
+		if equalValues(doc[key], value) {
+			return copyDocument(doc), true
+		}
+	}
+	return nil, false
+}
+
+// withId gives the document an _id if it doesn't have one, the same as mongo
+// does on insert. Backups need every document to have one.
+func withId(doc bson.M) bson.M {
+	if _, ok := doc["_id"]; !ok {
+		doc["_id"] = primitive.NewObjectID()
+	}
+	return doc
+}
+
+func (c *memoryCollection) insert(doc bson.M) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.docs = append(c.docs, withId(doc))
+	c.touched()
+}
+
+// replace swaps out the first document where key equals value, inserting the
+// document if none matched. It returns true if a document was replaced. A
+// replacement without an _id keeps the one it replaced.
+func (c *memoryCollection) replace(key string, value any, doc bson.M) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i, existing := range c.docs {
+		if equalValues(existing[key], value) {
+			if _, ok := doc["_id"]; !ok {
+				doc["_id"] = existing["_id"]
+			}
+			c.docs[i] = withId(doc)
+			c.touched()
+			return true
+		}
+	}
+
+	c.docs = append(c.docs, withId(doc))
+	c.touched()
+	return false
+}
+
+// set merges the fields into the first document where key equals value,
+// inserting a new document if none matched
+func (c *memoryCollection) set(key string, value any, fields bson.M) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, existing := range c.docs {
+		if equalValues(existing[key], value) {
+			for k, v := range fields {
+				existing[k] = v
+			}
+			c.touched()
+			return
+		}
+	}
+
+	fields[key] = value
+	c.docs = append(c.docs, fields)
+	c.touched()
+}
+
+// remove deletes the first document where key equals value. It returns false
+// if nothing was deleted.
+func (c *memoryCollection) remove(key string, value any) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i, doc := range c.docs {
+		if equalValues(doc[key], value) {
+			c.docs = append(c.docs[:i], c.docs[i+1:]...)
+			c.touched()
+			return true
+		}
+	}
+	return false
+}
+
+// filterDocuments returns the documents that match the mongo style filter
+func filterDocuments(docs []bson.M, filter interface{}) ([]bson.M, error) {
+	f, err := toFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	matched := []bson.M{}
+	for _, doc := range docs {
+		if matchDocument(doc, f) {
+			matched = append(matched, doc)
+		}
+	}
+	return matched, nil
+}
+
+// sortDocuments sorts the documents by a single field. Documents missing the
+// field sort first, the same as mongo.
+func sortDocuments(docs []bson.M, field string, ascending bool) {
+	sort.SliceStable(docs, func(i, j int) bool {
+		a, _ := lookupPath(docs[i], field)
+		b, _ := lookupPath(docs[j], field)
+		c := compareForSort(a, b)
+		if ascending {
+			return c < 0
+		}
+		return c > 0
+	})
+}
+
+// paginate skips and limits the documents, treating a limit of 0 as no limit
+func paginate(docs []bson.M, skip, limit int) []bson.M {
+	if skip > len(docs) {
+		return []bson.M{}
+	}
+	docs = docs[skip:]
+	if limit > 0 && limit < len(docs) {
+		docs = docs[:limit]
+	}
+	return docs
+}
+
+// toTime reads a date out of a stored value, accepting the RFC3339 strings
+// that end up in documents marshaled through json
+func toTime(v any) (time.Time, bool) {
+	switch t := v.(type) {
+	case primitive.DateTime:
+		return t.Time().UTC(), true
+	case time.Time:
+		return t.UTC(), true
+	case string:
+		parsed, err := time.Parse(time.RFC3339, t)
+		if err != nil {
+			return time.Time{}, false
+		}
+		return parsed.UTC(), true
+	}
+	return time.Time{}, false
+}
+
+// memoryCursor walks a fixed set of documents the same way a *mongo.Cursor
+// walks a result batch
+type memoryCursor struct {
+	docs    []bson.M
+	current bson.M
+	pos     int
+	err     error
+}
+
+func newMemoryCursor(docs []bson.M) *memoryCursor {
+	return &memoryCursor{docs: docs}
+}
+
+func (c *memoryCursor) Next(ctx context.Context) bool {
+	if err := ctx.Err(); err != nil {
+		c.err = timeout(err)
+		return false
+	}
+
+	if c.pos >= len(c.docs) {
+		c.current = nil
+		return false
+	}
+
+	c.current = c.docs[c.pos]
+	c.pos++
+	return true
+}
+
+func (c *memoryCursor) Decode(val interface{}) error {
+	if c.current == nil {
+		return io.EOF
+	}
+
+	b, err := bson.Marshal(c.current)
+	if err != nil {
+		return err
+	}
+	return bson.Unmarshal(b, val)
+}
+
+func (c *memoryCursor) All(ctx context.Context, results interface{}) error {
+	resultsVal := reflect.ValueOf(results)
+	if resultsVal.Kind() != reflect.Ptr || resultsVal.Elem().Kind() != reflect.Slice {
+		return errors.New("results argument must be a pointer to a slice")
+	}
+
+	sliceVal := resultsVal.Elem()
+	elemType := sliceVal.Type().Elem()
+
+	for c.Next(ctx) {
+		elem := reflect.New(elemType)
+		if err := c.Decode(elem.Interface()); err != nil {
+			return err
+		}
+		sliceVal = reflect.Append(sliceVal, elem.Elem())
+	}
+	resultsVal.Elem().Set(sliceVal)
+
+	return c.err
+}
+
+func (c *memoryCursor) Close(_ context.Context) error {
+	c.docs = nil
+	c.current = nil
+	return nil
+}
+
+func (c *memoryCursor) Err() error {
+	return c.err
+}
+
+// memorySingleResult mirrors *mongo.SingleResult for the in-memory stores
+type memorySingleResult struct {
+	doc bson.M
+	err error
+}
+
+func (r *memorySingleResult) Decode(v interface{}) error {
+	if r.err != nil {
+		return r.err
+	}
+
+	b, err := bson.Marshal(r.doc)
+	if err != nil {
+		return err
+	}
+	return bson.Unmarshal(b, v)
+}
+
+func (r *memorySingleResult) Err() error {
+	return r.err
+}
@@ -0,0 +1,32 @@
+package stores
+
+import "context"
+
+type memoryActivityStore struct {
+	db *memoryDatabase
+}
+
+func newMemoryActivityStore(db *memoryDatabase) *memoryActivityStore {
+	return &memoryActivityStore{db: db}
+}
+
+func (s *memoryActivityStore) Create(_ context.Context, activity any) error {
+	doc, err := toDocument(activity)
+	if err != nil {
+		return err
+	}
+
+	s.db.collection(ACTIVITY).insert(doc)
+	return nil
+}
+
+func (s *memoryActivityStore) List(_ context.Context, filter interface{}) (Cursor, error) {
+	docs, err := filterDocuments(s.db.collection(ACTIVITY).all(), filter)
+	if err != nil {
+		return nil, err
+	}
+
+	sortDocuments(docs, "when", true)
+
+	return newMemoryCursor(docs), nil
+}
@@ -0,0 +1,176 @@
+package stores
+
+import (
+	"context"
+
+	"math"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type memoryAttendanceStore struct {
+	db *memoryDatabase
+}
+
+func newMemoryAttendanceStore(db *memoryDatabase) *memoryAttendanceStore {
+	return &memoryAttendanceStore{db: db}
+}
+
+func (s *memoryAttendanceStore) collection() *memoryCollection {
+	return s.db.collection(ATTENDANCE)
+}
+
+func (s *memoryAttendanceStore) Create(_ context.Context, attendance any) error {
+	doc, err := toDocument(attendance)
+	if err != nil {
+		return err
+	}
+
+	if id, ok := doc["_id"]; ok {
+		if _, exists := s.collection().find("_id", id); exists {
+			return ErrDuplicateKey
+		}
+	}
+
+	s.collection().insert(doc)
+	return nil
+}
+
+// withMembers resolves the member ids on the record into member documents the
+// same way the $lookup stages in the mongo pipeline do. Records whose
+// submitter no longer exists are dropped by the $unwind, so false is returned
+// for them.
+func (s *memoryAttendanceStore) withMembers(doc bson.M) (bson.M, bool) {
+	members := s.db.collection(MEMBERS)
+
+	lookupMany := func(field string) {
+		ids, _ := asArray(doc[field])
+		found := bson.A{}
+		for _, id := range ids {
+			if member, ok := members.find("_id", id); ok {
+				found = append(found, member)
+			}
+		}
+		doc[field] = found
+	}
+
+	lookupMany("with_issues")
+	lookupMany("members")
+
+	submittedBy, ok := members.find("_id", doc["submitted_by"])
+	if !ok {
+		return nil, false
+	}
+	doc["submitted_by"] = submittedBy
+
+	return doc, true
+}
+
+func (s *memoryAttendanceStore) Get(_ context.Context, id string) (Cursor, error) {
+	doc, ok := s.collection().find("_id", id)
+	if !ok {
+		return newMemoryCursor(nil), nil
+	}
+
+	doc, ok = s.withMembers(doc)
+	if !ok {
+		return newMemoryCursor(nil), nil
+	}
+
+	return newMemoryCursor([]bson.M{doc}), nil
+}
+
+func (s *memoryAttendanceStore) List(_ context.Context, filter interface{}, limit int, page int) (Cursor, error) {
+	docs := []bson.M{}
+	for _, doc := range s.collection().all() {
+		if doc, ok := s.withMembers(doc); ok {
+			docs = append(docs, doc)
+		}
+	}
+
+	docs, err := filterDocuments(docs, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	sortDocuments(docs, "date_created", false)
+
+	if limit > 0 {
+		if page == 0 {
+			page = 1
+		}
+		docs = paginate(docs, (page-1)*limit, limit)
+	}
+
+	return newMemoryCursor(docs), nil
+}
+
+func (s *memoryAttendanceStore) ListRecorded(_ context.Context, memberId string) (Cursor, error) {
+	recorded, err := filterDocuments(s.collection().all(), bson.D{{Key: "recorded", Value: true}, {Key: "members", Value: memberId}})
+	if err != nil {
+		return nil, err
+	}
+
+	docs := []bson.M{}
+	for _, doc := range recorded {
+		if doc, ok := s.withMembers(doc); ok {
+			docs = append(docs, doc)
+		}
+	}
+
+	sortDocuments(docs, "date_created", true)
+
+	return newMemoryCursor(docs), nil
+}
+
+// GetCount mirrors the mongo aggregation: recorded records with the member are
+// sorted oldest first and each record after the first is compared to the one
+// before it. Records within 8 hours of the previous one are overlaps and don't
+// count, the rest count once per hour they were created in.
+func (s *memoryAttendanceStore) GetCount(_ context.Context, memberId string) (int, error) {
+	docs, err := filterDocuments(s.collection().all(), bson.D{
+		{Key: "recorded", Value: true},
+		{Key: "members", Value: bson.D{{Key: "$in", Value: bson.A{memberId}}}},
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	sortDocuments(docs, "date_created", true)
+
+	groups := map[string]bool{}
+	for i := 1; i < len(docs); i++ {
+		current, ok := toTime(docs[i]["date_created"])
+		if !ok {
+			continue
+		}
+		prev, ok := toTime(docs[i-1]["date_created"])
+		if !ok {
+			continue
+		}
+
+		// mongo's $round rounds half to even
+		if math.RoundToEven(current.Sub(prev).Hours()) <= 8 {
+			continue
+		}
+
+		groups[current.Format("2006-01-02-15")] = true
+	}
+
+	return len(groups), nil
+}
+
+func (s *memoryAttendanceStore) Upsert(_ context.Context, id string, attendance any) error {
+	doc, err := toDocument(attendance)
+	if err != nil {
+		return err
+	}
+
+	s.collection().set("_id", id, doc)
+	return nil
+}
+
+func (s *memoryAttendanceStore) Delete(_ context.Context, id string) error {
+	s.collection().remove("_id", id)
+	return nil
+}
@@ -0,0 +1,153 @@
+package stores
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// TestMemoryGetCount follows the mongo aggregation: records are compared to
+// the one before, the first never counts, and records within 8 hours (rounded
+// half to even) of the previous one are overlaps
+func TestMemoryGetCount(t *testing.T) {
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		offsets []time.Duration
+		want    int
+	}{
+		{"no records", nil, 0},
+		{"first record never counts", []time.Duration{0}, 0},
+		{"apart", []time.Duration{0, 10 * time.Hour}, 1},
+		{"exactly 8 hours is an overlap", []time.Duration{0, 8 * time.Hour}, 0},
+		{"8.5 hours rounds to even", []time.Duration{0, 8*time.Hour + 30*time.Minute}, 0},
+		{"8.6 hours rounds up", []time.Duration{0, 8*time.Hour + 36*time.Minute}, 1},
+		{"9.5 hours rounds to even", []time.Duration{0, 9*time.Hour + 30*time.Minute}, 1},
+		{"overlap after a counted record", []time.Duration{0, 10 * time.Hour, 10*time.Hour + 30*time.Minute}, 1},
+		{"several", []time.Duration{0, 24 * time.Hour, 48 * time.Hour, 72 * time.Hour}, 3},
+		{"out of order", []time.Duration{48 * time.Hour, 0, 24 * time.Hour}, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			store := newMemoryAttendanceStore(newMemoryDatabase())
+
+			for i, offset := range tt.offsets {
+				if err := store.Create(ctx, bson.M{
+					"_id":          fmt.Sprintf("record-%d", i),
+					"recorded":     true,
+					"members":      bson.A{"1", "2"},
+					"date_created": start.Add(offset),
+				}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			// neither unrecorded records nor other members' records count
+			if err := store.Create(ctx, bson.M{
+				"_id":          "unrecorded",
+				"recorded":     false,
+				"members":      bson.A{"1"},
+				"date_created": start.Add(1000 * time.Hour),
+			}); err != nil {
+				t.Fatal(err)
+			}
+			if err := store.Create(ctx, bson.M{
+				"_id":          "someone else",
+				"recorded":     true,
+				"members":      bson.A{"3"},
+				"date_created": start.Add(2000 * time.Hour),
+			}); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := store.GetCount(ctx, "1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemoryAttendanceListResolvesMembers(t *testing.T) {
+	ctx := context.Background()
+	db := newMemoryDatabase()
+	store := newMemoryAttendanceStore(db)
+
+	db.collection(MEMBERS).insert(bson.M{"_id": "1", "name": "one"})
+	db.collection(MEMBERS).insert(bson.M{"_id": "2", "name": "two"})
+
+	for id, members := range map[string]bson.A{"a": {"1"}, "b": {"2"}, "c": {"1", "2"}} {
+		if err := store.Create(ctx, bson.M{"_id": id, "submitted_by": "1", "members": members, "recorded": true}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// a record whose submitter is gone is dropped like the $unwind does
+	if err := store.Create(ctx, bson.M{"_id": "d", "submitted_by": "gone", "members": bson.A{"1"}, "recorded": true}); err != nil {
+		t.Fatal(err)
+	}
+
+	cur, err := store.List(ctx, bson.D{{Key: "members._id", Value: "2"}}, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	records := []bson.M{}
+	if err := cur.All(ctx, &records); err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("got %d records, want 2", len(records))
+	}
+	for _, record := range records {
+		if record["_id"] == "a" {
+			t.Errorf("record a doesn't have member 2")
+		}
+	}
+}
+
+func TestMemoryListRecorded(t *testing.T) {
+	ctx := context.Background()
+	db := newMemoryDatabase()
+	store := newMemoryAttendanceStore(db)
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	db.collection(MEMBERS).insert(bson.M{"_id": "1", "name": "one"})
+	db.collection(MEMBERS).insert(bson.M{"_id": "2", "name": "two"})
+
+	for _, record := range []bson.M{
+		{"_id": "later", "recorded": true, "members": bson.A{"1", "2"}, "date_created": start.Add(time.Hour)},
+		{"_id": "earlier", "recorded": true, "members": bson.A{"1"}, "date_created": start},
+		{"_id": "unrecorded", "recorded": false, "members": bson.A{"1"}, "date_created": start},
+		{"_id": "someone else", "recorded": true, "members": bson.A{"2"}, "date_created": start},
+	} {
+		record["submitted_by"] = "1"
+		if err := store.Create(ctx, record); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cur, err := store.ListRecorded(ctx, "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	records := []bson.M{}
+	if err := cur.All(ctx, &records); err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || records[0]["_id"] != "earlier" || records[1]["_id"] != "later" {
+		t.Fatalf("got %v, want earlier then later", records)
+	}
+	if members, _ := records[1]["members"].(bson.A); len(members) != 2 {
+		t.Errorf("members weren't looked up: %v", records[1]["members"])
+	}
+}
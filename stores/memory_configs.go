@@ -0,0 +1,63 @@
+package stores
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type memoryConfigsStore struct {
+	db *memoryDatabase
+}
+
+func newMemoryConfigsStore(db *memoryDatabase) *memoryConfigsStore {
+	return &memoryConfigsStore{db: db}
+}
+
+func (s *memoryConfigsStore) collection() *memoryCollection {
+	return s.db.collection(CONFIGS)
+}
+
+func (s *memoryConfigsStore) Create(_ context.Context, config any) error {
+	doc, err := toDocument(config)
+	if err != nil {
+		return err
+	}
+	// configs are looked up by name, so it makes a stable id
+	if name, ok := doc["name"]; ok {
+		if _, ok := doc["_id"]; !ok {
+			doc["_id"] = name
+		}
+	}
+
+	s.collection().insert(doc)
+	return nil
+}
+
+func (s *memoryConfigsStore) Get(_ context.Context, name string) SingleResult {
+	doc, ok := s.collection().find("name", name)
+	if !ok {
+		return &memorySingleResult{err: mongo.ErrNoDocuments}
+	}
+	return &memorySingleResult{doc: doc}
+}
+
+func (s *memoryConfigsStore) Upsert(_ context.Context, name string, config any) error {
+	doc, err := toDocument(config)
+	if err != nil {
+		return err
+	}
+	if _, ok := doc["name"]; !ok {
+		doc["name"] = name
+	}
+	if _, ok := doc["_id"]; !ok {
+		if existing, ok := s.collection().find("name", name); ok {
+			doc["_id"] = existing["_id"]
+		} else {
+			doc["_id"] = name
+		}
+	}
+
+	s.collection().replace("name", name, doc)
+	return nil
+}
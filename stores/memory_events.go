@@ -0,0 +1,56 @@
+package stores
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type memoryEventsStore struct {
+	db *memoryDatabase
+}
+
+func newMemoryEventsStore(db *memoryDatabase) *memoryEventsStore {
+	return &memoryEventsStore{db: db}
+}
+
+func (s *memoryEventsStore) collection() *memoryCollection {
+	return s.db.collection(EVENTS)
+}
+
+func (s *memoryEventsStore) Get(_ context.Context, id string) SingleResult {
+	doc, ok := s.collection().find("_id", id)
+	if !ok {
+		return &memorySingleResult{err: mongo.ErrNoDocuments}
+	}
+	return &memorySingleResult{doc: doc}
+}
+
+func (s *memoryEventsStore) List(_ context.Context, filter interface{}, limit int, page int) (Cursor, error) {
+	docs, err := filterDocuments(s.collection().all(), filter)
+	if err != nil {
+		return nil, err
+	}
+
+	sortDocuments(docs, "start", true)
+
+	if limit > 0 {
+		if page == 0 {
+			page = 1
+		}
+		docs = paginate(docs, (page-1)*limit, limit)
+	}
+
+	return newMemoryCursor(docs), nil
+}
+
+func (s *memoryEventsStore) Upsert(_ context.Context, id string, event any) error {
+	doc, err := toDocument(event)
+	if err != nil {
+		return err
+	}
+	doc["_id"] = id
+
+	s.collection().replace("_id", id, doc)
+	return nil
+}
@@ -0,0 +1,429 @@
+package stores
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// toFilter normalizes a mongo style filter (bson.D, bson.M, nil, ...) into a
+// bson.M with the same value types as stored documents
+func toFilter(filter interface{}) (bson.M, error) {
+	if filter == nil {
+		return bson.M{}, nil
+	}
+
+	f, err := toDocument(filter)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading filter")
+	}
+	return f, nil
+}
+
+// asDocument converts the different embedded document types bson can decode
+// into a bson.M
+func asDocument(v any) (bson.M, bool) {
+	switch d := v.(type) {
+	case bson.M:
+		return d, true
+	case map[string]interface{}:
+		return bson.M(d), true
+	case bson.D:
+		return d.Map(), true
+	}
+	return nil, false
+}
+
+// asArray converts the different array types bson can decode into a bson.A
+func asArray(v any) (bson.A, bool) {
+	switch a := v.(type) {
+	case bson.A:
+		return a, true
+	case []interface{}:
+		return bson.A(a), true
+	case []string:
+		arr := bson.A{}
+		for _, s := range a {
+			arr = append(arr, s)
+		}
+		return arr, true
+	}
+	return nil, false
+}
+
+// lookupPath finds the value at a dotted path in the document
+func lookupPath(doc bson.M, path string) (any, bool) {
+	return lookupParts(doc, strings.Split(path, "."))
+}
+
+func lookupParts(current any, parts []string) (any, bool) {
+	if len(parts) == 0 {
+		return current, true
+	}
+	part, rest := parts[0], parts[1:]
+
+	if arr, ok := asArray(current); ok {
+		// a number indexes into an array, like "members.0"
+		if i, err := strconv.Atoi(part); err == nil {
+			if i < 0 || i >= len(arr) {
+				return nil, false
+			}
+			return lookupParts(arr[i], rest)
+		}
+
+		// anything else looks into every document in the array, like
+		// "members._id", and matches if any of them do
+		found := bson.A{}
+		for _, elem := range arr {
+			if _, ok := asDocument(elem); !ok {
+				continue
+			}
+			value, ok := lookupParts(elem, parts)
+			if !ok {
+				continue
+			}
+			if values, ok := asArray(value); ok {
+				found = append(found, values...)
+				continue
+			}
+			found = append(found, value)
+		}
+		return found, len(found) > 0
+	}
+
+	d, ok := asDocument(current)
+	if !ok {
+		return nil, false
+	}
+	value, ok := d[part]
+	if !ok {
+		return nil, false
+	}
+	return lookupParts(value, rest)
+}
+
+// matchDocument reports if the document satisfies the filter. It supports the
+// subset of the mongo query language this bot uses.
+func matchDocument(doc bson.M, filter bson.M) bool {
+	for key, cond := range filter {
+		switch key {
+		case "$and":
+			subs, _ := asArray(cond)
+			for _, sub := range subs {
+				f, _ := asDocument(sub)
+				if !matchDocument(doc, f) {
+					return false
+				}
+			}
+		case "$or":
+			subs, _ := asArray(cond)
+			matched := false
+			for _, sub := range subs {
+				f, _ := asDocument(sub)
+				if matchDocument(doc, f) {
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return false
+			}
+		case "$nor":
+			subs, _ := asArray(cond)
+			for _, sub := range subs {
+				f, _ := asDocument(sub)
+				if matchDocument(doc, f) {
+					return false
+				}
+			}
+		default:
+			value, exists := lookupPath(doc, key)
+			if !matchCondition(value, exists, cond) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// matchCondition checks a single field against either a literal value or a
+// document of operators
+func matchCondition(value any, exists bool, cond any) bool {
+	ops, ok := asDocument(cond)
+	if !ok || !isOperatorDocument(ops) {
+		return matchEquals(value, exists, cond)
+	}
+
+	for op, arg := range ops {
+		switch op {
+		case "$eq":
+			if !matchEquals(value, exists, arg) {
+				return false
+			}
+		case "$ne":
+			if matchEquals(value, exists, arg) {
+				return false
+			}
+		case "$gt", "$gte", "$lt", "$lte":
+			if !exists || !anyValue(value, func(v any) bool { return compareOp(op, v, arg) }) {
+				return false
+			}
+		case "$in":
+			list, _ := asArray(arg)
+			if !matchIn(value, exists, list) {
+				return false
+			}
+		case "$nin":
+			list, _ := asArray(arg)
+			if matchIn(value, exists, list) {
+				return false
+			}
+		case "$exists":
+			want, _ := arg.(bool)
+			if exists != want {
+				return false
+			}
+		case "$not":
+			if matchCondition(value, exists, arg) {
+				return false
+			}
+		case "$regex":
+			pattern, _ := arg.(string)
+			if options, ok := ops["$options"].(string); ok && options != "" {
+				pattern = "(?" + options + ")" + pattern
+			}
+			re, err := regexp.Compile(pattern)
+			if err != nil || !exists {
+				return false
+			}
+			if !anyValue(value, func(v any) bool {
+				s, ok := v.(string)
+				return ok && re.MatchString(s)
+			}) {
+				return false
+			}
+		case "$options":
+		case "$size":
+			arr, ok := asArray(value)
+			if !ok || compareValues(len(arr), arg) != 0 {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+func isOperatorDocument(d bson.M) bool {
+	if len(d) == 0 {
+		return false
+	}
+	for k := range d {
+		if !strings.HasPrefix(k, "$") {
+			return false
+		}
+	}
+	return true
+}
+
+// matchEquals follows mongo equality, where a missing field equals null and an
+// array field matches if any element matches
+func matchEquals(value any, exists bool, target any) bool {
+	if target == nil {
+		return !exists || value == nil
+	}
+	if !exists {
+		return false
+	}
+	if equalValues(value, target) {
+		return true
+	}
+	if arr, ok := asArray(value); ok {
+		for _, v := range arr {
+			if equalValues(v, target) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func matchIn(value any, exists bool, list bson.A) bool {
+	for _, target := range list {
+		if matchEquals(value, exists, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// anyValue runs the check against the value, or each element if the value is
+// an array
+func anyValue(value any, check func(v any) bool) bool {
+	if arr, ok := asArray(value); ok {
+		for _, v := range arr {
+			if check(v) {
+				return true
+			}
+		}
+		return false
+	}
+	return check(value)
+}
+
+func compareOp(op string, a, b any) bool {
+	if !sameKind(a, b) {
+		return false
+	}
+
+	c := compareValues(a, b)
+	switch op {
+	case "$gt":
+		return c > 0
+	case "$gte":
+		return c >= 0
+	case "$lt":
+		return c < 0
+	case "$lte":
+		return c <= 0
+	}
+	return false
+}
+
+// equalValues compares two scalar, array or document values
+func equalValues(a, b any) bool {
+	if aArr, ok := asArray(a); ok {
+		bArr, ok := asArray(b)
+		if !ok || len(aArr) != len(bArr) {
+			return false
+		}
+		for i := range aArr {
+			if !equalValues(aArr[i], bArr[i]) {
+				return false
+			}
+		}
+		return true
+	}
+
+	if aDoc, ok := asDocument(a); ok {
+		bDoc, ok := asDocument(b)
+		if !ok || len(aDoc) != len(bDoc) {
+			return false
+		}
+		for k, v := range aDoc {
+			if !equalValues(v, bDoc[k]) {
+				return false
+			}
+		}
+		return true
+	}
+
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+
+	return sameKind(a, b) && compareValues(a, b) == 0
+}
+
+type valueKind int
+
+const (
+	kindUnknown valueKind = iota
+	kindNull
+	kindNumber
+	kindString
+	kindBool
+	kindDate
+	kindObjectID
+)
+
+func kindOf(v any) valueKind {
+	switch v.(type) {
+	case nil:
+		return kindNull
+	case int, int32, int64, float32, float64:
+		return kindNumber
+	case string:
+		return kindString
+	case bool:
+		return kindBool
+	case primitive.DateTime, time.Time:
+		return kindDate
+	case primitive.ObjectID:
+		return kindObjectID
+	}
+	return kindUnknown
+}
+
+func sameKind(a, b any) bool {
+	ka := kindOf(a)
+	return ka != kindUnknown && ka == kindOf(b)
+}
+
+func toFloat(v any) float64 {
+	switch n := v.(type) {
+	case int:
+		return float64(n)
+	case int32:
+		return float64(n)
+	case int64:
+		return float64(n)
+	case float32:
+		return float64(n)
+	case float64:
+		return n
+	}
+	return 0
+}
+
+// compareValues orders two values of the same kind
+func compareValues(a, b any) int {
+	switch kindOf(a) {
+	case kindNumber:
+		x, y := toFloat(a), toFloat(b)
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+	case kindString:
+		return strings.Compare(a.(string), b.(string))
+	case kindBool:
+		x, y := a.(bool), b.(bool)
+		switch {
+		case x == y:
+			return 0
+		case !x:
+			return -1
+		}
+		return 1
+	case kindDate:
+		x, _ := toTime(a)
+		y, _ := toTime(b)
+		return x.Compare(y)
+	case kindObjectID:
+		x, y := a.(primitive.ObjectID), b.(primitive.ObjectID)
+		return bytes.Compare(x[:], y[:])
+	}
+	return 0
+}
+
+// compareForSort orders values of any kind, putting null and missing values
+// first like mongo does
+func compareForSort(a, b any) int {
+	ka, kb := kindOf(a), kindOf(b)
+	if ka != kb {
+		return int(ka) - int(kb)
+	}
+	return compareValues(a, b)
+}
@@ -0,0 +1,123 @@
+package stores
+
+import (
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestMatchDocument(t *testing.T) {
+	now := time.Date(2024, 5, 1, 19, 0, 0, 0, time.UTC)
+	id := primitive.NewObjectID()
+
+	doc := bson.M{
+		"_id":      id,
+		"name":     "Jo",
+		"rank":     3,
+		"tags":     bson.A{"pilot", "miner"},
+		"members":  bson.A{bson.M{"_id": "1"}, bson.M{"_id": "2"}},
+		"rsi":      bson.M{"handle": "jo_rsi", "validated": true},
+		"empty":    nil,
+		"when":     now,
+		"recorded": false,
+	}
+
+	tests := []struct {
+		name   string
+		filter interface{}
+		want   bool
+	}{
+		{"empty filter", bson.D{}, true},
+		{"nil filter", nil, true},
+		{"equal string", bson.D{{Key: "name", Value: "Jo"}}, true},
+		{"unequal string", bson.D{{Key: "name", Value: "Al"}}, false},
+		{"number types compare by value", bson.D{{Key: "rank", Value: int64(3)}}, true},
+		{"array has element", bson.D{{Key: "tags", Value: "miner"}}, true},
+		{"array whole match", bson.D{{Key: "tags", Value: bson.A{"pilot", "miner"}}}, true},
+		{"array order matters", bson.D{{Key: "tags", Value: bson.A{"miner", "pilot"}}}, false},
+		{"dotted path", bson.D{{Key: "rsi.handle", Value: "jo_rsi"}}, true},
+		{"dotted path into array of documents", bson.D{{Key: "members._id", Value: "2"}}, true},
+		{"dotted path into array of documents no match", bson.D{{Key: "members._id", Value: "3"}}, false},
+		{"$in through array of documents", bson.D{{Key: "members._id", Value: bson.D{{Key: "$in", Value: bson.A{"3", "1"}}}}}, true},
+		{"array index", bson.D{{Key: "tags.1", Value: "miner"}}, true},
+		{"missing equals null", bson.D{{Key: "nope", Value: nil}}, true},
+		{"null equals null", bson.D{{Key: "empty", Value: nil}}, true},
+		{"present isn't null", bson.D{{Key: "name", Value: nil}}, false},
+		{"$eq", bson.D{{Key: "rank", Value: bson.D{{Key: "$eq", Value: 3}}}}, true},
+		{"$ne", bson.D{{Key: "rank", Value: bson.D{{Key: "$ne", Value: 3}}}}, false},
+		{"$ne missing", bson.D{{Key: "nope", Value: bson.D{{Key: "$ne", Value: 3}}}}, true},
+		{"$gt", bson.D{{Key: "rank", Value: bson.D{{Key: "$gt", Value: 2}}}}, true},
+		{"$lte", bson.D{{Key: "rank", Value: bson.D{{Key: "$lte", Value: 2}}}}, false},
+		{"range", bson.D{{Key: "rank", Value: bson.D{{Key: "$gte", Value: 3}, {Key: "$lt", Value: 4}}}}, true},
+		{"compare across kinds never matches", bson.D{{Key: "name", Value: bson.D{{Key: "$gt", Value: 1}}}}, false},
+		{"compare missing never matches", bson.D{{Key: "nope", Value: bson.D{{Key: "$lt", Value: 1}}}}, false},
+		{"dates", bson.D{{Key: "when", Value: bson.D{{Key: "$gte", Value: now.Add(-time.Hour)}, {Key: "$lt", Value: now.Add(time.Hour)}}}}, true},
+		{"$in", bson.D{{Key: "name", Value: bson.D{{Key: "$in", Value: bson.A{"Al", "Jo"}}}}}, true},
+		{"$in array field", bson.D{{Key: "tags", Value: bson.D{{Key: "$in", Value: bson.A{"trader", "pilot"}}}}}, true},
+		{"$nin", bson.D{{Key: "name", Value: bson.D{{Key: "$nin", Value: bson.A{"Al", "Jo"}}}}}, false},
+		{"$exists", bson.D{{Key: "empty", Value: bson.D{{Key: "$exists", Value: true}}}}, true},
+		{"$exists false", bson.D{{Key: "nope", Value: bson.D{{Key: "$exists", Value: false}}}}, true},
+		{"$not", bson.D{{Key: "rank", Value: bson.D{{Key: "$not", Value: bson.D{{Key: "$gt", Value: 5}}}}}}, true},
+		{"$regex", bson.D{{Key: "rsi.handle", Value: bson.D{{Key: "$regex", Value: "^jo_"}}}}, true},
+		{"$regex options", bson.D{{Key: "name", Value: bson.D{{Key: "$regex", Value: "^jo$"}, {Key: "$options", Value: "i"}}}}, true},
+		{"$size", bson.D{{Key: "tags", Value: bson.D{{Key: "$size", Value: 2}}}}, true},
+		{"$and", bson.D{{Key: "$and", Value: bson.A{bson.D{{Key: "rank", Value: 3}}, bson.D{{Key: "recorded", Value: true}}}}}, false},
+		{"$or", bson.D{{Key: "$or", Value: bson.A{bson.D{{Key: "rank", Value: 1}}, bson.D{{Key: "recorded", Value: false}}}}}, true},
+		{"$nor", bson.D{{Key: "$nor", Value: bson.A{bson.D{{Key: "rank", Value: 3}}}}}, false},
+		{"object id by value", bson.D{{Key: "_id", Value: id}}, true},
+		{"other object id", bson.D{{Key: "_id", Value: primitive.NewObjectID()}}, false},
+		{"unknown operator never matches", bson.D{{Key: "rank", Value: bson.D{{Key: "$mod", Value: bson.A{2, 1}}}}}, false},
+	}
+
+	stored, err := toDocument(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matched, err := filterDocuments([]bson.M{stored}, tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := len(matched) == 1; got != tt.want {
+				t.Errorf("matched = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSortDocuments(t *testing.T) {
+	docs := []bson.M{
+		{"_id": "b", "rank": 2},
+		{"_id": "missing"},
+		{"_id": "a", "rank": 1},
+		{"_id": "c", "rank": 3},
+	}
+
+	sortDocuments(docs, "rank", true)
+
+	want := []string{"missing", "a", "b", "c"}
+	for i, doc := range docs {
+		if doc["_id"] != want[i] {
+			t.Fatalf("got %v at %d, want %s", doc["_id"], i, want[i])
+		}
+	}
+}
+
+func TestReplaceMatchesObjectIds(t *testing.T) {
+	c := &memoryCollection{}
+	id := primitive.NewObjectID()
+
+	c.replace("_id", id, bson.M{"_id": id, "n": 1})
+	if replaced := c.replace("_id", id, bson.M{"_id": id, "n": 2}); !replaced {
+		t.Error("document with the same object id was not replaced")
+	}
+
+	docs := c.all()
+	if len(docs) != 1 || docs[0]["n"] != int32(2) {
+		t.Errorf("got %v, want the one replaced document", docs)
+	}
+}
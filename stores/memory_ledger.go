@@ -0,0 +1,49 @@
+package stores
+
+import "context"
+
+type memoryLedgerStore struct {
+	db *memoryDatabase
+}
+
+func newMemoryLedgerStore(db *memoryDatabase) *memoryLedgerStore {
+	return &memoryLedgerStore{db: db}
+}
+
+func (s *memoryLedgerStore) collection() *memoryCollection {
+	return s.db.collection(LEDGER)
+}
+
+func (s *memoryLedgerStore) Create(_ context.Context, entry any) error {
+	doc, err := toDocument(entry)
+	if err != nil {
+		return err
+	}
+
+	if id, ok := doc["_id"]; ok {
+		if _, exists := s.collection().find("_id", id); exists {
+			return ErrDuplicateKey
+		}
+	}
+
+	s.collection().insert(doc)
+	return nil
+}
+
+func (s *memoryLedgerStore) List(_ context.Context, filter interface{}, limit int, page int) (Cursor, error) {
+	docs, err := filterDocuments(s.collection().all(), filter)
+	if err != nil {
+		return nil, err
+	}
+
+	sortDocuments(docs, "when", false)
+
+	if limit > 0 {
+		if page == 0 {
+			page = 1
+		}
+		docs = paginate(docs, (page-1)*limit, limit)
+	}
+
+	return newMemoryCursor(docs), nil
+}
@@ -0,0 +1,151 @@
+package stores
+
+import (
+	"context"
+
+	"math/rand"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type memoryMembersStore struct {
+	db *memoryDatabase
+}
+
+func newMemoryMembersStore(db *memoryDatabase) *memoryMembersStore {
+	return &memoryMembersStore{db: db}
+}
+
+func (s *memoryMembersStore) collection() *memoryCollection {
+	return s.db.collection(MEMBERS)
+}
+
+// withRecruiter replaces the recruiter id with the recruiter's document the
+// same way the $lookup and $unwind in the mongo pipeline do
+func (s *memoryMembersStore) withRecruiter(doc bson.M) bson.M {
+	recruiterId, ok := doc["recruiter"]
+	if !ok || recruiterId == nil {
+		delete(doc, "recruiter")
+		return doc
+	}
+
+	recruiter, ok := s.collection().find("_id", recruiterId)
+	if !ok {
+		delete(doc, "recruiter")
+		return doc
+	}
+
+	doc["recruiter"] = recruiter
+	return doc
+}
+
+func (s *memoryMembersStore) Get(_ context.Context, id string) (Cursor, error) {
+	doc, ok := s.collection().find("_id", id)
+	if !ok {
+		return newMemoryCursor(nil), nil
+	}
+
+	return newMemoryCursor([]bson.M{s.withRecruiter(doc)}), nil
+}
+
+func (s *memoryMembersStore) GetRandom(_ context.Context, max int, rankNames []string) ([]map[string]interface{}, error) {
+	docs, err := filterDocuments(s.collection().all(), bson.D{
+		{Key: "rank", Value: bson.D{{Key: "$in", Value: rankNames}}},
+		{Key: "archived_at", Value: nil},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rand.Shuffle(len(docs), func(i, j int) { docs[i], docs[j] = docs[j], docs[i] })
+	docs = paginate(docs, 0, max)
+
+	members := []map[string]interface{}{}
+	for _, doc := range docs {
+		members = append(members, map[string]interface{}(doc))
+	}
+
+	return members, nil
+}
+
+func (s *memoryMembersStore) List(_ context.Context, filter interface{}, page, max int) (Cursor, error) {
+	docs, err := filterDocuments(s.collection().all(), filter)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range docs {
+		docs[i] = s.withRecruiter(docs[i])
+	}
+
+	if page > 0 {
+		docs = paginate(docs, (page-1)*max, max)
+	}
+
+	return newMemoryCursor(docs), nil
+}
+
+func (s *memoryMembersStore) Upsert(_ context.Context, id string, version int64, member any) error {
+	doc, err := toDocument(member)
+	if err != nil {
+		return err
+	}
+	doc["_id"] = id
+
+	c := s.collection()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i, existing := range c.docs {
+		if !equalValues(existing["_id"], id) {
+			continue
+		}
+
+		stored, ok := existing["version"]
+		if !ok || stored == nil {
+			stored = 0
+		}
+		if !equalValues(stored, version) {
+			return ErrVersionConflict
+		}
+
+		c.docs[i] = doc
+		c.touched()
+		return nil
+	}
+
+	c.docs = append(c.docs, doc)
+	c.touched()
+	return nil
+}
+
+func (s *memoryMembersStore) Update(_ context.Context, id string, change *Change) (int64, error) {
+	c := s.collection()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, doc := range c.docs {
+		if !equalValues(doc["_id"], id) {
+			continue
+		}
+
+		if err := change.apply(doc); err != nil {
+			return 0, err
+		}
+
+		version := int64(toFloat(doc["version"])) + 1
+		doc["version"] = version
+		c.touched()
+		return version, nil
+	}
+
+	return 0, mongo.ErrNoDocuments
+}
+
+func (s *memoryMembersStore) Delete(_ context.Context, id string) error {
+	if !s.collection().remove("_id", id) {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
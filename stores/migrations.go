@@ -0,0 +1,183 @@
+package stores
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const migrationsConfigName = "migrations"
+
+// Migration is a versioned change to the documents of a collection. Migrate is
+// called for every document in the collection and returns the change to make
+// to it, or nil to leave it alone. It must be idempotent so a migration that
+// was interrupted can safely run again.
+type Migration struct {
+	Version     int
+	Description string
+	Collection  Collection
+	Migrate     func(doc bson.M) (*Change, error)
+}
+
+// AppliedMigration is the record of a migration kept in the configs collection
+type AppliedMigration struct {
+	Version     int       `bson:"version"`
+	Description string    `bson:"description"`
+	Changed     int       `bson:"changed"`
+	AppliedAt   time.Time `bson:"applied_at"`
+}
+
+type migrationsConfig struct {
+	Name    string             `bson:"name"`
+	Applied []AppliedMigration `bson:"applied"`
+}
+
+// MigrationReport describes what a migration did, or would do on a dry run
+type MigrationReport struct {
+	Version        int
+	Description    string
+	Collection     Collection
+	AlreadyApplied bool
+	Changed        int
+}
+
+func (r MigrationReport) String() string {
+	if r.AlreadyApplied {
+		return fmt.Sprintf("%04d %s: already applied", r.Version, r.Description)
+	}
+	return fmt.Sprintf("%04d %s: %d %s document(s) changed", r.Version, r.Description, r.Changed, r.Collection)
+}
+
+var migrations = map[int]Migration{}
+
+// RegisterMigration adds a migration to be run by Migrate. Versions must be
+// unique, registering the same version twice panics.
+func RegisterMigration(m Migration) {
+	if _, ok := migrations[m.Version]; ok {
+		panic(fmt.Sprintf("migration %d registered twice", m.Version))
+	}
+	migrations[m.Version] = m
+}
+
+func orderedMigrations() []Migration {
+	ordered := make([]Migration, 0, len(migrations))
+	for _, m := range migrations {
+		ordered = append(ordered, m)
+	}
+	sort.Slice(ordered, func(i, j int) bool { return ordered[i].Version < ordered[j].Version })
+	return ordered
+}
+
+// AppliedMigrations returns the migrations that have been recorded as applied
+func (c *Client) AppliedMigrations(ctx context.Context) ([]AppliedMigration, error) {
+	configsStore, ok := c.GetConfigsStore()
+	if !ok {
+		return nil, errors.New("configs store not found")
+	}
+
+	config := &migrationsConfig{}
+	if err := configsStore.Get(ctx, migrationsConfigName).Decode(config); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return []AppliedMigration{}, nil
+		}
+		return nil, errors.Wrap(err, "getting applied migrations")
+	}
+
+	return config.Applied, nil
+}
+
+// Migrate runs every registered migration that hasn't been applied yet, in
+// version order, recording each one as it finishes. With dryRun set nothing is
+// written and the report shows what would change.
+func (c *Client) Migrate(ctx context.Context, dryRun bool) ([]MigrationReport, error) {
+	logger := log.WithFields(log.Fields{
+		"func":    "stores.Migrate",
+		"dry_run": dryRun,
+	})
+
+	configsStore, ok := c.GetConfigsStore()
+	if !ok {
+		return nil, errors.New("configs store not found")
+	}
+
+	applied, err := c.AppliedMigrations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	appliedVersions := map[int]bool{}
+	for _, a := range applied {
+		appliedVersions[a.Version] = true
+	}
+
+	reports := []MigrationReport{}
+	for _, m := range orderedMigrations() {
+		report := MigrationReport{
+			Version:        m.Version,
+			Description:    m.Description,
+			Collection:     m.Collection,
+			AlreadyApplied: appliedVersions[m.Version],
+		}
+
+		if report.AlreadyApplied {
+			reports = append(reports, report)
+			continue
+		}
+
+		logger.WithField("version", m.Version).Info("running migration")
+
+		docs := c.documents(m.Collection)
+		if err := docs.each(ctx, func(doc bson.M) error {
+			change, err := m.Migrate(doc)
+			if err != nil {
+				return errors.Wrapf(err, "migrating document %v", doc["_id"])
+			}
+
+			if change.IsEmpty() {
+				return nil
+			}
+
+			report.Changed++
+
+			if dryRun {
+				return nil
+			}
+
+			return docs.update(ctx, doc["_id"], change)
+		}); err != nil {
+			return reports, errors.Wrapf(err, "running migration %d", m.Version)
+		}
+
+		reports = append(reports, report)
+
+		if dryRun {
+			continue
+		}
+
+		applied = append(applied, AppliedMigration{
+			Version:     m.Version,
+			Description: m.Description,
+			Changed:     report.Changed,
+			AppliedAt:   time.Now().UTC(),
+		})
+		if err := configsStore.Upsert(ctx, migrationsConfigName, &migrationsConfig{
+			Name:    migrationsConfigName,
+			Applied: applied,
+		}); err != nil {
+			return reports, errors.Wrapf(err, "recording migration %d", m.Version)
+		}
+
+		logger.WithFields(log.Fields{
+			"version": m.Version,
+			"changed": report.Changed,
+		}).Info("migration applied")
+	}
+
+	return reports, nil
+}
@@ -2,107 +2,266 @@ package stores
 
 import (
 	"context"
-	"fmt"
-	"time"
+	"sync"
 
+	"github.com/apex/log"
 	"github.com/pkg/errors"
 	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type Collection string
 
+var (
+	ErrVersionConflict = errors.New("document was changed since it was read")
+)
+
 const (
 	MEMBERS    Collection = "members"
 	CONFIGS    Collection = "configs"
 	ATTENDANCE Collection = "attendance"
 	ACTIVITY   Collection = "activity"
+	AUDIT      Collection = "audit"
+	LEDGER     Collection = "ledger"
+	EVENTS     Collection = "events"
 )
 
+// Cursor iterates over the documents returned by a store query. *mongo.Cursor
+// satisfies it, as does the cursor returned by the in-memory backend.
+type Cursor interface {
+	Next(ctx context.Context) bool
+	Decode(val interface{}) error
+	All(ctx context.Context, results interface{}) error
+	Close(ctx context.Context) error
+	Err() error
+}
+
+// SingleResult is the result of a single document lookup. *mongo.SingleResult
+// satisfies it.
+type SingleResult interface {
+	Decode(v interface{}) error
+	Err() error
+}
+
+type MembersStore interface {
+	Get(ctx context.Context, id string) (Cursor, error)
+	GetRandom(ctx context.Context, max int, rankNames []string) ([]map[string]interface{}, error)
+	List(ctx context.Context, filter interface{}, page, max int) (Cursor, error)
+	// Upsert replaces the member only if the stored version still matches the
+	// given version, otherwise ErrVersionConflict is returned. The member
+	// document is expected to carry the next version.
+	Upsert(ctx context.Context, id string, version int64, member any) error
+	// Update makes a field level change to the member, bumping the version,
+	// and returns the new version
+	Update(ctx context.Context, id string, change *Change) (int64, error)
+	Delete(ctx context.Context, id string) error
+}
+
+type AttendanceStore interface {
+	Create(ctx context.Context, attendance any) error
+	Get(ctx context.Context, id string) (Cursor, error)
+	List(ctx context.Context, filter interface{}, limit int, page int) (Cursor, error)
+	// ListRecorded returns the recorded attendance the member is in, oldest
+	// first
+	ListRecorded(ctx context.Context, memberId string) (Cursor, error)
+	GetCount(ctx context.Context, memberId string) (int, error)
+	Upsert(ctx context.Context, id string, attendance any) error
+	Delete(ctx context.Context, id string) error
+}
+
+type ActivityStore interface {
+	Create(ctx context.Context, activity any) error
+	// List returns the activity matching the filter, oldest first
+	List(ctx context.Context, filter interface{}) (Cursor, error)
+}
+
+type AuditStore interface {
+	Create(ctx context.Context, entry any) error
+	// List returns the entries matching the filter, newest first
+	List(ctx context.Context, filter interface{}, limit int, page int) (Cursor, error)
+}
+
+type LedgerStore interface {
+	Create(ctx context.Context, entry any) error
+	// List returns the entries matching the filter, newest first
+	List(ctx context.Context, filter interface{}, limit int, page int) (Cursor, error)
+}
+
+type EventsStore interface {
+	Get(ctx context.Context, id string) SingleResult
+	// List returns the events matching the filter, soonest first
+	List(ctx context.Context, filter interface{}, limit int, page int) (Cursor, error)
+	Upsert(ctx context.Context, id string, event any) error
+}
+
+type ConfigsStore interface {
+	Create(ctx context.Context, config any) error
+	Get(ctx context.Context, name string) SingleResult
+	Upsert(ctx context.Context, name string, config any) error
+}
+
 type store struct {
 	*mongo.Collection
-	ctx context.Context
 }
 
 type Client struct {
 	*mongo.Client
 
 	databases map[Collection]interface{}
+	database  string
+	memory    *memoryDatabase
+	file      *fileDatabase
+
+	indexStatus []IndexStatus
+	indexesMu   sync.RWMutex
 
 	ctx context.Context
 }
 
 var client *Client
 
-func New(ctx context.Context, host string, port int, username string, password string, database string) (*Client, error) {
-	usernamePassword := username + ":" + password + "@"
-	if usernamePassword == ":@" {
-		usernamePassword = ""
+// New connects to mongo with the given config and sets up every store
+func New(ctx context.Context, cfg Config) (*Client, error) {
+	c, err := Connect(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	client = c
+	return client, nil
+}
+
+// Connect is New without making the client the one Get returns, for when a
+// second backend is needed like when copying between them
+func Connect(ctx context.Context, cfg Config) (*Client, error) {
+	clientOptions, err := cfg.clientOptions()
+	if err != nil {
+		return nil, err
 	}
 
-	uri := fmt.Sprintf("mongodb://%s%s:%d",
-		usernamePassword,
-		host,
-		port)
+	database := cfg.Database
+	log.WithFields(logFields(clientOptions, database)).Info("connecting to mongo")
 
-	clientOptions := options.Client().ApplyURI(uri).SetConnectTimeout(5 * time.Second)
 	mongoClient, err := mongo.Connect(ctx, clientOptions)
 	if err != nil {
 		return nil, errors.Wrap(err, "creating new store")
 	}
 
-	client = &Client{
+	c := &Client{
 		Client:    mongoClient,
 		databases: map[Collection]interface{}{},
+		database:  database,
 		ctx:       ctx,
 	}
 
-	if ok := client.Connected(); !ok {
+	if ok := c.Connected(); !ok {
 		return nil, errors.New("unable to connect to store")
 	}
 
-	client.databases[MEMBERS] = newMembersStore(ctx, client.Client, database)
-	client.databases[CONFIGS] = newConfigsStore(ctx, client.Client, database)
-	client.databases[ATTENDANCE] = newAttendanceStore(ctx, client.Client, database)
-	client.databases[ACTIVITY] = newActivityStore(ctx, client.Client, database)
+	c.databases[MEMBERS] = newMembersStore(ctx, c.Client, database)
+	c.databases[CONFIGS] = newConfigsStore(ctx, c.Client, database)
+	c.databases[ATTENDANCE] = newAttendanceStore(ctx, c.Client, database)
+	c.databases[ACTIVITY] = newActivityStore(ctx, c.Client, database)
+	c.databases[AUDIT] = newAuditStore(ctx, c.Client, database)
+	c.databases[LEDGER] = newLedgerStore(ctx, c.Client, database)
+	c.databases[EVENTS] = newEventsStore(ctx, c.Client, database)
 
-	return client, nil
+	c.EnsureIndexes(ctx)
+
+	return c, nil
+}
+
+// NewMemory creates a client backed by the in-memory stores. Nothing is
+// persisted, which makes it useful for tests and local development.
+func NewMemory(ctx context.Context) *Client {
+	client = newMemoryClient(ctx)
+	return client
+}
+
+func newMemoryClient(ctx context.Context) *Client {
+	db := newMemoryDatabase()
+
+	c := &Client{
+		databases: map[Collection]interface{}{},
+		memory:    db,
+		ctx:       ctx,
+	}
+
+	c.databases[MEMBERS] = newMemoryMembersStore(db)
+	c.databases[CONFIGS] = newMemoryConfigsStore(db)
+	c.databases[ATTENDANCE] = newMemoryAttendanceStore(db)
+	c.databases[ACTIVITY] = newMemoryActivityStore(db)
+	c.databases[AUDIT] = newMemoryAuditStore(db)
+	c.databases[LEDGER] = newMemoryLedgerStore(db)
+	c.databases[EVENTS] = newMemoryEventsStore(db)
+
+	return c
 }
 
 func Get() *Client {
 	return client
 }
 
-func (c *Client) GetMembersStore() (*MembersStore, bool) {
+func (c *Client) GetMembersStore() (MembersStore, bool) {
 	storeInterface, ok := c.GetCollection(MEMBERS)
 	if !ok {
 		return nil, false
 	}
-	return storeInterface.(*MembersStore), ok
+	st, ok := storeInterface.(MembersStore)
+	return st, ok
 }
 
-func (c *Client) GetConfigsStore() (*ConfigsStore, bool) {
+func (c *Client) GetConfigsStore() (ConfigsStore, bool) {
 	storeInterface, ok := c.GetCollection(CONFIGS)
 	if !ok {
 		return nil, false
 	}
-	return storeInterface.(*ConfigsStore), ok
+	st, ok := storeInterface.(ConfigsStore)
+	return st, ok
 }
 
-func (c *Client) GetAttendanceStore() (*AttendanceStore, bool) {
+func (c *Client) GetAttendanceStore() (AttendanceStore, bool) {
 	storeInterface, ok := c.GetCollection(ATTENDANCE)
 	if !ok {
 		return nil, false
 	}
-	return storeInterface.(*AttendanceStore), ok
+	st, ok := storeInterface.(AttendanceStore)
+	return st, ok
 }
 
-func (c *Client) GetActivityStore() (*ActivityStore, bool) {
+func (c *Client) GetActivityStore() (ActivityStore, bool) {
 	storeInterface, ok := c.GetCollection(ACTIVITY)
 	if !ok {
 		return nil, false
 	}
-	return storeInterface.(*ActivityStore), ok
+	st, ok := storeInterface.(ActivityStore)
+	return st, ok
+}
+
+func (c *Client) GetAuditStore() (AuditStore, bool) {
+	storeInterface, ok := c.GetCollection(AUDIT)
+	if !ok {
+		return nil, false
+	}
+	st, ok := storeInterface.(AuditStore)
+	return st, ok
+}
+
+func (c *Client) GetLedgerStore() (LedgerStore, bool) {
+	storeInterface, ok := c.GetCollection(LEDGER)
+	if !ok {
+		return nil, false
+	}
+	st, ok := storeInterface.(LedgerStore)
+	return st, ok
+}
+
+func (c *Client) GetEventsStore() (EventsStore, bool) {
+	storeInterface, ok := c.GetCollection(EVENTS)
+	if !ok {
+		return nil, false
+	}
+	st, ok := storeInterface.(EventsStore)
+	return st, ok
 }
 
 func (c *Client) GetCollection(collection Collection) (interface{}, bool) {
@@ -112,13 +271,78 @@ func (c *Client) GetCollection(collection Collection) (interface{}, bool) {
 	return c.databases[collection], true
 }
 
+// cursor converts the result of a mongo query into a Cursor without wrapping a
+// nil *mongo.Cursor in a non-nil interface
+func cursor(cur *mongo.Cursor, err error) (Cursor, error) {
+	if err != nil {
+		return nil, timeout(err)
+	}
+	return &mongoCursor{cur}, nil
+}
+
+// mongoCursor reports timeouts while iterating as a TimeoutError
+type mongoCursor struct {
+	*mongo.Cursor
+}
+
+func (c *mongoCursor) All(ctx context.Context, results interface{}) error {
+	return timeout(c.Cursor.All(ctx, results))
+}
+
+func (c *mongoCursor) Err() error {
+	return timeout(c.Cursor.Err())
+}
+
+// mongoSingleResult reports a lookup that timed out as a TimeoutError
+type mongoSingleResult struct {
+	*mongo.SingleResult
+}
+
+func (r *mongoSingleResult) Decode(v interface{}) error {
+	return timeout(r.SingleResult.Decode(v))
+}
+
+func (r *mongoSingleResult) Err() error {
+	return timeout(r.SingleResult.Err())
+}
+
 func (c *Client) Disconnect() {
+	if c.file != nil {
+		if err := c.file.close(); err != nil {
+			log.WithError(err).Error("saving the store file")
+		}
+	}
+	if c.Client == nil {
+		return
+	}
 	_ = c.Client.Disconnect(c.ctx)
 }
 
 func (c *Client) Connected() bool {
+	if c.Client == nil { // in-memory stores are always available
+		return true
+	}
 	if err := c.Ping(c.ctx, nil); err != nil {
 		return false
 	}
 	return true
 }
+
+// make sure both backends keep up with the interfaces, the getters only find
+// out at runtime
+var (
+	_ MembersStore    = (*mongoMembersStore)(nil)
+	_ MembersStore    = (*memoryMembersStore)(nil)
+	_ AttendanceStore = (*mongoAttendanceStore)(nil)
+	_ AttendanceStore = (*memoryAttendanceStore)(nil)
+	_ ActivityStore   = (*mongoActivityStore)(nil)
+	_ ActivityStore   = (*memoryActivityStore)(nil)
+	_ AuditStore      = (*mongoAuditStore)(nil)
+	_ AuditStore      = (*memoryAuditStore)(nil)
+	_ LedgerStore     = (*mongoLedgerStore)(nil)
+	_ LedgerStore     = (*memoryLedgerStore)(nil)
+	_ EventsStore     = (*mongoEventsStore)(nil)
+	_ EventsStore     = (*memoryEventsStore)(nil)
+	_ ConfigsStore    = (*mongoConfigsStore)(nil)
+	_ ConfigsStore    = (*memoryConfigsStore)(nil)
+)
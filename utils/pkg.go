@@ -100,3 +100,12 @@ func GenerateRandomAlphaNumeric(size int) string {
 func StringPointer(s string) *string {
 	return &s
 }
+
+// Float64Pointer returns a pointer to the input float64.
+//
+// Parameters:
+// - f: the input float64
+// Return type: *float64
+func Float64Pointer(f float64) *float64 {
+	return &f
+}